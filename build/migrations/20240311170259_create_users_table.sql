@@ -13,5 +13,5 @@ CREATE TABLE IF NOT EXISTS users (
 
 -- +goose Down
 -- +goose StatementBegin
-DROP TABLE IF EXISTS followers;
+DROP TABLE IF EXISTS users;
 -- +goose StatementEnd
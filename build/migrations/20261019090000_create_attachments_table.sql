@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS attachments (
+    id serial PRIMARY KEY,
+    owner uuid NOT NULL,
+    post_id int,
+    storage_key varchar(255) NOT NULL UNIQUE,
+    thumbnail_key varchar(255) NOT NULL,
+    content_type varchar(50) NOT NULL,
+    size bigint NOT NULL,
+    width int NOT NULL,
+    height int NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (owner) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS attachments_post_id_idx ON attachments (post_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS attachments;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS bio varchar(160) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS avatar varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS website varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS location varchar(30) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS pronouns varchar(30) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS bio,
+    DROP COLUMN IF EXISTS avatar,
+    DROP COLUMN IF EXISTS website,
+    DROP COLUMN IF EXISTS location,
+    DROP COLUMN IF EXISTS pronouns;
+-- +goose StatementEnd
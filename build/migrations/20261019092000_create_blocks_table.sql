@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS blocks (
+    user_id uuid NOT NULL,
+    blocked uuid NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked) REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, blocked)
+);
+CREATE INDEX IF NOT EXISTS followers_follower_idx ON followers (follower);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS followers_follower_idx;
+DROP TABLE IF EXISTS blocks;
+-- +goose StatementEnd
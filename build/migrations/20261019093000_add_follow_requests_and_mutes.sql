@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS protected boolean NOT NULL DEFAULT false;
+ALTER TABLE followers ADD COLUMN IF NOT EXISTS accepted boolean NOT NULL DEFAULT true;
+
+CREATE TABLE IF NOT EXISTS mutes (
+    user_id uuid NOT NULL,
+    muted uuid NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (muted) REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, muted)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mutes;
+ALTER TABLE followers DROP COLUMN IF EXISTS accepted;
+ALTER TABLE users DROP COLUMN IF EXISTS protected;
+-- +goose StatementEnd
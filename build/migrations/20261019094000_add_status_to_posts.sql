@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS status varchar(10) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'scheduled', 'published'));
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at timestamptz;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS published_at timestamptz;
+UPDATE posts SET published_at = created_at WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS posts_status_publish_at_idx ON posts (status, publish_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS posts_status_publish_at_idx;
+ALTER TABLE posts DROP COLUMN IF EXISTS published_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS edited_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id serial PRIMARY KEY,
+    post_id int NOT NULL,
+    title varchar(100) NOT NULL,
+    content varchar(500) NOT NULL,
+    replaced_at timestamptz NOT NULL DEFAULT now(),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS post_revisions_post_id_idx ON post_revisions (post_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS post_revisions;
+ALTER TABLE posts DROP COLUMN IF EXISTS edited_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS visibility varchar(10) NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'direct'));
+
+CREATE TABLE IF NOT EXISTS post_mentions (
+    post_id int NOT NULL,
+    user_id uuid NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS post_mentions_user_id_idx ON post_mentions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS post_mentions;
+ALTER TABLE posts DROP COLUMN IF EXISTS visibility;
+-- +goose StatementEnd
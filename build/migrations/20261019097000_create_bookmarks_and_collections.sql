@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS bookmarks (
+    user_id uuid NOT NULL,
+    post_id int NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE IF NOT EXISTS collections (
+    id serial PRIMARY KEY,
+    owner uuid NOT NULL,
+    name varchar(50) NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (owner) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS collections_owner_idx ON collections (owner);
+
+CREATE TABLE IF NOT EXISTS collection_posts (
+    collection_id int NOT NULL,
+    post_id int NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    PRIMARY KEY (collection_id, post_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS collection_posts;
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS bookmarks;
+-- +goose StatementEnd
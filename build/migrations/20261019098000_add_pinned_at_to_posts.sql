@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS pinned_at timestamptz;
+CREATE INDEX IF NOT EXISTS posts_author_pinned_at_idx ON posts (author, pinned_at) WHERE pinned_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS posts_author_pinned_at_idx;
+ALTER TABLE posts DROP COLUMN IF EXISTS pinned_at;
+-- +goose StatementEnd
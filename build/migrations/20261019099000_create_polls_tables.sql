@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS polls (
+    id serial PRIMARY KEY,
+    post_id int NOT NULL UNIQUE,
+    multiple boolean NOT NULL DEFAULT false,
+    hide_results boolean NOT NULL DEFAULT false,
+    expires_at timestamptz NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id serial PRIMARY KEY,
+    poll_id int NOT NULL,
+    position smallint NOT NULL,
+    text varchar(50) NOT NULL,
+    FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS poll_options_poll_id_idx ON poll_options (poll_id);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id int NOT NULL,
+    option_id int NOT NULL,
+    user_id uuid NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+    FOREIGN KEY (option_id) REFERENCES poll_options(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (option_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS poll_votes_poll_id_user_id_idx ON poll_votes (poll_id, user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS poll_votes;
+DROP TABLE IF EXISTS poll_options;
+DROP TABLE IF EXISTS polls;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lists (
+    id serial PRIMARY KEY,
+    owner uuid NOT NULL,
+    name varchar(50) NOT NULL,
+    private boolean NOT NULL DEFAULT false,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (owner) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS lists_owner_idx ON lists (owner);
+
+CREATE TABLE IF NOT EXISTS list_members (
+    list_id int NOT NULL,
+    user_id uuid NOT NULL,
+    created_at timestamp default current_timestamp,
+    FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (list_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS timelines (
+    user_id uuid NOT NULL,
+    post_id int NOT NULL,
+    author uuid NOT NULL,
+    published_at timestamptz,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, post_id)
+);
+CREATE INDEX IF NOT EXISTS timelines_user_id_published_at_idx ON timelines (user_id, published_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS timelines_user_id_author_idx ON timelines (user_id, author);
+
+INSERT INTO timelines (user_id, post_id, author, published_at)
+SELECT p.author, p.id, p.author, p.published_at FROM posts p WHERE p.status = 'published'
+UNION ALL
+SELECT f.follower, p.id, p.author, p.published_at FROM posts p
+JOIN followers f ON f.user_id = p.author AND f.accepted
+WHERE p.status = 'published'
+ON CONFLICT (user_id, post_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS timelines;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS bookmarks_post_id_idx ON bookmarks (post_id);
+CREATE INDEX IF NOT EXISTS poll_votes_user_id_idx ON poll_votes (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS poll_votes_user_id_idx;
+DROP INDEX IF EXISTS bookmarks_post_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stories (
+    id serial PRIMARY KEY,
+    author uuid NOT NULL,
+    content varchar(300) NOT NULL DEFAULT '',
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz default current_timestamp,
+    FOREIGN KEY (author) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS stories_author_expires_at_idx ON stories (author, expires_at);
+CREATE INDEX IF NOT EXISTS stories_expires_at_idx ON stories (expires_at);
+
+CREATE TABLE IF NOT EXISTS story_views (
+    story_id int NOT NULL,
+    viewer uuid NOT NULL,
+    viewed_at timestamptz default current_timestamp,
+    FOREIGN KEY (story_id) REFERENCES stories(id) ON DELETE CASCADE,
+    FOREIGN KEY (viewer) REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (story_id, viewer)
+);
+
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS story_id int REFERENCES stories(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS attachments_story_id_idx ON attachments (story_id) WHERE story_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS attachments_story_id_idx;
+ALTER TABLE attachments DROP COLUMN IF EXISTS story_id;
+DROP TABLE IF EXISTS story_views;
+DROP TABLE IF EXISTS stories;
+-- +goose StatementEnd
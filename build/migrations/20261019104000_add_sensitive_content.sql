@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS content_warning varchar(100) NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS sensitive boolean NOT NULL DEFAULT false;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS sensitive_by_moderator boolean NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS expand_sensitive boolean NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS moderator boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS moderator;
+ALTER TABLE users DROP COLUMN IF EXISTS expand_sensitive;
+ALTER TABLE posts DROP COLUMN IF EXISTS sensitive_by_moderator;
+ALTER TABLE posts DROP COLUMN IF EXISTS sensitive;
+ALTER TABLE posts DROP COLUMN IF EXISTS content_warning;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Buckets are cheap to lose, so the table skips the write-ahead log.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key text PRIMARY KEY,
+    tokens double precision NOT NULL,
+    updated_at timestamptz NOT NULL,
+    full_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limits_full_at_idx ON rate_limits (full_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_limits;
+-- +goose StatementEnd
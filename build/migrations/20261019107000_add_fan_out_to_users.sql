@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS followers_count int NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS fan_out boolean NOT NULL DEFAULT true;
+
+UPDATE users u SET followers_count = (SELECT count(*) FROM followers f WHERE f.user_id = u.id AND f.accepted);
+-- Switches accounts past the default FANOUT_MAX_FOLLOWERS to merge on read. With another limit, run
+-- rebuild-timelines once migrated to apply it.
+UPDATE users SET fan_out = followers_count < 10000;
+
+CREATE OR REPLACE FUNCTION count_followers() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        IF OLD.accepted THEN
+            UPDATE users SET followers_count = followers_count - 1 WHERE id = OLD.user_id;
+        END IF;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.accepted THEN
+            UPDATE users SET followers_count = followers_count + 1 WHERE id = NEW.user_id;
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER followers_count AFTER INSERT OR DELETE OR UPDATE OF accepted ON followers
+FOR EACH ROW EXECUTE FUNCTION count_followers();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS followers_count ON followers;
+DROP FUNCTION IF EXISTS count_followers();
+ALTER TABLE users DROP COLUMN IF EXISTS fan_out;
+ALTER TABLE users DROP COLUMN IF EXISTS followers_count;
+-- +goose StatementEnd
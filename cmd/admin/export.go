@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// export is the data of an account, as the account itself would see it through the API.
+type export struct {
+	ExportedAt  time.Time          `json:"exportedAt"`
+	Profile     entity.Profile     `json:"profile"`
+	Posts       []entity.Post      `json:"posts"`
+	Drafts      []entity.Post      `json:"drafts"`
+	Followers   []entity.User      `json:"followers"`
+	Following   []entity.User      `json:"following"`
+	Bookmarks   []entity.Post      `json:"bookmarks"`
+	Collections []exportCollection `json:"collections"`
+	Lists       []exportList       `json:"lists"`
+}
+
+type exportCollection struct {
+	entity.Collection
+	PostIds []uint64 `json:"postIds"`
+}
+
+type exportList struct {
+	entity.List
+	MemberIds []string `json:"memberIds"`
+}
+
+// exportUser writes the data of an account as JSON, to answer data access requests.
+func exportUser(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export-user", flag.ContinueOnError)
+	output := flags.String("o", "", "file to write, stdout when empty")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: socialnets-admin export-user [-o file] <user id or nick>")
+		flags.PrintDefaults()
+	}
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		return err
+	}
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	userId, err := resolveUser(ctx, userUseCase, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	data := export{ExportedAt: time.Now().UTC()}
+	if data.Profile, err = userUseCase.GetProfile(ctx, userId, userId); err != nil {
+		return err
+	}
+	if data.Followers, err = userUseCase.GetFollowers(ctx, userId); err != nil {
+		return err
+	}
+	if data.Following, err = userUseCase.GetFollowing(ctx, userId); err != nil {
+		return err
+	}
+
+	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
+	if data.Posts, err = postUseCase.GetUserPosts(ctx, userId, userId); err != nil {
+		return err
+	}
+	if data.Drafts, err = postUseCase.GetDrafts(ctx, userId); err != nil {
+		return err
+	}
+
+	bookmarkUseCase := usecase.NewBookmarkUseCase(repository.NewBookmarkRepository(db), repository.NewPostRepository(db))
+	if data.Bookmarks, err = allPages(func(page int) ([]entity.Post, error) {
+		return bookmarkUseCase.Get(ctx, userId, page, usecase.MaxPageSize)
+	}); err != nil {
+		return err
+	}
+	collections, err := bookmarkUseCase.GetCollections(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		posts, err := allPages(func(page int) ([]entity.Post, error) {
+			_, posts, err := bookmarkUseCase.GetCollection(ctx, userId, collection.Id, page, usecase.MaxPageSize)
+			return posts, err
+		})
+		if err != nil {
+			return err
+		}
+		data.Collections = append(data.Collections, exportCollection{Collection: collection, PostIds: postIds(posts)})
+	}
+
+	listUseCase := usecase.NewListUseCase(repository.NewListRepository(db))
+	lists, err := listUseCase.GetLists(ctx, userId, userId)
+	if err != nil {
+		return err
+	}
+	for _, list := range lists {
+		members, err := listUseCase.GetMembers(ctx, list.Id, userId)
+		if err != nil {
+			return err
+		}
+		memberIds := make([]string, 0, len(members))
+		for _, member := range members {
+			memberIds = append(memberIds, member.Id)
+		}
+		data.Lists = append(data.Lists, exportList{List: list, MemberIds: memberIds})
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(data); err != nil {
+		return err
+	}
+
+	slog.Info("user exported", "user_id", userId, "posts", len(data.Posts), "bookmarks", len(data.Bookmarks))
+	return nil
+}
+
+// allPages calls fetch with increasing pages of usecase.MaxPageSize until a page comes back short.
+func allPages(fetch func(page int) ([]entity.Post, error)) ([]entity.Post, error) {
+	var all []entity.Post
+	for page := 1; ; page++ {
+		posts, err := fetch(page)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, posts...)
+		if len(posts) < usecase.MaxPageSize {
+			return all, nil
+		}
+	}
+}
+
+func postIds(posts []entity.Post) []uint64 {
+	ids := make([]uint64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+	}
+
+	return ids
+}
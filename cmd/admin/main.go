@@ -0,0 +1,160 @@
+// Command admin runs operational tasks against the database of the API: managing accounts, removing posts, rebuilding
+// derived data, exporting a user's data and seeding development databases. It goes through the same use cases as the
+// API, so the rules enforced there apply here too.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+)
+
+const usage = `usage: socialnets-admin <command> [flags]
+
+commands:
+  create-user        create an account
+  reset-password     set a new password for an account
+  suspend            suspend an account, so it can't log in
+  unsuspend          reinstate a suspended account
+  moderator          grant or revoke the moderator role
+  delete-post        delete a post and its media
+  rebuild-timelines  recompute materialized home timelines
+  export-user        write the data of an account as JSON
+  seed               fill a development database with fake users, follows and posts
+
+Run socialnets-admin <command> -h for the flags of a command.`
+
+var uuidPattern = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+// errUsage is returned by commands called with wrong arguments, after the flag set printed its usage.
+var errUsage = errors.New("invalid arguments")
+
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"create-user":       createUser,
+	"reset-password":    resetPassword,
+	"suspend":           suspend,
+	"unsuspend":         unsuspend,
+	"moderator":         moderator,
+	"delete-post":       deletePost,
+	"rebuild-timelines": rebuildTimelines,
+	"export-user":       exportUser,
+	"seed":              seed,
+}
+
+func main() {
+	// Arguments are checked first, so usage is printed even without a configuration.
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "-h", "-help", "--help", "help":
+		fmt.Println(usage)
+		return
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s\n", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if wantsHelp(os.Args[2:]) {
+		// Commands parse their flags first, so they print them and return before needing the configuration.
+		run(context.Background(), os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stderr, cfg.LogLevel))
+	if err = database.Open(cfg.Database); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = run(config.WithConfig(context.Background(), cfg), os.Args[2:])
+	if closeErr := database.Close(); closeErr != nil {
+		slog.Error("closing database", "error", closeErr)
+	}
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error(os.Args[1]+" failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// wantsHelp reports whether the flags of a command are asked for.
+func wantsHelp(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return arg == "-h" || arg == "-help" || arg == "--help"
+	})
+}
+
+// parse parses the flags of a command, which takes nargs positional arguments.
+func parse(flags *flag.FlagSet, args []string, nargs int) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != nargs {
+		flags.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+func newUserUseCase() (*usecase.UserUseCase, error) {
+	db, err := database.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.NewUserUseCase(repository.NewUserRepository(db)), nil
+}
+
+func newPostUseCase() (*usecase.PostUseCase, error) {
+	db, err := database.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.NewPostUseCase(repository.NewPostRepository(db)), nil
+}
+
+// resolveUser returns the id of the account identified by its id or its exact nick.
+func resolveUser(ctx context.Context, userUseCase *usecase.UserUseCase, idOrNick string) (string, error) {
+	if uuidPattern.MatchString(idOrNick) {
+		user, err := userUseCase.GetById(ctx, idOrNick)
+		if err != nil {
+			return "", fmt.Errorf("user %s: %w", idOrNick, err)
+		}
+
+		return user.Id, nil
+	}
+
+	users, err := userUseCase.GetByNameOrNick(ctx, idOrNick)
+	if err != nil {
+		return "", err
+	}
+	for _, user := range users {
+		if user.Nick == idOrNick {
+			return user.Id, nil
+		}
+	}
+
+	return "", fmt.Errorf("user %s not found", idOrNick)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/storage"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"log/slog"
+	"strconv"
+)
+
+// deletePost removes a post of any author along with its bookmarks, timeline entries and media.
+func deletePost(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("delete-post", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: socialnets-admin delete-post <post id>")
+	}
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+	postId, err := strconv.ParseUint(flags.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid post id %q", flags.Arg(0))
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		return err
+	}
+	cfg := config.FromContext(ctx)
+	blobStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	mediaUseCase := usecase.NewMediaUseCase(repository.NewMediaRepository(db), blobStore, cfg.MediaMaxSize)
+	posts := []entity.Post{{Id: postId}}
+	if err = mediaUseCase.LoadAttachments(ctx, posts); err != nil {
+		return err
+	}
+
+	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
+	if err = postUseCase.Remove(ctx, postId); err != nil {
+		return err
+	}
+	if err = mediaUseCase.Purge(ctx, posts[0].Attachments); err != nil {
+		return err
+	}
+
+	slog.Info("post deleted", "post_id", postId, "attachments", len(posts[0].Attachments))
+	return nil
+}
+
+// rebuildTimelines recomputes the materialized home timeline of one user, or of everyone. Follower, following and
+// like counts are read from their source tables, so timelines are the only derived data to rebuild.
+func rebuildTimelines(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("rebuild-timelines", flag.ContinueOnError)
+	user := flags.String("user", "", "id or nick of the user to rebuild, every user when empty")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	var userId string
+	if *user != "" {
+		userUseCase, err := newUserUseCase()
+		if err != nil {
+			return err
+		}
+		if userId, err = resolveUser(ctx, userUseCase, *user); err != nil {
+			return err
+		}
+	}
+
+	postUseCase, err := newPostUseCase()
+	if err != nil {
+		return err
+	}
+	if err = postUseCase.RebuildTimelines(ctx, userId); err != nil {
+		return err
+	}
+
+	slog.Info("timelines rebuilt", "user_id", userId)
+	return nil
+}
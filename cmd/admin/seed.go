@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/lib/pq"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+)
+
+var (
+	firstNames = []string{
+		"Ana", "Bruno", "Carla", "Daniel", "Elisa", "Felipe", "Gabriela", "Hugo", "Isabela", "Joao", "Karina", "Lucas",
+		"Mariana", "Nicolas", "Olivia", "Pedro", "Rafaela", "Samuel", "Tatiana", "Vitor", "Amelia", "Ben", "Chloe",
+		"David", "Emma", "Frank", "Grace", "Henry", "Iris", "Jack", "Laura", "Mason", "Nora", "Oscar", "Ruby", "Theo",
+	}
+	lastNames = []string{
+		"Silva", "Santos", "Oliveira", "Souza", "Lima", "Pereira", "Costa", "Almeida", "Ferreira", "Rodrigues", "Gomes",
+		"Martins", "Araujo", "Barbosa", "Smith", "Johnson", "Brown", "Taylor", "Wilson", "Clarke", "Walker", "Young",
+	}
+	bios = []string{
+		"Coffee first, code later.", "Runner, reader and amateur photographer.", "Backend developer. Opinions are my own.",
+		"Plants, cats and too many browser tabs.", "Learning something new every day.", "", "",
+	}
+	topics = []string{
+		"Go", "PostgreSQL", "weekend hiking", "sourdough", "the new cafe downtown", "my reading list", "remote work",
+		"board games", "morning runs", "open source", "home office setups", "street photography",
+	}
+	titles = []string{
+		"Thoughts on %s", "A quick note about %s", "Why I keep coming back to %s", "Today I learned: %s",
+		"Ask me anything about %s", "Small wins with %s",
+	}
+	contents = []string{
+		"Spent the whole afternoon with %s and I'm still impressed by how much there is to learn.",
+		"Hot take: %s is underrated. Change my mind.",
+		"If you're getting started with %s, take it slow and enjoy the process.",
+		"Three things I wish I knew earlier about %s: patience, practice and good company.",
+		"Anyone else spending way too much time on %s this week?",
+	}
+)
+
+// seed fills a development database with users following each other and publishing posts. Follows and posts go
+// through the use cases, so timelines and counters end up as the API would leave them.
+func seed(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := flags.Int("users", 50, "number of users to create")
+	follows := flags.Int("follows", 10, "average number of accounts each user follows")
+	posts := flags.Int("posts", 5, "average number of posts per user")
+	password := flags.String("password", "password", "password of every seeded user")
+	randomSeed := flags.Uint64("seed", 0, "seed of the generator, for reproducible data; random when 0")
+	force := flags.Bool("force", false, "seed even when ENVIRONMENT is PROD")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *users < 1 || *follows < 0 || *posts < 0 {
+		flags.Usage()
+		return errUsage
+	}
+	if config.FromContext(ctx).Production() && !*force {
+		return errors.New("refusing to seed a production database, pass -force to do it anyway")
+	}
+
+	if *randomSeed == 0 {
+		*randomSeed = rand.Uint64()
+	}
+	rng := rand.New(rand.NewPCG(*randomSeed, *randomSeed))
+
+	userUseCase, err := newUserUseCase()
+	if err != nil {
+		return err
+	}
+	postUseCase, err := newPostUseCase()
+	if err != nil {
+		return err
+	}
+
+	userIds := make([]string, 0, *users)
+	for range *users {
+		userId, err := seedUser(ctx, rng, *password, func(user *entity.User) error {
+			return userUseCase.Register(ctx, user)
+		})
+		if err != nil {
+			return err
+		}
+		userIds = append(userIds, userId)
+	}
+
+	followCount := 0
+	for _, follower := range userIds {
+		for _, i := range rng.Perm(len(userIds))[:min(rng.IntN(2**follows+1), len(userIds))] {
+			if userIds[i] == follower {
+				continue
+			}
+			if err = userUseCase.Follow(ctx, userIds[i], follower); err != nil {
+				return err
+			}
+			followCount++
+		}
+	}
+
+	postCount := 0
+	for _, authorId := range userIds {
+		for range rng.IntN(2**posts + 1) {
+			topic := topics[rng.IntN(len(topics))]
+			post := entity.Post{
+				AuthorId: authorId,
+				Title:    fmt.Sprintf(titles[rng.IntN(len(titles))], topic),
+				Content:  fmt.Sprintf(contents[rng.IntN(len(contents))], topic),
+			}
+			if err = postUseCase.CreatePost(ctx, &post); err != nil {
+				return err
+			}
+			postCount++
+		}
+	}
+
+	slog.Info("database seeded", "seed", *randomSeed, "users", len(userIds), "follows", followCount, "posts", postCount)
+	return nil
+}
+
+// seedUser registers a user with a random name, retrying with another nick when it's taken.
+func seedUser(ctx context.Context, rng *rand.Rand, password string, register func(user *entity.User) error) (string, error) {
+	for attempt := 0; ; attempt++ {
+		first, last := firstNames[rng.IntN(len(firstNames))], lastNames[rng.IntN(len(lastNames))]
+		nick := fmt.Sprintf("%s_%s%d", strings.ToLower(first), strings.ToLower(last), rng.IntN(10000))
+		user := entity.User{
+			Name:     first + " " + last,
+			Nick:     nick,
+			Email:    nick + "@example.com",
+			Password: password,
+			Bio:      bios[rng.IntN(len(bios))],
+		}
+
+		err := register(&user)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" && attempt < 5 {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return user.Id, nil
+	}
+}
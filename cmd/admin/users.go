@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"log/slog"
+)
+
+// createUser registers an account, optionally as a moderator.
+func createUser(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("create-user", flag.ContinueOnError)
+	name := flags.String("name", "", "display name (required)")
+	nick := flags.String("nick", "", "nick (required)")
+	email := flags.String("email", "", "email (required)")
+	password := flags.String("password", "", "password, generated and printed when empty")
+	isModerator := flags.Bool("moderator", false, "grant the moderator role")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	userUseCase, err := newUserUseCase()
+	if err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		if *password, err = randomPassword(); err != nil {
+			return err
+		}
+	}
+
+	// The role is saved along the account, so a failure doesn't leave it created without the role.
+	user := entity.User{Name: *name, Nick: *nick, Email: *email, Password: *password, Moderator: *isModerator}
+	if err = userUseCase.Register(ctx, &user); err != nil {
+		return err
+	}
+
+	slog.Info("user created", "user_id", user.Id, "moderator", *isModerator)
+	fmt.Println(user.Id)
+	if generated {
+		fmt.Println(*password)
+	}
+
+	return nil
+}
+
+// resetPassword sets a new password for an account, without asking for the current one.
+func resetPassword(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	password := flags.String("password", "", "new password, generated and printed when empty")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: socialnets-admin reset-password [-password p] <user id or nick>")
+		flags.PrintDefaults()
+	}
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	userUseCase, err := newUserUseCase()
+	if err != nil {
+		return err
+	}
+	userId, err := resolveUser(ctx, userUseCase, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		if *password, err = randomPassword(); err != nil {
+			return err
+		}
+	}
+	if err = userUseCase.ResetPassword(ctx, userId, *password); err != nil {
+		return err
+	}
+
+	slog.Info("password reset", "user_id", userId)
+	if generated {
+		fmt.Println(*password)
+	}
+
+	return nil
+}
+
+func suspend(ctx context.Context, args []string) error {
+	return setSuspended(ctx, "suspend", args, true)
+}
+
+func unsuspend(ctx context.Context, args []string) error {
+	return setSuspended(ctx, "unsuspend", args, false)
+}
+
+func setSuspended(ctx context.Context, command string, args []string, suspended bool) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: socialnets-admin %s <user id or nick>\n", command)
+	}
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	userUseCase, err := newUserUseCase()
+	if err != nil {
+		return err
+	}
+	userId, err := resolveUser(ctx, userUseCase, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err = userUseCase.SetSuspended(ctx, userId, suspended); err != nil {
+		return err
+	}
+
+	slog.Info("suspension updated", "user_id", userId, "suspended", suspended)
+	return nil
+}
+
+// moderator grants or, with -revoke, revokes the moderator role.
+func moderator(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("moderator", flag.ContinueOnError)
+	revoke := flags.Bool("revoke", false, "revoke the role instead of granting it")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: socialnets-admin moderator [-revoke] <user id or nick>")
+		flags.PrintDefaults()
+	}
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	userUseCase, err := newUserUseCase()
+	if err != nil {
+		return err
+	}
+	userId, err := resolveUser(ctx, userUseCase, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err = userUseCase.SetModerator(ctx, userId, !*revoke); err != nil {
+		return err
+	}
+
+	slog.Info("moderator role updated", "user_id", userId, "moderator", !*revoke)
+	return nil
+}
+
+// randomPassword returns a password of 16 URL-safe characters.
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -5,7 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/health"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/ratelimit"
+	"github.com/edigar/socialnets-api/internal/repository"
 	"github.com/edigar/socialnets-api/internal/router"
+	"github.com/edigar/socialnets-api/internal/scheduler"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,11 +24,57 @@ import (
 )
 
 func main() {
-	config.Load()
-	r := router.Generate()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel))
+	if err = database.Open(cfg.Database); err != nil {
+		panic(err)
+	}
+	// Handlers, jobs and commands read the configuration from their context.
+	ctx := config.WithConfig(context.Background(), cfg)
+	if len(os.Args) > 1 {
+		os.Exit(command(ctx, os.Args[1], os.Args[2:]))
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingEndpoint)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.AutoMigrate {
+		if err = migrateUp(ctx); err != nil {
+			panic(err)
+		}
+	}
+	store, err := rateLimitStore(cfg.RateLimit)
+	if err != nil {
+		panic(err)
+	}
+	db, err := database.Connect()
+	if err != nil {
+		panic(err)
+	}
+	r := router.Generate(store, usecase.NewUserUseCase(repository.NewUserRepository(db)))
+
+	jobs := []scheduler.Job{
+		scheduler.PublishScheduledPosts(cfg.SchedulerInterval),
+		scheduler.PurgeExpiredStories(cfg.SchedulerInterval),
+		scheduler.PurgeUnattachedMedia(cfg.SchedulerInterval),
+	}
+	if cfg.RateLimit.Enabled && cfg.RateLimit.Store == "postgres" {
+		jobs = append(jobs, scheduler.PurgeRateLimits(time.Minute))
+	}
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	waitJobs := scheduler.Start(jobsCtx, jobs...)
 
-	server := &http.Server{Addr: fmt.Sprintf(":%d", config.Port), Handler: r}
-	fmt.Printf("SocialNets API is running on port %d...\n", config.Port)
+	server := &http.Server{
+		Addr:        fmt.Sprintf(":%d", cfg.Port),
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	slog.Info("SocialNets API is running", "port", cfg.Port)
 	go func() {
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			panic(err)
@@ -29,12 +85,50 @@ func main() {
 	signal.Notify(stop, syscall.SIGTERM, os.Interrupt, syscall.SIGINT)
 	<-stop
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	slog.Info("Stopping...")
+	health.Drain()
+	time.Sleep(cfg.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	fmt.Println("Stopping...")
 
-	if err := server.Shutdown(ctx); err != nil {
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		panic(err)
 	}
-	fmt.Println("Server stopped")
+	stopJobs()
+	waitJobs()
+	if err := database.Close(); err != nil {
+		slog.Error("closing database", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("flushing traces", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// rateLimitStore returns the store requests are rate limited against, nil when rate limiting is disabled.
+func rateLimitStore(cfg config.RateLimitConfig) (ratelimit.Store, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Store == "postgres" {
+		db, err := database.Connect()
+		if err != nil {
+			return nil, err
+		}
+		return ratelimit.NewPostgresStore(db), nil
+	}
+
+	return ratelimit.NewMemoryStore(), nil
+}
+
+// command runs a subcommand of the binary instead of the server and returns its exit code.
+func command(ctx context.Context, name string, args []string) int {
+	switch name {
+	case "migrate":
+		return migrate(ctx, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s\n", name, migrateUsage)
+		return 2
+	}
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/edigar/socialnets-api/build"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/migration"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: socialnets-api migrate up|down|status"
+
+// migrate applies, rolls back or lists the migrations embedded in the binary.
+func migrate(ctx context.Context, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	defer database.Close()
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = migrateUp(ctx)
+	case "down":
+		err = migrateDown(ctx)
+	case "status":
+		err = migrateStatus(ctx)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	if err != nil {
+		slog.Error("migrate "+args[0]+" failed", "error", err)
+		return 1
+	}
+
+	return 0
+}
+
+func newMigrator() (*migration.Migrator, error) {
+	db, err := database.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return migration.NewMigrator(db, build.Migrations())
+}
+
+func migrateUp(ctx context.Context) error {
+	migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err == nil && len(applied) == 0 {
+		slog.Info("database is up to date")
+	}
+
+	return err
+}
+
+func migrateDown(ctx context.Context) error {
+	migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Down(ctx)
+	if errors.Is(err, migration.ErrNothingToRollBack) {
+		slog.Info("no migration to roll back")
+		return nil
+	}
+
+	return err
+}
+
+func migrateStatus(ctx context.Context) error {
+	migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Applied At\tMigration")
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if status.Applied() {
+			appliedAt = status.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", appliedAt, status.Name)
+	}
+
+	return w.Flush()
+}
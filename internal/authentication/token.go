@@ -10,19 +10,26 @@ import (
 	"time"
 )
 
-func CreateToken(userId string) (string, error) {
+var errNoSecretKey = errors.New("secret key is not configured")
+
+// CreateToken returns a token authenticating userId, signed with secretKey.
+func CreateToken(secretKey string, userId string) (string, error) {
+	if secretKey == "" {
+		return "", errNoSecretKey
+	}
+
 	claims := jwt.MapClaims{}
 	claims["authorized"] = true
 	claims["exp"] = time.Now().Add(time.Hour * 6).Unix()
 	claims["userId"] = userId
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	return token.SignedString(config.SecretKey)
+	return token.SignedString([]byte(secretKey))
 }
 
 func TokenValidate(r *http.Request) error {
 	tokenString := extractToken(r)
-	token, err := jwt.Parse(tokenString, getVerificationKey)
+	token, err := jwt.Parse(tokenString, verificationKey(config.FromContext(r.Context()).SecretKey))
 	if err != nil {
 		return err
 	}
@@ -36,7 +43,7 @@ func TokenValidate(r *http.Request) error {
 
 func ExtractUserId(r *http.Request) (string, error) {
 	tokenString := extractToken(r)
-	token, err := jwt.Parse(tokenString, getVerificationKey)
+	token, err := jwt.Parse(tokenString, verificationKey(config.FromContext(r.Context()).SecretKey))
 	if err != nil {
 		return "", err
 	}
@@ -59,10 +66,16 @@ func extractToken(r *http.Request) string {
 	return ""
 }
 
-func getVerificationKey(token *jwt.Token) (any, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("unexpected Signature Method! %v", token.Header["alg"])
-	}
+// verificationKey returns the function checking the signature method of tokens and giving their key.
+func verificationKey(secretKey string) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected Signature Method! %v", token.Header["alg"])
+		}
+		if secretKey == "" {
+			return nil, errNoSecretKey
+		}
 
-	return config.SecretKey, nil
+		return []byte(secretKey), nil
+	}
 }
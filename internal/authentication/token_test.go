@@ -7,16 +7,28 @@ import (
 	"testing"
 )
 
+const secretKey = "0123456789abcdef0123456789abcdef"
+
+// newRequest returns a request authenticated with token, served with secretKey configured.
+func newRequest(t *testing.T, token string) *http.Request {
+	cfg := config.Default()
+	cfg.SecretKey = secretKey
+	request, _ := http.NewRequestWithContext(config.WithConfig(t.Context(), cfg), http.MethodGet, "/", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	return request
+}
+
 func TestCreateToken(t *testing.T) {
 	userId := "eedf21bf-dde8-4c85-b50b-89a1cba87c2e"
-	token, err := CreateToken(userId)
+	token, err := CreateToken(secretKey, userId)
 	if err != nil {
 		t.Errorf("CreateToken should not return an error for a valid uint64: %v", err)
 	}
 
 	claims := jwt.MapClaims{}
 	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
-		return config.SecretKey, nil
+		return []byte(secretKey), nil
 	})
 
 	if err != nil {
@@ -43,13 +55,12 @@ func TestCreateToken(t *testing.T) {
 
 func TestTokenValidateValidToken(t *testing.T) {
 	userId := "eedf21bf-dde8-4c85-b50b-89a1cba87c2e"
-	token, err := CreateToken(userId)
+	token, err := CreateToken(secretKey, userId)
 	if err != nil {
 		t.Errorf("CreateToken should not return an error for a valid uint64: %v", err)
 	}
 
-	request, _ := http.NewRequest(http.MethodGet, "/", nil)
-	request.Header.Set("Authorization", "Bearer "+token)
+	request := newRequest(t, token)
 
 	err = TokenValidate(request)
 	if err != nil {
@@ -60,8 +71,7 @@ func TestTokenValidateValidToken(t *testing.T) {
 func TestTokenValidateInvalidToken(t *testing.T) {
 	token := "invalid-token"
 
-	request, _ := http.NewRequest(http.MethodGet, "/", nil)
-	request.Header.Set("Authorization", "Bearer "+token)
+	request := newRequest(t, token)
 
 	err := TokenValidate(request)
 
@@ -72,13 +82,12 @@ func TestTokenValidateInvalidToken(t *testing.T) {
 
 func TestExtractUserIdValidToken(t *testing.T) {
 	userId := "eedf21bf-dde8-4c85-b50b-89a1cba87c2e"
-	token, err := CreateToken(userId)
+	token, err := CreateToken(secretKey, userId)
 	if err != nil {
 		t.Errorf("CreateToken should not return an error")
 	}
 
-	request, _ := http.NewRequest(http.MethodGet, "/", nil)
-	request.Header.Set("Authorization", "Bearer "+token)
+	request := newRequest(t, token)
 
 	extractedUserId, err := ExtractUserId(request)
 
@@ -97,11 +106,23 @@ func TestExtractUserIdValidToken(t *testing.T) {
 func TestExtractUserIdInvalidToken(t *testing.T) {
 	token := "invalid-token"
 
-	request, _ := http.NewRequest(http.MethodGet, "/", nil)
-	request.Header.Set("Authorization", "Bearer "+token)
+	request := newRequest(t, token)
 
 	_, err := ExtractUserId(request)
 	if err == nil {
 		t.Errorf("ExtractUserId should return an error for an invalid token")
 	}
 }
+
+func TestTokenValidateWithoutSecretKey(t *testing.T) {
+	if _, err := CreateToken("", "eedf21bf-dde8-4c85-b50b-89a1cba87c2e"); err == nil {
+		t.Errorf("CreateToken should return an error without a secret key")
+	}
+
+	token, _ := CreateToken(secretKey, "eedf21bf-dde8-4c85-b50b-89a1cba87c2e")
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	if err := TokenValidate(request); err == nil {
+		t.Errorf("TokenValidate should return an error when no secret key is configured")
+	}
+}
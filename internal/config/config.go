@@ -1,39 +1,124 @@
 package config
 
 import (
-	"fmt"
-	"github.com/joho/godotenv"
-	"log"
-	"os"
+	"context"
+	"net"
+	"net/url"
 	"strconv"
+	"time"
 )
 
-var (
-	DbStringConnection = ""
-	Port               = 0
-	SecretKey          []byte
-)
+// Config is the configuration of the API and of its command line tools. It's loaded once on startup with Load and
+// travels on contexts, see WithConfig, so it must not be changed afterwards.
+type Config struct {
+	Environment        string          `yaml:"environment" toml:"environment"`
+	Port               int             `yaml:"port" toml:"port"`
+	SecretKey          string          `yaml:"secretKey" toml:"secretKey"`
+	Database           DatabaseConfig  `yaml:"database" toml:"database"`
+	Storage            StorageConfig   `yaml:"storage" toml:"storage"`
+	MediaMaxSize       int64           `yaml:"mediaMaxSize" toml:"mediaMaxSize"`
+	SchedulerInterval  time.Duration   `yaml:"schedulerInterval" toml:"schedulerInterval"`
+	PostEditWindow     time.Duration   `yaml:"postEditWindow" toml:"postEditWindow"`
+	MaxPinnedPosts     int             `yaml:"maxPinnedPosts" toml:"maxPinnedPosts"`
+	FanOutMaxFollowers int             `yaml:"fanOutMaxFollowers" toml:"fanOutMaxFollowers"`
+	StoryLifetime      time.Duration   `yaml:"storyLifetime" toml:"storyLifetime"`
+	LogLevel           string          `yaml:"logLevel" toml:"logLevel"`
+	TracingEndpoint    string          `yaml:"tracingEndpoint" toml:"tracingEndpoint"`
+	MetricsToken       string          `yaml:"metricsToken" toml:"metricsToken"`
+	HealthCheckTimeout time.Duration   `yaml:"healthCheckTimeout" toml:"healthCheckTimeout"`
+	ShutdownDelay      time.Duration   `yaml:"shutdownDelay" toml:"shutdownDelay"`
+	AutoMigrate        bool            `yaml:"autoMigrate" toml:"autoMigrate"`
+	RateLimit          RateLimitConfig `yaml:"rateLimit" toml:"rateLimit"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	// SSLMode is the libpq sslmode: disable, allow, prefer, require, verify-ca or verify-full.
+	SSLMode string `yaml:"sslMode" toml:"sslMode"`
+}
+
+type StorageConfig struct {
+	Driver    string `yaml:"driver" toml:"driver"`
+	LocalDir  string `yaml:"localDir" toml:"localDir"`
+	Endpoint  string `yaml:"endpoint" toml:"endpoint"`
+	Bucket    string `yaml:"bucket" toml:"bucket"`
+	Region    string `yaml:"region" toml:"region"`
+	AccessKey string `yaml:"accessKey" toml:"accessKey"`
+	SecretKey string `yaml:"secretKey" toml:"secretKey"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Store is where buckets are kept: memory, for each replica on its own, or postgres, shared by every replica.
+	Store string `yaml:"store" toml:"store"`
+	// TrustForwardedFor limits anonymous clients by the last address of X-Forwarded-For, as set by a reverse proxy,
+	// instead of the address of the connection.
+	TrustForwardedFor bool `yaml:"trustForwardedFor" toml:"trustForwardedFor"`
+}
 
-func Load() {
-	var err error
+type contextKey struct{}
 
-	if os.Getenv("ENVIRONMENT") != "PROD" {
-		if err = godotenv.Load(); err != nil {
-			log.Fatal(err)
-		}
+// Default returns the configuration used for everything that isn't set. It has no secret key, so it doesn't validate.
+func Default() *Config {
+	return &Config{
+		Environment: "DEV",
+		Port:        8000,
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    5432,
+			SSLMode: "prefer",
+		},
+		Storage: StorageConfig{
+			Driver:   "local",
+			LocalDir: "./media",
+			Region:   "us-east-1",
+		},
+		MediaMaxSize:       5 << 20,
+		SchedulerInterval:  30 * time.Second,
+		MaxPinnedPosts:     3,
+		FanOutMaxFollowers: 10000,
+		StoryLifetime:      24 * time.Hour,
+		LogLevel:           "info",
+		HealthCheckTimeout: 2 * time.Second,
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+		},
 	}
+}
+
+// Production tells whether the configuration is the one of the production environment.
+func (c *Config) Production() bool {
+	return c.Environment == "PROD"
+}
 
-	Port, err = strconv.Atoi(os.Getenv("API_PORT"))
-	if err != nil {
-		Port = 8000
+// DSN returns the connection string of the database.
+func (d DatabaseConfig) DSN() string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.User, d.Password),
+		Host:     net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:     "/" + d.Name,
+		RawQuery: url.Values{"sslmode": {d.SSLMode}}.Encode(),
 	}
 
-	DbStringConnection = fmt.Sprintf("user=%s dbname=%s password=%s host=%s sslmode=disable",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-	)
+	return dsn.String()
+}
+
+// WithConfig returns a copy of ctx carrying config.
+func WithConfig(ctx context.Context, config *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, config)
+}
+
+// FromContext returns the configuration carried by ctx, or the default one.
+func FromContext(ctx context.Context) *Config {
+	if config, ok := ctx.Value(contextKey{}).(*Config); ok {
+		return config
+	}
 
-	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+	return Default()
 }
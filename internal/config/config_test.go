@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secretKey = "0123456789abcdef0123456789abcdef"
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Should apply file, .env and environment by increasing precedence", func(t *testing.T) {
+		file := writeFile(t, "config.yaml", `
+port: 9000
+logLevel: debug
+storyLifetime: 12h
+database:
+  host: db.internal
+  user: file-user
+  name: socialnets
+  sslMode: verify-full
+`)
+		dotenv := writeFile(t, ".env", "CONFIG_FILE="+file+"\nDB_USER=dotenv-user\nAPI_PORT=9100\nLOG_LEVEL=\n")
+		config, err := load(lookup(map[string]string{"SECRET_KEY": secretKey, "API_PORT": "9200"}), dotenv)
+		if err != nil {
+			t.Fatalf("load should not return an error. Error: %v", err)
+		}
+
+		if config.Port != 9200 {
+			t.Errorf("The environment should take precedence. Got port: %d", config.Port)
+		}
+		if config.Database.User != "dotenv-user" {
+			t.Errorf(".env should take precedence over the file. Got user: %q", config.Database.User)
+		}
+		if config.Database.Host != "db.internal" || config.Database.SSLMode != "verify-full" ||
+			config.StoryLifetime != 12*time.Hour || config.LogLevel != "debug" {
+			t.Errorf("The file should take precedence over defaults and empty variables. Got: %+v", config)
+		}
+		if config.SchedulerInterval != 30*time.Second || config.Database.Port != 5432 {
+			t.Errorf("Unset settings should keep their defaults. Got: %+v", config)
+		}
+	})
+
+	t.Run("Should read TOML files and work without .env", func(t *testing.T) {
+		file := writeFile(t, "config.toml", `
+secretKey = "`+secretKey+`"
+fanOutMaxFollowers = 500
+
+[database]
+user = "root"
+name = "socialnets"
+`)
+		config, err := load(lookup(map[string]string{"CONFIG_FILE": file}), filepath.Join(t.TempDir(), ".env"))
+		if err != nil {
+			t.Fatalf("load should not return an error. Error: %v", err)
+		}
+		if config.FanOutMaxFollowers != 500 || config.Database.User != "root" {
+			t.Errorf("load should read the TOML file. Got: %+v", config)
+		}
+	})
+
+	t.Run("Should ignore .env on production", func(t *testing.T) {
+		dotenv := writeFile(t, ".env", "DB_USER=dotenv-user\n")
+		env := map[string]string{"ENVIRONMENT": "PROD", "SECRET_KEY": secretKey, "DB_USER": "root", "DB_NAME": "sn"}
+		config, err := load(lookup(env), dotenv)
+		if err != nil || config.Database.User != "root" || !config.Production() {
+			t.Errorf("load should not read .env on production. Got: %+v. Error: %v", config, err)
+		}
+	})
+
+	t.Run("Should report every invalid setting", func(t *testing.T) {
+		env := map[string]string{
+			"SECRET_KEY":         "short",
+			"API_PORT":           "70000",
+			"DB_USER":            "root",
+			"DB_NAME":            "socialnets",
+			"DB_SSLMODE":         "off",
+			"SCHEDULER_INTERVAL": "often",
+			"STORAGE_DRIVER":     "s3",
+		}
+		_, err := load(lookup(env), filepath.Join(t.TempDir(), ".env"))
+		if err == nil {
+			t.Fatal("load should return an error for an invalid configuration")
+		}
+		for _, setting := range []string{"SECRET_KEY", "API_PORT", "DB_SSLMODE", "SCHEDULER_INTERVAL", "S3_BUCKET"} {
+			if !strings.Contains(err.Error(), setting) {
+				t.Errorf("load should report %s. Got: %v", setting, err)
+			}
+		}
+	})
+
+	t.Run("Should reject unknown config file formats", func(t *testing.T) {
+		file := writeFile(t, "config.json", "{}")
+		if _, err := load(lookup(map[string]string{"CONFIG_FILE": file}), ".env.missing"); err == nil {
+			t.Error("load should return an error for a .json config file")
+		}
+	})
+}
+
+func TestDSN(t *testing.T) {
+	database := DatabaseConfig{Host: "db", Port: 5432, User: "root", Password: "p@ss word", Name: "socialnets", SSLMode: "require"}
+	if dsn := database.DSN(); dsn != "postgres://root:p%40ss%20word@db:5432/socialnets?sslmode=require" {
+		t.Errorf("DSN should escape credentials and set sslmode. Got: %s", dsn)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if config := FromContext(t.Context()); config.Port != Default().Port {
+		t.Errorf("FromContext should return the defaults without a configuration. Got: %+v", config)
+	}
+
+	config := Default()
+	config.Port = 9000
+	if got := FromContext(WithConfig(t.Context(), config)); got != config {
+		t.Errorf("FromContext should return the carried configuration. Got: %+v", got)
+	}
+}
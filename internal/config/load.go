@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSecretKeyLength is the minimum size of the key tokens are signed with, the size of the HS256 hash.
+const minSecretKeyLength = 32
+
+var (
+	sslModes  = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	logLevels = []string{"debug", "info", "warn", "error"}
+)
+
+// Load reads the configuration from, by increasing precedence: the defaults, the YAML or TOML file named by
+// CONFIG_FILE, the .env file of the working directory and the environment. The .env file is optional, and it's
+// ignored when ENVIRONMENT is PROD. Variables set to an empty value count as unset. Every invalid setting is reported
+// in the returned error.
+func Load() (*Config, error) {
+	return load(os.LookupEnv, ".env")
+}
+
+func load(lookupEnv func(key string) (string, bool), dotenvPath string) (*Config, error) {
+	dotenv := map[string]string{}
+	if environment, _ := lookupEnv("ENVIRONMENT"); environment != "PROD" {
+		var err error
+		if dotenv, err = godotenv.Read(dotenvPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", dotenvPath, err)
+		}
+	}
+	env := source{lookup: func(key string) string {
+		if value, ok := lookupEnv(key); ok && value != "" {
+			return value
+		}
+		return dotenv[key]
+	}}
+
+	config := Default()
+	if path := env.lookup("CONFIG_FILE"); path != "" {
+		if err := readFile(path, config); err != nil {
+			return nil, err
+		}
+	}
+
+	env.string("ENVIRONMENT", &config.Environment)
+	env.int("API_PORT", &config.Port)
+	env.string("SECRET_KEY", &config.SecretKey)
+	env.string("DB_HOST", &config.Database.Host)
+	env.int("DB_PORT", &config.Database.Port)
+	env.string("DB_USER", &config.Database.User)
+	env.string("DB_PASSWORD", &config.Database.Password)
+	env.string("DB_NAME", &config.Database.Name)
+	env.string("DB_SSLMODE", &config.Database.SSLMode)
+	env.string("STORAGE_DRIVER", &config.Storage.Driver)
+	env.string("STORAGE_LOCAL_DIR", &config.Storage.LocalDir)
+	env.string("S3_ENDPOINT", &config.Storage.Endpoint)
+	env.string("S3_BUCKET", &config.Storage.Bucket)
+	env.string("S3_REGION", &config.Storage.Region)
+	env.string("S3_ACCESS_KEY", &config.Storage.AccessKey)
+	env.string("S3_SECRET_KEY", &config.Storage.SecretKey)
+	env.int64("MEDIA_MAX_SIZE", &config.MediaMaxSize)
+	env.duration("SCHEDULER_INTERVAL", &config.SchedulerInterval)
+	env.duration("POST_EDIT_WINDOW", &config.PostEditWindow)
+	env.int("MAX_PINNED_POSTS", &config.MaxPinnedPosts)
+	env.int("FANOUT_MAX_FOLLOWERS", &config.FanOutMaxFollowers)
+	env.duration("STORY_LIFETIME", &config.StoryLifetime)
+	env.string("LOG_LEVEL", &config.LogLevel)
+	env.string("OTEL_EXPORTER_OTLP_ENDPOINT", &config.TracingEndpoint)
+	env.string("METRICS_TOKEN", &config.MetricsToken)
+	env.duration("HEALTH_CHECK_TIMEOUT", &config.HealthCheckTimeout)
+	env.duration("SHUTDOWN_DELAY", &config.ShutdownDelay)
+	env.bool("AUTO_MIGRATE", &config.AutoMigrate)
+	env.bool("RATE_LIMIT_ENABLED", &config.RateLimit.Enabled)
+	env.string("RATE_LIMIT_STORE", &config.RateLimit.Store)
+	env.bool("RATE_LIMIT_TRUST_FORWARDED_FOR", &config.RateLimit.TrustForwardedFor)
+
+	if err := errors.Join(append(env.errs, config.validate()...)...); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return config, nil
+}
+
+// readFile decodes a YAML (.yaml, .yml) or TOML (.toml) file over config, leaving what it doesn't set untouched.
+func readFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, config)
+	case ".toml":
+		err = toml.Unmarshal(data, config)
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// source overrides settings with the variables that are set, keeping the errors of the ones that don't parse.
+type source struct {
+	lookup func(key string) string
+	errs   []error
+}
+
+func (s *source) string(key string, dst *string) {
+	if value := s.lookup(key); value != "" {
+		*dst = value
+	}
+}
+
+func (s *source) int(key string, dst *int) {
+	if value := s.lookup(key); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			s.errs = append(s.errs, fmt.Errorf("%s must be an integer, got %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (s *source) int64(key string, dst *int64) {
+	if value := s.lookup(key); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			s.errs = append(s.errs, fmt.Errorf("%s must be an integer, got %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (s *source) duration(key string, dst *time.Duration) {
+	if value := s.lookup(key); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			s.errs = append(s.errs, fmt.Errorf("%s must be a duration like 30s or 15m, got %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (s *source) bool(key string, dst *bool) {
+	if value := s.lookup(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			s.errs = append(s.errs, fmt.Errorf("%s must be true or false, got %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+// validate returns a problem for each invalid setting, named after its environment variable.
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Port > 0 && c.Port <= 65535, "API_PORT must be between 1 and 65535, got %d", c.Port)
+	check(len(c.SecretKey) >= minSecretKeyLength, "SECRET_KEY must have at least %d bytes, got %d",
+		minSecretKeyLength, len(c.SecretKey))
+
+	check(c.Database.Host != "", "DB_HOST is required")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "DB_PORT must be between 1 and 65535, got %d",
+		c.Database.Port)
+	check(c.Database.User != "", "DB_USER is required")
+	check(c.Database.Name != "", "DB_NAME is required")
+	check(slices.Contains(sslModes, c.Database.SSLMode), "DB_SSLMODE must be one of %s, got %q",
+		strings.Join(sslModes, ", "), c.Database.SSLMode)
+
+	switch c.Storage.Driver {
+	case "local":
+		check(c.Storage.LocalDir != "", "STORAGE_LOCAL_DIR is required by the local storage driver")
+	case "s3":
+		check(c.Storage.Bucket != "", "S3_BUCKET is required by the s3 storage driver")
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER must be local or s3, got %q", c.Storage.Driver))
+	}
+	check(c.MediaMaxSize > 0, "MEDIA_MAX_SIZE must be positive, got %d", c.MediaMaxSize)
+
+	check(c.SchedulerInterval > 0, "SCHEDULER_INTERVAL must be positive, got %s", c.SchedulerInterval)
+	check(c.PostEditWindow >= 0, "POST_EDIT_WINDOW must not be negative, got %s", c.PostEditWindow)
+	check(c.MaxPinnedPosts >= 0, "MAX_PINNED_POSTS must not be negative, got %d", c.MaxPinnedPosts)
+	check(c.FanOutMaxFollowers > 0, "FANOUT_MAX_FOLLOWERS must be positive, got %d", c.FanOutMaxFollowers)
+	check(c.StoryLifetime > 0, "STORY_LIFETIME must be positive, got %s", c.StoryLifetime)
+
+	check(slices.Contains(logLevels, strings.ToLower(c.LogLevel)), "LOG_LEVEL must be one of %s, got %q",
+		strings.Join(logLevels, ", "), c.LogLevel)
+	if c.TracingEndpoint != "" {
+		endpoint, err := url.Parse(c.TracingEndpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"OTEL_EXPORTER_OTLP_ENDPOINT must be an http or https URL, got %q", c.TracingEndpoint)
+	}
+	check(c.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive, got %s", c.HealthCheckTimeout)
+	check(c.ShutdownDelay >= 0, "SHUTDOWN_DELAY must not be negative, got %s", c.ShutdownDelay)
+	check(c.RateLimit.Store == "memory" || c.RateLimit.Store == "postgres",
+		"RATE_LIMIT_STORE must be memory or postgres, got %q", c.RateLimit.Store)
+
+	return errs
+}
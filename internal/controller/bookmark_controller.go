@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/response"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func Bookmark(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	postId, err := strconv.ParseUint(mux.Vars(r)["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newBookmarkUseCase(db).Add(r.Context(), userId, postId); err != nil {
+		respondBookmarkError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func Unbookmark(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	postId, err := strconv.ParseUint(mux.Vars(r)["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newBookmarkUseCase(db).Remove(r.Context(), userId, postId); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	page, limit, err := pageParams(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	posts, err := newBookmarkUseCase(db).Get(r.Context(), userId, page, limit)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if err = loadPostDetails(r.Context(), db, userId, posts); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
+}
+
+func PostCollection(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	var collection entity.Collection
+	if err = json.Unmarshal(body, &collection); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	collection.OwnerId = userId
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newBookmarkUseCase(db).CreateCollection(r.Context(), &collection); err != nil {
+		respondBookmarkError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, collection)
+}
+
+func GetCollections(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	collections, err := newBookmarkUseCase(db).GetCollections(r.Context(), userId)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, collections)
+}
+
+func GetCollection(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	collectionId, err := strconv.ParseUint(mux.Vars(r)["collectionId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	page, limit, err := pageParams(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	collection, posts, err := newBookmarkUseCase(db).GetCollection(r.Context(), userId, collectionId, page, limit)
+	if err != nil {
+		respondBookmarkError(w, err)
+		return
+	}
+	if err = loadPostDetails(r.Context(), db, userId, posts); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, struct {
+		entity.Collection
+		Items []entity.Post `json:"items"`
+	}{collection, posts})
+}
+
+func DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	collectionId, err := strconv.ParseUint(mux.Vars(r)["collectionId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newBookmarkUseCase(db).DeleteCollection(r.Context(), userId, collectionId); err != nil {
+		respondBookmarkError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func AddToCollection(w http.ResponseWriter, r *http.Request) {
+	updateCollection(w, r, (*usecase.BookmarkUseCase).AddToCollection)
+}
+
+func RemoveFromCollection(w http.ResponseWriter, r *http.Request) {
+	updateCollection(w, r, (*usecase.BookmarkUseCase).RemoveFromCollection)
+}
+
+func updateCollection(
+	w http.ResponseWriter,
+	r *http.Request,
+	update func(b *usecase.BookmarkUseCase, ctx context.Context, userId string, collectionId, postId uint64) error,
+) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	params := mux.Vars(r)
+	collectionId, err := strconv.ParseUint(params["collectionId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	postId, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = update(newBookmarkUseCase(db), r.Context(), userId, collectionId, postId); err != nil {
+		respondBookmarkError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func newBookmarkUseCase(db *sql.DB) *usecase.BookmarkUseCase {
+	return usecase.NewBookmarkUseCase(repository.NewBookmarkRepository(db), repository.NewPostRepository(db))
+}
+
+func respondBookmarkError(w http.ResponseWriter, err error) {
+	var ecv *errorType.ErrorCollectionValidation
+	switch {
+	case errors.As(err, &ecv):
+		response.Error(w, http.StatusBadRequest, ecv)
+	case errors.Is(err, usecase.ErrPostNotFound), errors.Is(err, usecase.ErrCollectionNotFound):
+		response.Error(w, http.StatusNotFound, err)
+	default:
+		respondError(w, err)
+	}
+}
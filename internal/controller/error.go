@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"errors"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/response"
+	"net/http"
+)
+
+// respondError answers the errors every controller handles the same way: missing rows with 404, duplicated unique
+// fields with 409 and anything else with 500.
+func respondError(w http.ResponseWriter, err error) {
+	var conflict *repository.ErrConflict
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(w, http.StatusNotFound, err)
+	case errors.As(err, &conflict):
+		response.Error(w, http.StatusConflict, err)
+	default:
+		response.Error(w, http.StatusInternalServerError, err)
+	}
+}
@@ -1,10 +1,92 @@
 package controller
 
 import (
+	"context"
+	"fmt"
+	"github.com/edigar/socialnets-api/build"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/health"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/migration"
 	"github.com/edigar/socialnets-api/internal/response"
+	"github.com/edigar/socialnets-api/internal/storage"
 	"net/http"
 )
 
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+// Liveness reports the process is up and serving requests. It doesn't check any dependency, so an outage of the
+// database doesn't get the API restarted.
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+// Readiness reports whether the API can serve traffic: the database is reachable and fully migrated and the media
+// storage is reachable. It fails while the API shuts down.
+func Readiness(w http.ResponseWriter, r *http.Request) {
+	if health.Draining() {
+		response.JSON(w, http.StatusServiceUnavailable, health.Report{
+			Status:     health.StatusDown,
+			Components: map[string]health.Component{"server": {Status: health.StatusDown}},
+		})
+		return
+	}
+
+	report := health.Run(r.Context(), config.FromContext(r.Context()).HealthCheckTimeout,
+		health.Check{Name: "database", Run: checkDatabase},
+		health.Check{Name: "migrations", Run: checkMigrations},
+		health.Check{Name: "storage", Run: checkStorage},
+	)
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	// Errors can carry hosts and other internals of the dependencies, so they are logged instead of answered.
+	for name, component := range report.Components {
+		if component.Error != "" {
+			logging.FromContext(r.Context()).Warn("readiness check failed", "component", name, "error", component.Error)
+			component.Error = ""
+			report.Components[name] = component
+		}
+	}
+
+	response.JSON(w, status, report)
+}
+
+func checkDatabase(ctx context.Context) error {
+	db, err := database.Connect()
+	if err != nil {
+		return err
+	}
+
+	return db.PingContext(ctx)
+}
+
+func checkMigrations(ctx context.Context) error {
+	db, err := database.Connect()
+	if err != nil {
+		return err
+	}
+
+	migrator, err := migration.NewMigrator(db, build.Migrations())
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, up to version %d", len(pending), pending[len(pending)-1].Version)
+	}
+
+	return nil
+}
+
+func checkStorage(ctx context.Context) error {
+	blobStore, err := storage.New(config.FromContext(ctx).Storage)
+	if err != nil {
+		return err
+	}
+
+	return blobStore.Ping(ctx)
 }
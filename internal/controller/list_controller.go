@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/response"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func PostList(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := readList(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	list.OwnerId = userId
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newListUseCase(db).Create(r.Context(), &list); err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, list)
+}
+
+func GetUserLists(w http.ResponseWriter, r *http.Request) {
+	viewerId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	ownerId := fmt.Sprintf("%s", mux.Vars(r)["userId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	lists, err := newListUseCase(db).GetLists(r.Context(), ownerId, viewerId)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, lists)
+}
+
+func GetList(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	listId, err := strconv.ParseUint(mux.Vars(r)["listId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	list, err := newListUseCase(db).GetList(r.Context(), listId, userId)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, list)
+}
+
+func UpdateList(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	listId, err := strconv.ParseUint(mux.Vars(r)["listId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	list, err := readList(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newListUseCase(db).Update(r.Context(), userId, listId, list); err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func DeleteList(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	listId, err := strconv.ParseUint(mux.Vars(r)["listId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newListUseCase(db).Delete(r.Context(), userId, listId); err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetListMembers(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	listId, err := strconv.ParseUint(mux.Vars(r)["listId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	members, err := newListUseCase(db).GetMembers(r.Context(), listId, userId)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, members)
+}
+
+func AddListMember(w http.ResponseWriter, r *http.Request) {
+	updateListMembers(w, r, (*usecase.ListUseCase).AddMember)
+}
+
+func RemoveListMember(w http.ResponseWriter, r *http.Request) {
+	updateListMembers(w, r, (*usecase.ListUseCase).RemoveMember)
+}
+
+func GetListTimeline(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	listId, err := strconv.ParseUint(mux.Vars(r)["listId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	page, limit, err := pageParams(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	posts, err := newListUseCase(db).GetTimeline(r.Context(), listId, userId, page, limit)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+	if err = loadPostDetails(r.Context(), db, userId, posts); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
+}
+
+func updateListMembers(
+	w http.ResponseWriter,
+	r *http.Request,
+	update func(l *usecase.ListUseCase, ctx context.Context, ownerId string, listId uint64, userId string) error,
+) {
+	ownerId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	params := mux.Vars(r)
+	listId, err := strconv.ParseUint(params["listId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	userId := fmt.Sprintf("%s", params["userId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = update(newListUseCase(db), r.Context(), ownerId, listId, userId); err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func readList(r *http.Request) (entity.List, error) {
+	var list entity.List
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return list, err
+	}
+	err = json.Unmarshal(body, &list)
+
+	return list, err
+}
+
+func newListUseCase(db *sql.DB) *usecase.ListUseCase {
+	return usecase.NewListUseCase(repository.NewListRepository(db))
+}
+
+func respondListError(w http.ResponseWriter, err error) {
+	var elv *errorType.ErrorListValidation
+	switch {
+	case errors.As(err, &elv):
+		response.Error(w, http.StatusBadRequest, elv)
+	case errors.Is(err, usecase.ErrListNotFound), errors.Is(err, usecase.ErrMemberNotFound):
+		response.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrAccessDenied):
+		response.Error(w, http.StatusForbidden, err)
+	default:
+		respondError(w, err)
+	}
+}
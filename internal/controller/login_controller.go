@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/config"
 	"github.com/edigar/socialnets-api/internal/database"
 	"github.com/edigar/socialnets-api/internal/dto"
 	"github.com/edigar/socialnets-api/internal/entity"
@@ -29,26 +30,34 @@ func Login(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	userId, err := userUseCase.Login(user.Email, user.Password)
+	userId, err := userUseCase.Login(r.Context(), user.Email, user.Password)
 	if err != nil {
+		// An unknown e-mail fails as a wrong password does, so logins don't tell which accounts exist.
+		if errors.Is(err, repository.ErrNotFound) {
+			response.Error(w, http.StatusUnauthorized, bcrypt.ErrMismatchedHashAndPassword)
+			return
+		}
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, bcrypt.ErrHashTooShort) || errors.Is(err, bcrypt.ErrPasswordTooLong) {
 			response.Error(w, http.StatusUnauthorized, err)
 			return
 		}
+		if errors.Is(err, usecase.ErrAccountSuspended) {
+			response.Error(w, http.StatusForbidden, err)
+			return
+		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
-	token, err := authentication.CreateToken(userId)
+	token, err := authentication.CreateToken(config.FromContext(r.Context()).SecretKey, userId)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
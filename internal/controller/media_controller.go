@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/response"
+	"github.com/edigar/socialnets-api/internal/storage"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func PostMedia(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	data, status, err := readUpload(w, r)
+	if err != nil {
+		response.Error(w, status, err)
+		return
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	mediaUseCase, err := newMediaUseCase(r.Context(), db)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	attachment, err := mediaUseCase.Upload(r.Context(), userId, data)
+	if err != nil {
+		respondUploadError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, attachment)
+}
+
+func GetMedia(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	mediaUseCase, err := newMediaUseCase(r.Context(), nil)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	// A missing or malformed expiry is zero, which fails the signature check.
+	expires, _ := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	blob, contentType, err := mediaUseCase.Open(r.Context(), params["key"], expires, r.URL.Query().Get("signature"))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, err)
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidSignature) {
+			response.Error(w, http.StatusForbidden, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+	defer blob.Close()
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if expires > 0 {
+		// Signed URLs can't be cached past their expiry, nor shared by caches serving other users.
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(expires-time.Now().Unix(), 10))
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	io.Copy(w, blob)
+}
+
+// newMediaUseCase builds the media use case backed by the configured blob store. db may be nil when only blobs are read.
+func newMediaUseCase(ctx context.Context, db *sql.DB) (*usecase.MediaUseCase, error) {
+	cfg := config.FromContext(ctx)
+	blobStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaUseCase := usecase.NewMediaUseCase(repository.NewMediaRepository(db), blobStore, cfg.MediaMaxSize)
+
+	return mediaUseCase.WithSigning(cfg.SecretKey), nil
+}
+
+// readUpload reads the multipart "file" field, bounded by MEDIA_MAX_SIZE.
+func readUpload(w http.ResponseWriter, r *http.Request) ([]byte, int, error) {
+	maxSize := config.FromContext(r.Context()).MediaMaxSize
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return nil, http.StatusRequestEntityTooLarge, usecase.ErrMediaTooLarge
+		}
+
+		return nil, http.StatusBadRequest, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	return data, http.StatusOK, nil
+}
+
+func respondUploadError(w http.ResponseWriter, err error) {
+	var emv *errorType.ErrorMediaValidation
+	if errors.As(err, &emv) {
+		response.Error(w, http.StatusUnsupportedMediaType, emv)
+		return
+	}
+	if errors.Is(err, usecase.ErrMediaTooLarge) {
+		response.Error(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+
+	respondError(w, err)
+}
@@ -1,13 +1,18 @@
 package controller
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/config"
 	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/dto"
 	"github.com/edigar/socialnets-api/internal/entity"
 	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/logging"
 	"github.com/edigar/socialnets-api/internal/repository"
 	"github.com/edigar/socialnets-api/internal/response"
 	"github.com/edigar/socialnets-api/internal/usecase"
@@ -38,24 +43,45 @@ func PostPost(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
+		return
+	}
+
+	mediaUseCase, err := newMediaUseCase(r.Context(), db)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if err = mediaUseCase.CheckAvailable(r.Context(), userId, post.AttachmentIds); err != nil {
+		var emv *errorType.ErrorMediaValidation
+		if errors.As(err, &emv) {
+			response.Error(w, http.StatusBadRequest, emv)
+			return
+		}
+
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	if err = postUseCase.CreatePost(&post); err != nil {
+	if err = postUseCase.CreatePost(r.Context(), &post); err != nil {
 		var epv *errorType.ErrorPostValidation
 		if errors.As(err, &epv) {
-			response.Error(w, http.StatusBadRequest, epv.Err)
+			response.Error(w, http.StatusBadRequest, epv)
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
+		return
+	}
+
+	posts := []entity.Post{post}
+	if err = loadPostDetails(r.Context(), db, userId, posts); err != nil {
+		respondError(w, err)
 		return
 	}
 
-	response.JSON(w, http.StatusCreated, post)
+	response.JSON(w, http.StatusCreated, posts[0])
 }
 
 func GetPosts(w http.ResponseWriter, r *http.Request) {
@@ -64,17 +90,36 @@ func GetPosts(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusUnauthorized, err)
 		return
 	}
+	page, limit, err := pageParams(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "chronological" && mode != "ranked" {
+		response.Error(w, http.StatusBadRequest, errors.New("mode must be chronological or ranked"))
+		return
+	}
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	posts, err := postUseCase.GetByUser(userId)
+	var posts []entity.Post
+	switch mode {
+	case "", "chronological":
+		posts, err = postUseCase.GetByUser(r.Context(), userId, page, limit)
+	case "ranked":
+		posts, err = postUseCase.GetRanked(r.Context(), userId, page, limit)
+	}
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
+		return
+	}
+	if err = loadPostDetails(r.Context(), db, userId, posts); err != nil {
+		respondError(w, err)
 		return
 	}
 
@@ -82,6 +127,11 @@ func GetPosts(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetPost(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
 	params := mux.Vars(r)
 	postId, err := strconv.ParseUint(params["postId"], 10, 64)
 	if err != nil {
@@ -90,19 +140,23 @@ func GetPost(w http.ResponseWriter, r *http.Request) {
 	}
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	post, err := postUseCase.GetById(postId)
+	post, err := postUseCase.GetById(r.Context(), postId, userId)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
+		return
+	}
+	posts := []entity.Post{post}
+	if err = loadPostDetails(r.Context(), db, userId, posts); err != nil {
+		respondError(w, err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, post)
+	response.JSON(w, http.StatusOK, posts[0])
 }
 
 func UpdatePost(w http.ResponseWriter, r *http.Request) {
@@ -123,37 +177,69 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var post entity.Post
+	var post dto.PostUpdate
 	if err = json.Unmarshal(body, &post); err != nil {
 		response.Error(w, http.StatusBadRequest, err)
 		return
 	}
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
-	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	if err = postUseCase.Update(userId, postId, post); err != nil {
+	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db)).WithEditWindow(config.FromContext(r.Context()).PostEditWindow)
+	if err = postUseCase.Update(r.Context(), userId, postId, post); err != nil {
 		var epv *errorType.ErrorPostValidation
 		if errors.As(err, &epv) {
-			response.Error(w, http.StatusBadRequest, epv.Err)
+			response.Error(w, http.StatusBadRequest, epv)
 			return
 		}
-		if errors.Is(err, usecase.ErrAccessDenied) {
+		if errors.Is(err, usecase.ErrAccessDenied) || errors.Is(err, usecase.ErrEditWindowOver) {
 			response.Error(w, http.StatusForbidden, err)
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
+func GetRevisions(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	params := mux.Vars(r)
+	postId, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
+	revisions, err := postUseCase.GetRevisions(r.Context(), postId, userId)
+	if err != nil {
+		if errors.Is(err, usecase.ErrPostNotFound) {
+			response.Error(w, http.StatusNotFound, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, revisions)
+}
+
 func DeletePost(w http.ResponseWriter, r *http.Request) {
 	userId, err := authentication.ExtractUserId(r)
 	if err != nil {
@@ -168,51 +254,100 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 	}
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
+		return
+	}
+
+	mediaUseCase, err := newMediaUseCase(r.Context(), db)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	posts := []entity.Post{{Id: postId}}
+	if err = mediaUseCase.LoadAttachments(r.Context(), posts); err != nil {
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	if err = postUseCase.Delete(postId, userId); err != nil {
+	if err = postUseCase.Delete(r.Context(), postId, userId); err != nil {
 		if errors.Is(err, usecase.ErrAccessDenied) {
 			response.Error(w, http.StatusForbidden, err)
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
+	if err = mediaUseCase.Purge(r.Context(), posts[0].Attachments); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to purge media of post", "post_id", postId, "error", err)
+	}
+
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
+func GetDrafts(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
+	posts, err := postUseCase.GetDrafts(r.Context(), userId)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if err = loadPostDetails(r.Context(), db, userId, posts); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
+}
+
 func GetUserPosts(w http.ResponseWriter, r *http.Request) {
+	viewerId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
 	params := mux.Vars(r)
 	userId := fmt.Sprintf("%s", params["userId"])
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	posts, err := postUseCase.GetUserPosts(userId)
+	posts, err := postUseCase.GetUserPosts(r.Context(), userId, viewerId)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
+		return
+	}
+	if err = loadPostDetails(r.Context(), db, viewerId, posts); err != nil {
+		respondError(w, err)
+		return
 	}
 
 	response.JSON(w, http.StatusOK, posts)
 }
 
 func LikePost(w http.ResponseWriter, r *http.Request) {
-	//userId, err := authentication.ExtractUserId(r)
-	//if err != nil {
-	//	responses.Error(w, http.StatusUnauthorized, err)
-	//	return
-	//}
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
 	params := mux.Vars(r)
 	postId, err := strconv.ParseUint(params["postId"], 10, 64)
 	if err != nil {
@@ -221,15 +356,14 @@ func LikePost(w http.ResponseWriter, r *http.Request) {
 	}
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	err = postUseCase.LikePost(postId)
+	err = postUseCase.LikePost(r.Context(), postId, userId)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -237,6 +371,11 @@ func LikePost(w http.ResponseWriter, r *http.Request) {
 }
 
 func UnlikePost(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
 	params := mux.Vars(r)
 	postId, err := strconv.ParseUint(params["postId"], 10, 64)
 	if err != nil {
@@ -245,17 +384,208 @@ func UnlikePost(w http.ResponseWriter, r *http.Request) {
 	}
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
-	err = postUseCase.UnLikePost(postId)
+	err = postUseCase.UnLikePost(r.Context(), postId, userId)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 }
+
+func PinPost(w http.ResponseWriter, r *http.Request) {
+	updatePin(w, r, (*usecase.PostUseCase).Pin)
+}
+
+func UnpinPost(w http.ResponseWriter, r *http.Request) {
+	updatePin(w, r, (*usecase.PostUseCase).Unpin)
+}
+
+func updatePin(
+	w http.ResponseWriter,
+	r *http.Request,
+	update func(p *usecase.PostUseCase, ctx context.Context, authorId string, postId uint64) error,
+) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	params := mux.Vars(r)
+	postId, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db)).WithMaxPinned(config.FromContext(r.Context()).MaxPinnedPosts)
+	if err = update(postUseCase, r.Context(), userId, postId); err != nil {
+		var epv *errorType.ErrorPostValidation
+		if errors.As(err, &epv) {
+			response.Error(w, http.StatusBadRequest, epv)
+			return
+		}
+		if errors.Is(err, usecase.ErrAccessDenied) {
+			response.Error(w, http.StatusForbidden, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func VotePoll(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	params := mux.Vars(r)
+	postId, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	var vote dto.Vote
+	if err = json.Unmarshal(body, &vote); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newPollUseCase(db).Vote(r.Context(), userId, postId, vote.Options); err != nil {
+		var epv *errorType.ErrorPostValidation
+		switch {
+		case errors.As(err, &epv):
+			response.Error(w, http.StatusBadRequest, epv)
+		case errors.Is(err, usecase.ErrPostNotFound), errors.Is(err, usecase.ErrPollNotFound):
+			response.Error(w, http.StatusNotFound, err)
+		case errors.Is(err, usecase.ErrPollClosed):
+			response.Error(w, http.StatusConflict, err)
+		default:
+			respondError(w, err)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func SetPostSensitive(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	params := mux.Vars(r)
+	postId, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	var sensitive dto.Sensitive
+	if err = json.Unmarshal(body, &sensitive); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	actor, err := usecase.NewUserUseCase(repository.NewUserRepository(db)).GetById(r.Context(), userId)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
+	if err = postUseCase.SetSensitive(r.Context(), actor, postId, sensitive); err != nil {
+		var epv *errorType.ErrorPostValidation
+		switch {
+		case errors.As(err, &epv):
+			response.Error(w, http.StatusBadRequest, epv)
+		case errors.Is(err, usecase.ErrPostNotFound):
+			response.Error(w, http.StatusNotFound, err)
+		case errors.Is(err, usecase.ErrAccessDenied):
+			response.Error(w, http.StatusForbidden, err)
+		default:
+			respondError(w, err)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func newPollUseCase(db *sql.DB) *usecase.PollUseCase {
+	return usecase.NewPollUseCase(repository.NewPollRepository(db), repository.NewPostRepository(db))
+}
+
+// loadPostDetails fills the attachments and polls of the posts and whether the viewer bookmarked them.
+func loadPostDetails(ctx context.Context, db *sql.DB, viewerId string, posts []entity.Post) error {
+	mediaUseCase, err := newMediaUseCase(ctx, db)
+	if err != nil {
+		return err
+	}
+	if err = mediaUseCase.LoadAttachments(ctx, posts); err != nil {
+		return err
+	}
+	if err = newPollUseCase(db).LoadPolls(ctx, viewerId, posts); err != nil {
+		return err
+	}
+	viewer, err := usecase.NewUserUseCase(repository.NewUserRepository(db)).GetById(ctx, viewerId)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Collapse(viewer.ExpandSensitive)
+	}
+
+	return newBookmarkUseCase(db).LoadBookmarked(ctx, viewerId, posts)
+}
+
+// pageParams reads the optional page and limit query parameters. Defaults and bounds are applied by the use cases.
+func pageParams(r *http.Request) (int, int, error) {
+	var page, limit int
+	var err error
+	if rawPage := r.URL.Query().Get("page"); rawPage != "" {
+		if page, err = strconv.Atoi(rawPage); err != nil || page < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+	}
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		if limit, err = strconv.Atoi(rawLimit); err != nil || limit < 1 {
+			return 0, 0, errors.New("limit must be a positive integer")
+		}
+	}
+
+	return page, limit, nil
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/response"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func PostStory(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	var story entity.Story
+	if err = json.Unmarshal(body, &story); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	story.AuthorId = userId
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	mediaUseCase, err := newMediaUseCase(r.Context(), db)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if err = mediaUseCase.CheckAvailable(r.Context(), userId, story.AttachmentIds); err != nil {
+		var emv *errorType.ErrorMediaValidation
+		if errors.As(err, &emv) {
+			response.Error(w, http.StatusBadRequest, emv)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	if err = newStoryUseCase(r.Context(), db).Create(r.Context(), &story); err != nil {
+		respondStoryError(w, err)
+		return
+	}
+	stories := []entity.Story{story}
+	if err = mediaUseCase.LoadStoryAttachments(r.Context(), stories); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, stories[0])
+}
+
+func GetStories(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	groups, err := newStoryUseCase(r.Context(), db).GetRail(r.Context(), userId)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	mediaUseCase, err := newMediaUseCase(r.Context(), db)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	for _, group := range groups {
+		if err = mediaUseCase.LoadStoryAttachments(r.Context(), group.Stories); err != nil {
+			respondError(w, err)
+			return
+		}
+	}
+
+	response.JSON(w, http.StatusOK, groups)
+}
+
+func ViewStory(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	storyId, err := strconv.ParseUint(mux.Vars(r)["storyId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	if err = newStoryUseCase(r.Context(), db).View(r.Context(), storyId, userId); err != nil {
+		respondStoryError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetStoryViews(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	storyId, err := strconv.ParseUint(mux.Vars(r)["storyId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	views, err := newStoryUseCase(r.Context(), db).GetViews(r.Context(), storyId, userId)
+	if err != nil {
+		respondStoryError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, views)
+}
+
+func newStoryUseCase(ctx context.Context, db *sql.DB) *usecase.StoryUseCase {
+	return usecase.NewStoryUseCase(repository.NewStoryRepository(db)).WithLifetime(config.FromContext(ctx).StoryLifetime)
+}
+
+func respondStoryError(w http.ResponseWriter, err error) {
+	var esv *errorType.ErrorStoryValidation
+	switch {
+	case errors.As(err, &esv):
+		response.Error(w, http.StatusBadRequest, esv)
+	case errors.Is(err, usecase.ErrStoryNotFound):
+		response.Error(w, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrAccessDenied):
+		response.Error(w, http.StatusForbidden, err)
+	default:
+		respondError(w, err)
+	}
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"github.com/edigar/socialnets-api/internal/dto"
 	"github.com/edigar/socialnets-api/internal/entity"
 	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/logging"
 	"github.com/edigar/socialnets-api/internal/repository"
 	"github.com/edigar/socialnets-api/internal/response"
 	"github.com/edigar/socialnets-api/internal/usecase"
@@ -16,6 +18,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -34,20 +37,19 @@ func PostUser(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	if err = userUseCase.Register(&user); err != nil {
+	if err = userUseCase.Register(r.Context(), &user); err != nil {
 		var uve *errorType.ErrorUserValidation
 		if errors.As(err, &uve) {
-			response.Error(w, http.StatusBadRequest, uve.Err)
+			response.Error(w, http.StatusBadRequest, uve)
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -58,15 +60,14 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 	nameOrNick := strings.ToLower(r.URL.Query().Get("search"))
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	users, err := userUseCase.GetByNameOrNick(nameOrNick)
+	users, err := userUseCase.GetByNameOrNick(r.Context(), nameOrNick)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -77,27 +78,27 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userId := fmt.Sprintf("%s", params["userId"])
 
-	db, err := database.Connect()
+	viewerId, err := authentication.ExtractUserId(r)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, http.StatusUnauthorized, err)
 		return
 	}
-	defer db.Close()
 
-	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-
-	user, err := userUseCase.GetById(userId)
+	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
-	if (entity.User{}) == user {
-		response.JSON(w, http.StatusNotFound, nil)
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+
+	profile, err := userUseCase.GetProfile(r.Context(), userId, viewerId)
+	if err != nil {
+		respondError(w, err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, user)
+	response.JSON(w, http.StatusOK, profile)
 }
 
 func UpdateUser(w http.ResponseWriter, r *http.Request) {
@@ -128,20 +129,19 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	if err = userUseCase.Update(userId, user); err != nil {
+	if err = userUseCase.Update(r.Context(), userId, user); err != nil {
 		var uve *errorType.ErrorUserValidation
 		if errors.As(err, &uve) {
-			response.Error(w, http.StatusBadRequest, uve.Err)
+			response.Error(w, http.StatusBadRequest, uve)
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -164,14 +164,14 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	if err = userUseCase.Delete(userId); err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+	if err = userUseCase.Delete(r.Context(), userId); err != nil {
+		respondError(w, err)
+		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
@@ -189,19 +189,18 @@ func Follow(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	if err = userUseCase.Follow(userId, follower); err != nil {
+	if err = userUseCase.Follow(r.Context(), userId, follower); err != nil {
 		if errors.Is(err, usecase.ErrOperationDenied) {
 			response.Error(w, http.StatusForbidden, err)
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -220,19 +219,18 @@ func Unfollow(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	if err = userUseCase.Unfollow(userId, follower); err != nil {
+	if err = userUseCase.Unfollow(r.Context(), userId, follower); err != nil {
 		if errors.Is(err, usecase.ErrOperationDenied) {
 			response.Error(w, http.StatusForbidden, err)
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -245,15 +243,14 @@ func GetFollowers(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	followers, err := userUseCase.GetFollowers(userId)
+	followers, err := userUseCase.GetFollowers(r.Context(), userId)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -266,15 +263,14 @@ func GetFollowing(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	following, err := userUseCase.GetFollowing(userId)
+	following, err := userUseCase.GetFollowing(r.Context(), userId)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
@@ -308,13 +304,12 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 
 	db, err := database.Connect()
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
-	defer db.Close()
 
 	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
-	if err = userUseCase.UpdatePassword(userId, password); err != nil {
+	if err = userUseCase.UpdatePassword(r.Context(), userId, password); err != nil {
 		if errors.Is(err, usecase.ErrWrongPassword) {
 			response.Error(w, http.StatusUnauthorized, err)
 			return
@@ -325,9 +320,356 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		response.Error(w, http.StatusInternalServerError, err)
+		respondError(w, err)
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 }
+
+func UpdateAvatar(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userId := fmt.Sprintf("%s", params["userId"])
+
+	tokenUserId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+	if userId != tokenUserId {
+		response.Error(w, http.StatusForbidden, errors.New("access denied"))
+		return
+	}
+
+	data, status, err := readUpload(w, r)
+	if err != nil {
+		response.Error(w, status, err)
+		return
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	mediaUseCase, err := newMediaUseCase(r.Context(), db)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	avatarKey, err := mediaUseCase.UploadAvatar(r.Context(), data)
+	if err != nil {
+		respondUploadError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	previousKey, err := userUseCase.UpdateAvatar(r.Context(), userId, avatarKey)
+	if err != nil {
+		mediaUseCase.RemoveBlob(r.Context(), avatarKey)
+		respondError(w, err)
+		return
+	}
+	if err = mediaUseCase.RemoveBlob(r.Context(), previousKey); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to remove previous avatar", "key", previousKey, "error", err)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"avatarUrl": "/media/" + avatarKey})
+}
+
+func GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	limit := 10
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		if limit, err = strconv.Atoi(rawLimit); err != nil || limit < 1 {
+			response.Error(w, http.StatusBadRequest, errors.New("limit must be a positive integer"))
+			return
+		}
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	suggestions, err := userUseCase.GetSuggestions(r.Context(), userId, limit)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, suggestions)
+}
+
+func Block(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	blocked := fmt.Sprintf("%s", params["userId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	if err = userUseCase.Block(r.Context(), userId, blocked); err != nil {
+		if errors.Is(err, usecase.ErrOperationDenied) {
+			response.Error(w, http.StatusForbidden, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func Unblock(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	blocked := fmt.Sprintf("%s", params["userId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	if err = userUseCase.Unblock(r.Context(), userId, blocked); err != nil {
+		if errors.Is(err, usecase.ErrOperationDenied) {
+			response.Error(w, http.StatusForbidden, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func Mute(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	muted := fmt.Sprintf("%s", params["userId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	if err = userUseCase.Mute(r.Context(), userId, muted); err != nil {
+		if errors.Is(err, usecase.ErrOperationDenied) {
+			response.Error(w, http.StatusForbidden, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func Unmute(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	muted := fmt.Sprintf("%s", params["userId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	if err = userUseCase.Unmute(r.Context(), userId, muted); err != nil {
+		if errors.Is(err, usecase.ErrOperationDenied) {
+			response.Error(w, http.StatusForbidden, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	requests, err := userUseCase.GetFollowRequests(r.Context(), userId)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, requests)
+}
+
+func AcceptFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	follower := fmt.Sprintf("%s", params["followerId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	if err = userUseCase.AcceptFollowRequest(r.Context(), userId, follower); err != nil {
+		if errors.Is(err, usecase.ErrFollowRequestNotFound) {
+			response.Error(w, http.StatusNotFound, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func RejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	follower := fmt.Sprintf("%s", params["followerId"])
+
+	db, err := database.Connect()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	if err = userUseCase.RejectFollowRequest(r.Context(), userId, follower); err != nil {
+		if errors.Is(err, usecase.ErrFollowRequestNotFound) {
+			response.Error(w, http.StatusNotFound, err)
+			return
+		}
+
+		respondError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetRelationship(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	otherId := fmt.Sprintf("%s", params["userId"])
+
+	relationships, status, err := fetchRelationships(r.Context(), userId, []string{otherId})
+	if err != nil {
+		response.Error(w, status, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, relationships[0])
+}
+
+func GetRelationships(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.ExtractUserId(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var otherIds []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			otherIds = append(otherIds, id)
+		}
+	}
+
+	relationships, status, err := fetchRelationships(r.Context(), userId, otherIds)
+	if err != nil {
+		response.Error(w, status, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, relationships)
+}
+
+func fetchRelationships(ctx context.Context, userId string, otherIds []string) ([]entity.Relationship, int, error) {
+	db, err := database.Connect()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	userUseCase := usecase.NewUserUseCase(repository.NewUserRepository(db))
+	relationships, err := userUseCase.GetRelationships(ctx, userId, otherIds)
+	if err != nil {
+		var uve *errorType.ErrorUserValidation
+		if errors.As(err, &uve) {
+			return nil, http.StatusBadRequest, uve
+		}
+
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return relationships, http.StatusOK, nil
+}
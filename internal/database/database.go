@@ -2,21 +2,74 @@ package database
 
 import (
 	"database/sql"
+	"errors"
+	"github.com/XSAM/otelsql"
 	"github.com/edigar/socialnets-api/internal/config"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"sync"
 
 	_ "github.com/lib/pq"
 )
 
-func Connect() (*sql.DB, error) {
-	db, err := sql.Open("postgres", config.DbStringConnection)
+var (
+	mu   sync.Mutex
+	pool *sql.DB
+)
+
+// Open opens the connection pool shared by the whole process. Connections are made on first use, so it doesn't fail
+// when the database is down.
+func Open(config config.DatabaseConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if pool != nil {
+		return errors.New("database is already open")
+	}
+
+	db, err := otelsql.Open("postgres", config.DSN(),
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true}),
+	)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	pool = db
 
-	if err = db.Ping(); err != nil {
-		db.Close()
-		return nil, err
+	return nil
+}
+
+// Connect returns the shared pool. Callers must not close it; Close does it on shutdown. Statements run with a
+// context are traced as children of the span it carries.
+func Connect() (*sql.DB, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if pool == nil {
+		return nil, errors.New("database is not open")
 	}
 
-	return db, nil
+	return pool, nil
+}
+
+// Stats returns the statistics of the shared pool, if it's open.
+func Stats() (sql.DBStats, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if pool == nil {
+		return sql.DBStats{}, false
+	}
+
+	return pool.Stats(), true
+}
+
+// Close closes the shared pool.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if pool == nil {
+		return nil
+	}
+
+	err := pool.Close()
+	pool = nil
+
+	return err
 }
@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/edigar/socialnets-api/internal/entity"
+
+// PostUpdate is the body of post edits. Sensitive and ContentWarning are nil when they aren't sent, so the post keeps
+// the ones it has.
+type PostUpdate struct {
+	entity.Post
+	Sensitive      *bool   `json:"sensitive"`
+	ContentWarning *string `json:"contentWarning"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+type Sensitive struct {
+	Sensitive      bool   `json:"sensitive"`
+	ContentWarning string `json:"contentWarning"`
+}
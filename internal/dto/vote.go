@@ -0,0 +1,5 @@
+package dto
+
+type Vote struct {
+	Options []uint64 `json:"options"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+type Attachment struct {
+	Id           uint64    `json:"id"`
+	PostId       *uint64   `json:"postId,omitempty"`
+	StoryId      *uint64   `json:"storyId,omitempty"`
+	OwnerId      string    `json:"ownerId,omitempty"`
+	Key          string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"createdAt,omitempty"`
+}
+
+// SetURLs fills the URLs from the storage keys, as url builds them.
+func (attachment *Attachment) SetURLs(url func(key string) string) {
+	attachment.URL = url(attachment.Key)
+	attachment.ThumbnailURL = url(attachment.ThumbnailKey)
+}
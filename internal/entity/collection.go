@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxCollectionNameLength = 50
+
+// Collection is a named, private list of posts curated by a user.
+type Collection struct {
+	Id        uint64    `json:"id,omitempty"`
+	OwnerId   string    `json:"ownerId,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Posts     uint64    `json:"posts"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+func (collection *Collection) Prepare() error {
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" {
+		return errorType.NewErrorCollectionValidation(
+			errorType.NewFieldError("name", errorType.CodeRequired, "name is required"),
+		)
+	}
+	if utf8.RuneCountInString(collection.Name) > maxCollectionNameLength {
+		return errorType.NewErrorCollectionValidation(
+			errorType.NewFieldError("name", errorType.CodeTooLong, "name must have at most 50 characters"),
+		)
+	}
+
+	return nil
+}
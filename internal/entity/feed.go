@@ -0,0 +1,11 @@
+package entity
+
+// FeedCandidate is a post that may go into the ranked feed of a user, with the signals used to score it.
+type FeedCandidate struct {
+	Post Post
+	// Bookmarks is how many users saved the post.
+	Bookmarks uint64
+	// Interactions is how many times the user interacted with the author lately: bookmarks of their posts, votes on
+	// their polls and mentions of them.
+	Interactions uint64
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxListNameLength = 50
+
+// List is a named group of accounts curated by a user, with its own timeline. Private lists are only visible to
+// their owner.
+type List struct {
+	Id        uint64    `json:"id,omitempty"`
+	OwnerId   string    `json:"ownerId,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Private   bool      `json:"private"`
+	Members   uint64    `json:"members"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+func (list *List) Prepare() error {
+	list.Name = strings.TrimSpace(list.Name)
+	if list.Name == "" {
+		return errorType.NewErrorListValidation(
+			errorType.NewFieldError("name", errorType.CodeRequired, "name is required"),
+		)
+	}
+	if utf8.RuneCountInString(list.Name) > maxListNameLength {
+		return errorType.NewErrorListValidation(
+			errorType.NewFieldError("name", errorType.CodeTooLong, "name must have at most 50 characters"),
+		)
+	}
+
+	return nil
+}
+
+// VisibleTo reports whether the viewer can see the list.
+func (list *List) VisibleTo(viewerId string) bool {
+	return list.Id != 0 && (!list.Private || list.OwnerId == viewerId)
+}
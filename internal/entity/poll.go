@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 6
+	maxPollOptionLength = 50
+	MaxPollDuration     = 30 * 24 * time.Hour
+)
+
+// Poll is a question attached to a post. Tallies are nil while they are hidden from the viewer.
+type Poll struct {
+	Id          uint64       `json:"id,omitempty"`
+	PostId      uint64       `json:"-"`
+	Options     []PollOption `json:"options"`
+	Multiple    bool         `json:"multiple"`
+	HideResults bool         `json:"hideResults"`
+	ExpiresAt   time.Time    `json:"expiresAt"`
+	Expired     bool         `json:"expired"`
+	Voters      *uint64      `json:"voters,omitempty"`
+	Voted       bool         `json:"voted"`
+}
+
+type PollOption struct {
+	Id     uint64  `json:"id,omitempty"`
+	Text   string  `json:"text"`
+	Votes  *uint64 `json:"votes,omitempty"`
+	Chosen bool    `json:"chosen,omitempty"`
+}
+
+// validate returns the failures of the poll, named after the fields of the post it belongs to. The poll runs from
+// start, when the post is published.
+func (poll *Poll) validate(start time.Time) []errorType.FieldError {
+	var fields []errorType.FieldError
+	invalid := func(field, code, message string) {
+		fields = append(fields, errorType.NewFieldError(field, code, message))
+	}
+
+	if len(poll.Options) < MinPollOptions || len(poll.Options) > MaxPollOptions {
+		code := errorType.CodeTooFew
+		if len(poll.Options) > MaxPollOptions {
+			code = errorType.CodeTooMany
+		}
+		invalid("poll.options", code,
+			fmt.Sprintf("a poll must have between %d and %d options", MinPollOptions, MaxPollOptions))
+	}
+	seen := map[string]bool{}
+	for i, option := range poll.Options {
+		field := fmt.Sprintf("poll.options[%d].text", i)
+		text := strings.TrimSpace(option.Text)
+		if text == "" || utf8.RuneCountInString(text) > maxPollOptionLength {
+			invalid(field, errorType.CodeInvalid, "poll options must have between 1 and 50 characters")
+		} else if seen[strings.ToLower(text)] {
+			invalid(field, errorType.CodeDuplicate, "poll options must be different")
+		}
+		seen[strings.ToLower(text)] = true
+	}
+	if !poll.ExpiresAt.After(start) {
+		invalid("poll.expiresAt", errorType.CodeInvalid, "poll expiresAt must be after the post is published")
+	} else if poll.ExpiresAt.Sub(start) > MaxPollDuration {
+		invalid("poll.expiresAt", errorType.CodeInvalid, "a poll can last at most 30 days")
+	}
+
+	return fields
+}
+
+func (poll *Poll) format() {
+	for i := range poll.Options {
+		poll.Options[i].Text = strings.TrimSpace(poll.Options[i].Text)
+	}
+}
+
+// Present prepares the poll to be shown: it flags expiry and hides tallies when results are hidden until the
+// viewer votes or the poll expires. The post author always sees the results.
+func (poll *Poll) Present(viewerIsAuthor bool, now time.Time) {
+	poll.Expired = !now.Before(poll.ExpiresAt)
+	if !poll.HideResults || poll.Voted || poll.Expired || viewerIsAuthor {
+		return
+	}
+
+	poll.Voters = nil
+	for i := range poll.Options {
+		poll.Options[i].Votes = nil
+	}
+}
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollValidate(t *testing.T) {
+	t.Run("Should accept a valid poll", func(t *testing.T) {
+		post := Post{Title: "title", Content: "content", Poll: &Poll{
+			Options:   []PollOption{{Text: " Yes "}, {Text: "No"}},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}}
+		if err := post.Prepare(); err != nil {
+			t.Errorf("Post prepare should not return an error for a valid poll. Error: %v", err)
+		}
+		if post.Poll.Options[0].Text != "Yes" {
+			t.Errorf("Post prepare should trim poll options. Got: %v", post.Poll.Options[0].Text)
+		}
+	})
+
+	t.Run("Should return error for invalid polls", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		scenarios := []Poll{
+			{Options: []PollOption{{Text: "Yes"}}, ExpiresAt: future},
+			{Options: []PollOption{{Text: "1"}, {Text: "2"}, {Text: "3"}, {Text: "4"}, {Text: "5"}, {Text: "6"}, {Text: "7"}}, ExpiresAt: future},
+			{Options: []PollOption{{Text: "Yes"}, {Text: " "}}, ExpiresAt: future},
+			{Options: []PollOption{{Text: "Yes"}, {Text: "yes"}}, ExpiresAt: future},
+			{Options: []PollOption{{Text: "Yes"}, {Text: "No"}}, ExpiresAt: time.Now().Add(-time.Hour)},
+			{Options: []PollOption{{Text: "Yes"}, {Text: "No"}}, ExpiresAt: time.Now().Add(MaxPollDuration + time.Hour)},
+		}
+		for _, poll := range scenarios {
+			post := Post{Title: "title", Content: "content", Poll: &poll}
+			if err := post.Prepare(); err == nil {
+				t.Errorf("Post prepare should return an error for an invalid poll. Poll: %v", poll)
+			}
+		}
+	})
+
+	t.Run("Should return error for polls expiring before a scheduled post is published", func(t *testing.T) {
+		publishAt := time.Now().Add(2 * time.Hour)
+		post := Post{Title: "title", Content: "content", Status: PostScheduled, PublishAt: &publishAt, Poll: &Poll{
+			Options:   []PollOption{{Text: "Yes"}, {Text: "No"}},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}}
+		if err := post.Prepare(); err == nil {
+			t.Errorf("Post prepare should return an error for a poll expiring before publishAt. Poll: %v", post.Poll)
+		}
+
+		post.Poll.ExpiresAt = publishAt.Add(MaxPollDuration - time.Hour)
+		if err := post.Prepare(); err != nil {
+			t.Errorf("Post prepare should count the poll duration from publishAt. Error: %v", err)
+		}
+	})
+}
+
+func TestPollPresent(t *testing.T) {
+	votes := uint64(3)
+	newPoll := func() Poll {
+		return Poll{
+			Options:     []PollOption{{Text: "Yes", Votes: &votes}},
+			HideResults: true,
+			ExpiresAt:   time.Now().Add(time.Hour),
+			Voters:      &votes,
+		}
+	}
+
+	t.Run("Should hide tallies from viewers who didn't vote", func(t *testing.T) {
+		poll := newPoll()
+		poll.Present(false, time.Now())
+		if poll.Voters != nil || poll.Options[0].Votes != nil {
+			t.Errorf("Present should hide tallies. Got: %v", poll)
+		}
+	})
+
+	t.Run("Should show tallies to the author and after expiry", func(t *testing.T) {
+		poll := newPoll()
+		poll.Present(true, time.Now())
+		if poll.Voters == nil {
+			t.Errorf("Present should show tallies to the author. Got: %v", poll)
+		}
+
+		poll = newPoll()
+		poll.Present(false, time.Now().Add(2*time.Hour))
+		if !poll.Expired || poll.Voters == nil {
+			t.Errorf("Present should show tallies of an expired poll. Got: %v", poll)
+		}
+	})
+}
@@ -1,19 +1,59 @@
 package entity
 
 import (
+	"fmt"
 	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+const MaxPostAttachments = 4
+
+const maxContentWarningLength = 100
+
+const (
+	PostDraft     = "draft"
+	PostScheduled = "scheduled"
+	PostPublished = "published"
+)
+
+// Visibility levels: public posts are visible to everyone, followers posts to approved followers and direct posts
+// only to the users mentioned on them. Authors and mentioned users always see their posts.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityDirect    = "direct"
+)
+
+var mentionPattern = regexp.MustCompile(`\B@(\w+)`)
+
+// Post is an entry written by a user. Sensitive posts carry an optional content warning shown in place of their
+// content and media; Collapsed is computed per viewer from their preference to expand sensitive content.
 type Post struct {
-	Id         uint64    `json:"id,omitempty"`
-	Title      string    `json:"title,omitempty"`
-	Content    string    `json:"content,omitempty"`
-	AuthorId   string    `json:"authorId,omitempty"`
-	AuthorNick string    `json:"authorNick,omitempty"`
-	Likes      uint64    `json:"likes"`
-	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	Id                   uint64       `json:"id,omitempty"`
+	Title                string       `json:"title,omitempty"`
+	Content              string       `json:"content,omitempty"`
+	AuthorId             string       `json:"authorId,omitempty"`
+	AuthorNick           string       `json:"authorNick,omitempty"`
+	Likes                uint64       `json:"likes"`
+	Status               string       `json:"status,omitempty"`
+	PublishAt            *time.Time   `json:"publishAt,omitempty"`
+	PublishedAt          *time.Time   `json:"publishedAt,omitempty"`
+	EditedAt             *time.Time   `json:"editedAt,omitempty"`
+	Visibility           string       `json:"visibility,omitempty"`
+	ContentWarning       string       `json:"contentWarning,omitempty"`
+	Sensitive            bool         `json:"sensitive"`
+	SensitiveByModerator bool         `json:"-"`
+	Collapsed            bool         `json:"collapsed"`
+	Mentions             []string     `json:"mentions,omitempty"`
+	Bookmarked           bool         `json:"bookmarkedByMe"`
+	Pinned               bool         `json:"pinned"`
+	Poll                 *Poll        `json:"poll,omitempty"`
+	CreatedAt            time.Time    `json:"createdAt,omitempty"`
+	AttachmentIds        []uint64     `json:"attachmentIds,omitempty"`
+	Attachments          []Attachment `json:"attachments,omitempty"`
 }
 
 func (post *Post) Prepare() error {
@@ -26,11 +66,47 @@ func (post *Post) Prepare() error {
 }
 
 func (post *Post) validate() error {
+	var fields []errorType.FieldError
+	invalid := func(field, code, message string) {
+		fields = append(fields, errorType.NewFieldError(field, code, message))
+	}
+
 	if post.Title == "" {
-		return errorType.NewErrorPostValidation("title is required")
+		invalid("title", errorType.CodeRequired, "title is required")
 	}
 	if post.Content == "" {
-		return errorType.NewErrorPostValidation("content is required")
+		invalid("content", errorType.CodeRequired, "content is required")
+	}
+	switch post.Status {
+	case "", PostDraft, PostPublished:
+	case PostScheduled:
+		if post.PublishAt == nil {
+			invalid("publishAt", errorType.CodeRequired, "publishAt is required for scheduled posts")
+		} else if !post.PublishAt.After(time.Now()) {
+			invalid("publishAt", errorType.CodeInvalid, "publishAt must be in the future")
+		}
+	default:
+		invalid("status", errorType.CodeInvalid, "status must be draft, scheduled or published")
+	}
+	switch post.Visibility {
+	case "", VisibilityPublic, VisibilityFollowers, VisibilityDirect:
+	default:
+		invalid("visibility", errorType.CodeInvalid, "visibility must be public, followers or direct")
+	}
+	fields = append(fields, validateContentWarning(post.ContentWarning)...)
+	if len(post.AttachmentIds) > MaxPostAttachments {
+		invalid("attachmentIds", errorType.CodeTooMany,
+			fmt.Sprintf("a post can have at most %d attachments", MaxPostAttachments))
+	}
+	if post.Poll != nil {
+		start := time.Now()
+		if post.Status == PostScheduled && post.PublishAt != nil && post.PublishAt.After(start) {
+			start = *post.PublishAt
+		}
+		fields = append(fields, post.Poll.validate(start)...)
+	}
+	if len(fields) > 0 {
+		return errorType.NewErrorPostValidation(fields...)
 	}
 
 	return nil
@@ -39,4 +115,59 @@ func (post *Post) validate() error {
 func (post *Post) format() {
 	post.Title = strings.TrimSpace(post.Title)
 	post.Content = strings.TrimSpace(post.Content)
+	if post.Status != "" && post.Status != PostScheduled {
+		post.PublishAt = nil
+	}
+	post.ContentWarning = strings.TrimSpace(post.ContentWarning)
+	if post.ContentWarning != "" {
+		post.Sensitive = true
+	}
+	post.Mentions = mentions(post.Content)
+	if post.Poll != nil {
+		post.Poll.format()
+	}
+}
+
+// MarkSensitive flags or unflags the post as sensitive. Unflagged posts have no content warning.
+func (post *Post) MarkSensitive(sensitive bool, contentWarning string) error {
+	if fields := validateContentWarning(contentWarning); len(fields) > 0 {
+		return errorType.NewErrorPostValidation(fields...)
+	}
+
+	post.Sensitive = sensitive
+	post.ContentWarning = ""
+	if sensitive {
+		post.ContentWarning = strings.TrimSpace(contentWarning)
+	}
+
+	return nil
+}
+
+func validateContentWarning(contentWarning string) []errorType.FieldError {
+	if utf8.RuneCountInString(strings.TrimSpace(contentWarning)) > maxContentWarningLength {
+		return []errorType.FieldError{errorType.NewFieldError("contentWarning", errorType.CodeTooLong,
+			fmt.Sprintf("contentWarning must have at most %d characters", maxContentWarningLength))}
+	}
+
+	return nil
+}
+
+// Collapse hides sensitive posts behind their warning unless the viewer prefers to expand them.
+func (post *Post) Collapse(expandSensitive bool) {
+	post.Collapsed = post.Sensitive && !expandSensitive
+}
+
+// mentions returns the nicks mentioned with @ on content, lowercased and without repetition.
+func mentions(content string) []string {
+	var nicks []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		nick := strings.ToLower(match[1])
+		if !seen[nick] {
+			seen[nick] = true
+			nicks = append(nicks, nick)
+		}
+	}
+
+	return nicks
 }
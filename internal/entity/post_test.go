@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -17,16 +18,16 @@ func TestPostPrepare(t *testing.T) {
 		createdAt := time.Now()
 		scenarios := []PostScenarios{
 			{
-				Post{1, "test title", "test content", AUTHOR_ID, "nick", 0, createdAt},
-				Post{1, "test title", "test content", AUTHOR_ID, "nick", 0, createdAt},
+				Post{Id: 1, Title: "test title", Content: "test content", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt},
+				Post{Id: 1, Title: "test title", Content: "test content", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt},
 			},
 			{
-				Post{1, "   test title   ", "  test content   ", AUTHOR_ID, "nick", 0, createdAt},
-				Post{1, "test title", "test content", AUTHOR_ID, "nick", 0, createdAt},
+				Post{Id: 1, Title: "   test title   ", Content: "  test content   ", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt},
+				Post{Id: 1, Title: "test title", Content: "test content", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt},
 			},
 			{
-				Post{1, " test  title ", " test  content ", AUTHOR_ID, "nick", 0, createdAt},
-				Post{1, "test  title", "test  content", AUTHOR_ID, "nick", 0, createdAt},
+				Post{Id: 1, Title: " test  title ", Content: " test  content ", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt},
+				Post{Id: 1, Title: "test  title", Content: "test  content", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt},
 			},
 		}
 
@@ -36,7 +37,7 @@ func TestPostPrepare(t *testing.T) {
 			if err != nil {
 				t.Errorf("Post prepare should not return an error for a valid post: %v. Scenario: %v", err, scenario.post)
 			}
-			if scenario.post != scenario.expected {
+			if !reflect.DeepEqual(scenario.post, scenario.expected) {
 				t.Errorf(
 					"Post prepare should correctly format the post data. Post: %v. Post expected: %v",
 					scenario.post,
@@ -48,7 +49,7 @@ func TestPostPrepare(t *testing.T) {
 
 	t.Run("Should return error if title is empty", func(t *testing.T) {
 		createdAt := time.Now()
-		post := Post{1, "", "content", AUTHOR_ID, "nick", 0, createdAt}
+		post := Post{Id: 1, Title: "", Content: "content", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt}
 		err := post.Prepare()
 
 		if err.Error() != "title is required" {
@@ -58,11 +59,86 @@ func TestPostPrepare(t *testing.T) {
 
 	t.Run("Should return error if content is empty", func(t *testing.T) {
 		createdAt := time.Now()
-		post := Post{1, "title", "", AUTHOR_ID, "nick", 0, createdAt}
+		post := Post{Id: 1, Title: "title", Content: "", AuthorId: AUTHOR_ID, AuthorNick: "nick", CreatedAt: createdAt}
 		err := post.Prepare()
 
 		if err.Error() != "content is required" {
 			t.Errorf("Post prepare should return a 'content is required' error if content is empty. Error: %v", err)
 		}
 	})
+
+	t.Run("Should validate status and publishAt", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		future := time.Now().Add(time.Hour)
+		scenarios := []struct {
+			post     Post
+			expected string
+		}{
+			{Post{Title: "title", Content: "content", Status: "archived"}, "status must be draft, scheduled or published"},
+			{Post{Title: "title", Content: "content", Status: PostScheduled}, "publishAt is required for scheduled posts"},
+			{Post{Title: "title", Content: "content", Status: PostScheduled, PublishAt: &past}, "publishAt must be in the future"},
+		}
+
+		for _, scenario := range scenarios {
+			err := scenario.post.Prepare()
+			if err == nil || err.Error() != scenario.expected {
+				t.Errorf("Post prepare should return %q. Got: %v", scenario.expected, err)
+			}
+		}
+
+		scheduled := Post{Title: "title", Content: "content", Status: PostScheduled, PublishAt: &future}
+		if err := scheduled.Prepare(); err != nil {
+			t.Errorf("Post prepare should accept a post scheduled in the future. Error: %v", err)
+		}
+
+		draft := Post{Title: "title", Content: "content", Status: PostDraft, PublishAt: &future}
+		if err := draft.Prepare(); err != nil || draft.PublishAt != nil {
+			t.Errorf("Post prepare should clear publishAt of non scheduled posts. Got: %v. Error: %v", draft.PublishAt, err)
+		}
+	})
+}
+
+func TestPostVisibility(t *testing.T) {
+	t.Run("Should return error if visibility is unknown", func(t *testing.T) {
+		post := Post{Title: "title", Content: "content", Visibility: "friends"}
+		if err := post.Prepare(); err == nil || err.Error() != "visibility must be public, followers or direct" {
+			t.Errorf("Post prepare should return a visibility error. Error: %v", err)
+		}
+	})
+
+	t.Run("Should extract mentions from content", func(t *testing.T) {
+		post := Post{Title: "title", Content: "@alice hi @Bob_1, mail me at carol@example.com or ask @alice", Visibility: VisibilityDirect}
+		if err := post.Prepare(); err != nil {
+			t.Errorf("Post prepare should not return an error. Error: %v", err)
+		}
+		if !reflect.DeepEqual(post.Mentions, []string{"alice", "bob_1"}) {
+			t.Errorf("Post prepare should extract unique mentions. Got: %v", post.Mentions)
+		}
+	})
+}
+
+func TestSensitivePost(t *testing.T) {
+	t.Run("Should flag posts with a content warning as sensitive", func(t *testing.T) {
+		post := Post{Title: "title", Content: "content", ContentWarning: " spoilers "}
+		if err := post.Prepare(); err != nil {
+			t.Errorf("Post prepare should not return an error. Error: %v", err)
+		}
+		if !post.Sensitive || post.ContentWarning != "spoilers" {
+			t.Errorf("Post prepare should flag the post and trim the warning. Got: %v, %q", post.Sensitive, post.ContentWarning)
+		}
+	})
+
+	t.Run("Should collapse sensitive posts unless the viewer expands them", func(t *testing.T) {
+		post := Post{Sensitive: true}
+		if post.Collapse(false); !post.Collapsed {
+			t.Errorf("Collapse should hide sensitive posts by default")
+		}
+		if post.Collapse(true); post.Collapsed {
+			t.Errorf("Collapse should expand sensitive posts for viewers who prefer so")
+		}
+		plain := Post{}
+		if plain.Collapse(false); plain.Collapsed {
+			t.Errorf("Collapse should not hide posts that aren't sensitive")
+		}
+	})
 }
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// Profile is the public representation of a user, with social counters.
+type Profile struct {
+	Id              string     `json:"id"`
+	Name            string     `json:"name"`
+	Nick            string     `json:"nick"`
+	Email           string     `json:"email,omitempty"`
+	Bio             string     `json:"bio"`
+	AvatarKey       string     `json:"-"`
+	AvatarURL       string     `json:"avatarUrl,omitempty"`
+	Website         string     `json:"website"`
+	Location        string     `json:"location"`
+	Pronouns        string     `json:"pronouns"`
+	Protected       bool       `json:"protected"`
+	ExpandSensitive bool       `json:"expandSensitive,omitempty"`
+	Followers       uint64     `json:"followers"`
+	Following       uint64     `json:"following"`
+	Posts           uint64     `json:"posts"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       *time.Time `json:"updatedAt,omitempty"`
+}
+
+// VisibleTo returns the profile as seen by viewerId. The e-mail and preferences are only visible to their owner.
+func (profile Profile) VisibleTo(viewerId string) Profile {
+	if profile.Id != viewerId {
+		profile.Email = ""
+		profile.ExpandSensitive = false
+	}
+	if profile.AvatarKey != "" {
+		profile.AvatarURL = "/media/" + profile.AvatarKey
+	}
+
+	return profile
+}
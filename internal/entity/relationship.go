@@ -0,0 +1,13 @@
+package entity
+
+// Relationship describes how the authenticated user relates to another account.
+type Relationship struct {
+	Id         string `json:"id"`
+	Following  bool   `json:"following"`
+	FollowedBy bool   `json:"followedBy"`
+	Pending    bool   `json:"pending"`
+	Requested  bool   `json:"requested"`
+	Blocking   bool   `json:"blocking"`
+	BlockedBy  bool   `json:"blockedBy"`
+	Muting     bool   `json:"muting"`
+}
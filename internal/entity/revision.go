@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Revision is a previous version of a published post, kept when the post is edited.
+type Revision struct {
+	Id         uint64    `json:"id"`
+	PostId     uint64    `json:"postId"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	ReplacedAt time.Time `json:"replacedAt"`
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxStoryContentLength = 300
+	MaxStoryAttachments   = 1
+)
+
+// Story is an ephemeral post shown on the stories rail of the followers of its author until it expires.
+type Story struct {
+	Id            uint64       `json:"id,omitempty"`
+	AuthorId      string       `json:"authorId,omitempty"`
+	AuthorNick    string       `json:"authorNick,omitempty"`
+	Content       string       `json:"content,omitempty"`
+	Seen          bool         `json:"seenByMe"`
+	Views         *uint64      `json:"views,omitempty"`
+	ExpiresAt     time.Time    `json:"expiresAt"`
+	CreatedAt     time.Time    `json:"createdAt,omitempty"`
+	AttachmentIds []uint64     `json:"attachmentIds,omitempty"`
+	Attachments   []Attachment `json:"attachments,omitempty"`
+}
+
+// StoryGroup gathers the active stories of an author, oldest first, as shown on the rail.
+type StoryGroup struct {
+	AuthorId   string  `json:"authorId"`
+	AuthorNick string  `json:"authorNick"`
+	Unseen     bool    `json:"unseen"`
+	Stories    []Story `json:"stories"`
+}
+
+// StoryView records that a user watched a story.
+type StoryView struct {
+	ViewerId   string    `json:"viewerId"`
+	ViewerNick string    `json:"viewerNick"`
+	ViewedAt   time.Time `json:"viewedAt"`
+}
+
+func (story *Story) Prepare() error {
+	story.Content = strings.TrimSpace(story.Content)
+	var fields []errorType.FieldError
+	if story.Content == "" && len(story.AttachmentIds) == 0 {
+		fields = append(fields, errorType.NewFieldError("content", errorType.CodeRequired,
+			"content or an attachment is required"))
+	}
+	if utf8.RuneCountInString(story.Content) > maxStoryContentLength {
+		fields = append(fields, errorType.NewFieldError("content", errorType.CodeTooLong,
+			fmt.Sprintf("content must have at most %d characters", maxStoryContentLength)))
+	}
+	if len(story.AttachmentIds) > MaxStoryAttachments {
+		fields = append(fields, errorType.NewFieldError("attachmentIds", errorType.CodeTooMany,
+			fmt.Sprintf("a story can have at most %d attachment", MaxStoryAttachments)))
+	}
+	if len(fields) > 0 {
+		return errorType.NewErrorStoryValidation(fields...)
+	}
+
+	return nil
+}
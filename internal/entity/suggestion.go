@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Suggestion is an account the user may want to follow, reached through the accounts they already follow.
+type Suggestion struct {
+	Id           string     `json:"id"`
+	Name         string     `json:"name"`
+	Nick         string     `json:"nick"`
+	AvatarKey    string     `json:"-"`
+	AvatarURL    string     `json:"avatarUrl,omitempty"`
+	Mutuals      uint64     `json:"mutuals"`
+	FollowedBy   string     `json:"-"`
+	LastActivity *time.Time `json:"lastActivity,omitempty"`
+	Reason       string     `json:"reason"`
+}
+
+// Explain fills the human readable reason and avatar url of the suggestion.
+func (suggestion *Suggestion) Explain() {
+	switch suggestion.Mutuals {
+	case 0, 1:
+		suggestion.Reason = fmt.Sprintf("followed by %s", suggestion.FollowedBy)
+	case 2:
+		suggestion.Reason = fmt.Sprintf("followed by %s and 1 other", suggestion.FollowedBy)
+	default:
+		suggestion.Reason = fmt.Sprintf("followed by %s and %d others", suggestion.FollowedBy, suggestion.Mutuals-1)
+	}
+
+	if suggestion.AvatarKey != "" {
+		suggestion.AvatarURL = "/media/" + suggestion.AvatarKey
+	}
+}
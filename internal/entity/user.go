@@ -5,19 +5,37 @@ import (
 	"github.com/edigar/socialnets-api/internal/error_type"
 	"github.com/edigar/socialnets-api/pkg/crypt"
 	"net/mail"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxBioLength      = 160
+	maxLocationLength = 30
+	maxPronounsLength = 30
+	maxWebsiteLength  = 255
 )
 
 type User struct {
-	Id        string     `json:"id,omitempty"`
-	Name      string     `json:"name,omitempty"`
-	Nick      string     `json:"nick,omitempty"`
-	Email     string     `json:"email,omitempty"`
-	Password  string     `json:"-"`
-	CreatedAt time.Time  `json:"createdAt,omitempty"`
-	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	Id              string     `json:"id,omitempty"`
+	Name            string     `json:"name,omitempty"`
+	Nick            string     `json:"nick,omitempty"`
+	Email           string     `json:"email,omitempty"`
+	Password        string     `json:"-"`
+	Bio             string     `json:"bio,omitempty"`
+	AvatarKey       string     `json:"-"`
+	Website         string     `json:"website,omitempty"`
+	Location        string     `json:"location,omitempty"`
+	Pronouns        string     `json:"pronouns,omitempty"`
+	Protected       bool       `json:"protected"`
+	ExpandSensitive bool       `json:"expandSensitive"`
+	Moderator       bool       `json:"-"`
+	Suspended       bool       `json:"-"`
+	CreatedAt       time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt       *time.Time `json:"updatedAt,omitempty"`
 }
 
 func (user *User) Prepare(step string) error {
@@ -32,28 +50,57 @@ func (user *User) Prepare(step string) error {
 }
 
 func (user *User) validate(step string) error {
-	if user.Name == "" {
-		return errorType.NewErrorUserValidation("username is required")
+	var fields []errorType.FieldError
+	invalid := func(field, code, message string) {
+		fields = append(fields, errorType.NewFieldError(field, code, message))
 	}
 
-	if !regexp.MustCompile("^[A-Za-z\\s]{3,}$").MatchString(user.Name) {
-		return errorType.NewErrorUserValidation("username must have three or more characters")
+	if user.Name == "" {
+		invalid("name", errorType.CodeRequired, "username is required")
+	} else if !regexp.MustCompile("^[A-Za-z\\s]{3,}$").MatchString(user.Name) {
+		invalid("name", errorType.CodeInvalid, "username must have three or more characters")
 	}
 
 	if user.Nick == "" {
-		return errorType.NewErrorUserValidation("nick is required")
+		invalid("nick", errorType.CodeRequired, "nick is required")
 	}
 
 	if user.Email == "" {
-		return errorType.NewErrorUserValidation("email is required")
+		invalid("email", errorType.CodeRequired, "email is required")
+	} else if _, err := mail.ParseAddress(user.Email); err != nil {
+		invalid("email", errorType.CodeInvalid, fmt.Sprintf("invalid email. %s", err))
 	}
 
-	if _, err := mail.ParseAddress(user.Email); err != nil {
-		return errorType.NewErrorUserValidation(fmt.Sprintf("invalid email. %s", err))
+	if step == "register" && user.Password == "" {
+		invalid("password", errorType.CodeRequired, "password is required")
 	}
 
-	if step == "register" && user.Password == "" {
-		return errorType.NewErrorUserValidation("password is required")
+	if utf8.RuneCountInString(strings.TrimSpace(user.Bio)) > maxBioLength {
+		invalid("bio", errorType.CodeTooLong, fmt.Sprintf("bio must have at most %d characters", maxBioLength))
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(user.Location)) > maxLocationLength {
+		invalid("location", errorType.CodeTooLong,
+			fmt.Sprintf("location must have at most %d characters", maxLocationLength))
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(user.Pronouns)) > maxPronounsLength {
+		invalid("pronouns", errorType.CodeTooLong,
+			fmt.Sprintf("pronouns must have at most %d characters", maxPronounsLength))
+	}
+
+	if website := strings.TrimSpace(user.Website); website != "" {
+		websiteUrl, err := url.ParseRequestURI(website)
+		if err != nil || (websiteUrl.Scheme != "http" && websiteUrl.Scheme != "https") || websiteUrl.Host == "" {
+			invalid("website", errorType.CodeInvalid, "website must be a valid http or https url")
+		} else if len(website) > maxWebsiteLength {
+			invalid("website", errorType.CodeTooLong,
+				fmt.Sprintf("website must have at most %d characters", maxWebsiteLength))
+		}
+	}
+
+	if len(fields) > 0 {
+		return errorType.NewErrorUserValidation(fields...)
 	}
 
 	return nil
@@ -63,6 +110,10 @@ func (user *User) format(step string) error {
 	user.Name = strings.TrimSpace(user.Name)
 	user.Nick = strings.TrimSpace(user.Nick)
 	user.Email = strings.TrimSpace(user.Email)
+	user.Bio = strings.TrimSpace(user.Bio)
+	user.Website = strings.TrimSpace(user.Website)
+	user.Location = strings.TrimSpace(user.Location)
+	user.Pronouns = strings.TrimSpace(user.Pronouns)
 
 	if step == "register" {
 		passwordHash, err := crypt.Hash(user.Password)
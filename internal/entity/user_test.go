@@ -1,6 +1,10 @@
 package entity
 
 import (
+	"errors"
+	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -206,4 +210,74 @@ func TestUserPrepareWithValidUser(t *testing.T) {
 			t.Errorf("User prepare should return error if password is empty on register step. Error %v", err)
 		}
 	})
+
+	t.Run("Should return an error if profile fields are invalid", func(t *testing.T) {
+		scenarios := []struct {
+			user     User
+			expected string
+		}{
+			{
+				User{Name: "name", Nick: "nick", Email: "name@mail.com", Bio: strings.Repeat("a", 161)},
+				"bio must have at most 160 characters",
+			},
+			{
+				User{Name: "name", Nick: "nick", Email: "name@mail.com", Location: strings.Repeat("a", 31)},
+				"location must have at most 30 characters",
+			},
+			{
+				User{Name: "name", Nick: "nick", Email: "name@mail.com", Pronouns: strings.Repeat("a", 31)},
+				"pronouns must have at most 30 characters",
+			},
+			{
+				User{Name: "name", Nick: "nick", Email: "name@mail.com", Website: "javascript:alert(1)"},
+				"website must be a valid http or https url",
+			},
+			{
+				User{Name: "name", Nick: "nick", Email: "name@mail.com", Website: "example.com"},
+				"website must be a valid http or https url",
+			},
+		}
+
+		for _, scenario := range scenarios {
+			err := scenario.user.Prepare("no register")
+			if err == nil || err.Error() != scenario.expected {
+				t.Errorf("User prepare should return %q. Got: %v", scenario.expected, err)
+			}
+		}
+	})
+
+	t.Run("Should accept and format valid profile fields", func(t *testing.T) {
+		user := User{
+			Name:     "name",
+			Nick:     "nick",
+			Email:    "name@mail.com",
+			Bio:      "  Gopher  ",
+			Website:  " https://example.com ",
+			Location: " Recife ",
+			Pronouns: " they/them ",
+		}
+		if err := user.Prepare("no register"); err != nil {
+			t.Errorf("User prepare should not return an error for valid profile fields. Error: %v", err)
+		}
+		if user.Bio != "Gopher" || user.Website != "https://example.com" || user.Location != "Recife" || user.Pronouns != "they/them" {
+			t.Errorf("User prepare should trim profile fields. Got: %v", user)
+		}
+	})
+	t.Run("Should report every invalid field at once", func(t *testing.T) {
+		user := User{Name: "ab", Email: "not an email", Bio: strings.Repeat("a", maxBioLength+1)}
+		err := user.Prepare("register")
+
+		var uve *errorType.ErrorUserValidation
+		if !errors.As(err, &uve) {
+			t.Fatalf("User prepare should return a user validation error. Got: %v", err)
+		}
+		expected := []string{"name:invalid", "nick:required", "email:invalid", "password:required", "bio:too_long"}
+		var got []string
+		for _, field := range uve.Fields {
+			got = append(got, field.Field+":"+field.Code)
+		}
+		if !slices.Equal(got, expected) {
+			t.Errorf("User prepare should report %v. Got: %v", expected, got)
+		}
+	})
 }
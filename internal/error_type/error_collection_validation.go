@@ -0,0 +1,20 @@
+package errorType
+
+// ErrorCollectionValidation reports every invalid field of a collection at once.
+type ErrorCollectionValidation struct {
+	Fields []FieldError
+}
+
+func NewErrorCollectionValidation(fields ...FieldError) *ErrorCollectionValidation {
+	return &ErrorCollectionValidation{
+		Fields: fields,
+	}
+}
+
+func (cve *ErrorCollectionValidation) Error() string {
+	return joinFields(cve.Fields)
+}
+
+func (cve *ErrorCollectionValidation) FieldErrors() []FieldError {
+	return cve.Fields
+}
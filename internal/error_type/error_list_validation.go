@@ -0,0 +1,20 @@
+package errorType
+
+// ErrorListValidation reports every invalid field of a list at once.
+type ErrorListValidation struct {
+	Fields []FieldError
+}
+
+func NewErrorListValidation(fields ...FieldError) *ErrorListValidation {
+	return &ErrorListValidation{
+		Fields: fields,
+	}
+}
+
+func (lve *ErrorListValidation) Error() string {
+	return joinFields(lve.Fields)
+}
+
+func (lve *ErrorListValidation) FieldErrors() []FieldError {
+	return lve.Fields
+}
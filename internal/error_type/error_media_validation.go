@@ -0,0 +1,20 @@
+package errorType
+
+// ErrorMediaValidation reports every invalid field of a media at once.
+type ErrorMediaValidation struct {
+	Fields []FieldError
+}
+
+func NewErrorMediaValidation(fields ...FieldError) *ErrorMediaValidation {
+	return &ErrorMediaValidation{
+		Fields: fields,
+	}
+}
+
+func (mve *ErrorMediaValidation) Error() string {
+	return joinFields(mve.Fields)
+}
+
+func (mve *ErrorMediaValidation) FieldErrors() []FieldError {
+	return mve.Fields
+}
@@ -1,20 +1,20 @@
 package errorType
 
-import (
-	"errors"
-	"fmt"
-)
-
+// ErrorPostValidation reports every invalid field of a post at once.
 type ErrorPostValidation struct {
-	Err error
+	Fields []FieldError
 }
 
-func NewErrorPostValidation(text string) *ErrorPostValidation {
+func NewErrorPostValidation(fields ...FieldError) *ErrorPostValidation {
 	return &ErrorPostValidation{
-		Err: errors.New(text),
+		Fields: fields,
 	}
 }
 
 func (uve *ErrorPostValidation) Error() string {
-	return fmt.Sprintf("%s", uve.Err)
+	return joinFields(uve.Fields)
+}
+
+func (uve *ErrorPostValidation) FieldErrors() []FieldError {
+	return uve.Fields
 }
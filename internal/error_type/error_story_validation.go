@@ -0,0 +1,20 @@
+package errorType
+
+// ErrorStoryValidation reports every invalid field of a story at once.
+type ErrorStoryValidation struct {
+	Fields []FieldError
+}
+
+func NewErrorStoryValidation(fields ...FieldError) *ErrorStoryValidation {
+	return &ErrorStoryValidation{
+		Fields: fields,
+	}
+}
+
+func (sve *ErrorStoryValidation) Error() string {
+	return joinFields(sve.Fields)
+}
+
+func (sve *ErrorStoryValidation) FieldErrors() []FieldError {
+	return sve.Fields
+}
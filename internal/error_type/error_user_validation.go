@@ -1,20 +1,20 @@
 package errorType
 
-import (
-	"errors"
-	"fmt"
-)
-
+// ErrorUserValidation reports every invalid field of a user at once.
 type ErrorUserValidation struct {
-	Err error
+	Fields []FieldError
 }
 
-func NewErrorUserValidation(text string) *ErrorUserValidation {
+func NewErrorUserValidation(fields ...FieldError) *ErrorUserValidation {
 	return &ErrorUserValidation{
-		Err: errors.New(text),
+		Fields: fields,
 	}
 }
 
 func (uve *ErrorUserValidation) Error() string {
-	return fmt.Sprintf("%s", uve.Err)
+	return joinFields(uve.Fields)
+}
+
+func (uve *ErrorUserValidation) FieldErrors() []FieldError {
+	return uve.Fields
 }
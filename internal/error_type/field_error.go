@@ -0,0 +1,62 @@
+package errorType
+
+import "strings"
+
+// Codes of field errors, stable so clients can rely on them.
+const (
+	CodeRequired   = "required"
+	CodeInvalid    = "invalid"
+	CodeTooShort   = "too_short"
+	CodeTooLong    = "too_long"
+	CodeTooMany    = "too_many"
+	CodeTooFew     = "too_few"
+	CodeDuplicate  = "duplicate"
+	CodeNotAllowed = "not_allowed"
+	CodeTaken      = "taken"
+)
+
+// FieldError is a validation failure of a single field of the request. Field is empty for failures that aren't about
+// any field in particular.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewFieldError(field, code, message string) FieldError {
+	return FieldError{Field: field, Code: code, Message: message}
+}
+
+// joinFields is the text of a validation error: the messages of its fields, separated by semicolons.
+func joinFields(fields []FieldError) string {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// CodedError is an error clients tell apart by its stable Code. Err, when set, is a more general error it is a kind
+// of, as reported by errors.Is.
+type CodedError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func NewCodedError(code, message string) *CodedError {
+	return &CodedError{Code: code, Message: message}
+}
+
+func (ce *CodedError) Error() string {
+	return ce.Message
+}
+
+func (ce *CodedError) ErrorCode() string {
+	return ce.Code
+}
+
+func (ce *CodedError) Unwrap() error {
+	return ce.Err
+}
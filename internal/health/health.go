@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check probes a single dependency. Run must honor the deadline of ctx.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Component struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+var draining atomic.Bool
+
+// Drain makes the service report itself not ready from now on, so load balancers stop routing to it while it shuts
+// down.
+func Drain() {
+	draining.Store(true)
+}
+
+func Draining() bool {
+	return draining.Load()
+}
+
+// Run runs the checks concurrently, each one bounded by timeout, and reports the status of every component. The report
+// is up only if every check passed.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) Report {
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component := run(ctx, timeout, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[check.Name] = component
+			if component.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run gives up on the check once its timeout is over, even if the check itself ignores the deadline.
+func run(ctx context.Context, timeout time.Duration, check Check) Component {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	component := Component{Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+
+	return component
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	ok := Check{Name: "ok", Run: func(ctx context.Context) error { return nil }}
+	failing := Check{Name: "failing", Run: func(ctx context.Context) error { return errors.New("connection refused") }}
+	stuck := Check{Name: "stuck", Run: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+
+	t.Run("Should be up when every check passes", func(t *testing.T) {
+		report := Run(t.Context(), time.Second, ok)
+		if report.Status != StatusUp || report.Components["ok"].Status != StatusUp {
+			t.Errorf("Run should report up. Got: %+v", report)
+		}
+	})
+
+	t.Run("Should report each failing component", func(t *testing.T) {
+		report := Run(t.Context(), time.Second, ok, failing)
+		if report.Status != StatusDown {
+			t.Errorf("Run should report down when a check fails. Got: %+v", report)
+		}
+		if component := report.Components["failing"]; component.Status != StatusDown || component.Error != "connection refused" {
+			t.Errorf("Run should report the failing component with its error. Got: %+v", component)
+		}
+		if report.Components["ok"].Status != StatusUp {
+			t.Errorf("Run should report the passing component as up. Got: %+v", report)
+		}
+	})
+
+	t.Run("Should give up on checks past the timeout", func(t *testing.T) {
+		start := time.Now()
+		report := Run(t.Context(), 20*time.Millisecond, stuck)
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Run should return once the timeout is over. Took: %v", elapsed)
+		}
+		if component := report.Components["stuck"]; component.Status != StatusDown ||
+			component.Error != context.DeadlineExceeded.Error() {
+			t.Errorf("Run should report a timed out check as down. Got: %+v", component)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("Should fall back to the default logger", func(t *testing.T) {
+		if logger := FromContext(context.Background()); logger != slog.Default() {
+			t.Errorf("FromContext should return the default logger without one in the context. Got: %v", logger)
+		}
+	})
+
+	t.Run("Should add context attributes to records", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := WithLogger(context.Background(), New(&buf, "info"))
+		ctx = With(ctx, "request_id", "abc")
+		FromContext(ctx).Info("hello")
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("Logger should write JSON records. Got: %s", buf.String())
+		}
+		if record["msg"] != "hello" || record["request_id"] != "abc" {
+			t.Errorf("Logger should keep the attributes of the context. Got: %v", record)
+		}
+	})
+
+	t.Run("Should filter records below the level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(&buf, "warn")
+		logger.Info("hidden")
+		if buf.Len() != 0 {
+			t.Errorf("Logger should drop records below its level. Got: %s", buf.String())
+		}
+	})
+}
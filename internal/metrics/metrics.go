@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Login results.
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+	LoginError   = "error"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time to serve HTTP requests, by route template and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "socialnets_logins_total",
+		Help: "Login attempts by result: success, failure (wrong credentials) or error.",
+	}, []string{"result"})
+
+	PostsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "socialnets_posts_created_total",
+		Help: "Posts created, including drafts and scheduled posts.",
+	})
+	Follows = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "socialnets_follows_total",
+		Help: "Follows established, including follow requests once accepted.",
+	})
+	Likes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "socialnets_likes_total",
+		Help: "Post likes.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		dbStatsCollector{},
+		requests,
+		requestDuration,
+		logins,
+		PostsCreated,
+		Follows,
+		Likes,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a served request under its route template, like /api/post/{postId}.
+func ObserveRequest(route, method string, status int, duration time.Duration) {
+	requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	requestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// Login records a login attempt with one of the Login results.
+func Login(result string) {
+	logins.WithLabelValues(result).Inc()
+}
+
+var (
+	dbOpenConnections = prometheus.NewDesc("db_open_connections", "Established connections, in use and idle.", nil, nil)
+	dbInUse           = prometheus.NewDesc("db_in_use_connections", "Connections currently in use.", nil, nil)
+	dbIdle            = prometheus.NewDesc("db_idle_connections", "Idle connections.", nil, nil)
+	dbWaitCount       = prometheus.NewDesc("db_wait_count_total", "Connections waited for.", nil, nil)
+	dbWaitDuration    = prometheus.NewDesc("db_wait_duration_seconds_total", "Time blocked waiting for a connection.", nil, nil)
+)
+
+// dbStatsCollector exposes the statistics of the shared database pool, once it's open.
+type dbStatsCollector struct{}
+
+func (dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbOpenConnections
+	ch <- dbInUse
+	ch <- dbIdle
+	ch <- dbWaitCount
+	ch <- dbWaitDuration
+}
+
+func (dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, ok := database.Stats()
+	if !ok {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(dbOpenConnections, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbInUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(dbIdle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(dbWaitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(dbWaitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
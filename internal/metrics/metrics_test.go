@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	ObserveRequest("/api/post/{postId}", http.MethodGet, http.StatusOK, 30*time.Millisecond)
+	Login(LoginFailure)
+	PostsCreated.Inc()
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	body, _ := io.ReadAll(recorder.Body)
+
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/post/{postId}",status="200"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/post/{postId}"} 1`,
+		`socialnets_logins_total{result="failure"} 1`,
+		`socialnets_posts_created_total 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
+}
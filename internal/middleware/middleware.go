@@ -1,25 +1,296 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/config"
+	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/metrics"
+	"github.com/edigar/socialnets-api/internal/ratelimit"
+	"github.com/edigar/socialnets-api/internal/repository"
 	"github.com/edigar/socialnets-api/internal/response"
-	"log"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds incoming request ids, so clients can't flood the logs.
+const maxRequestIDLength = 128
+
+// RequestID tags the request with the id sent on X-Request-ID, or a new one, echoes it on the response and adds it
+// to the logger of the request context.
+func RequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestId) {
+			requestId = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestId)
+		next(w, r.WithContext(logging.With(r.Context(), "request_id", requestId)))
+	}
+}
+
+// Trace serves the request on a server span named after the method and route template, continuing the trace of the
+// caller when the request carries W3C trace context headers. The trace id is added to the logger of the request.
+func Trace(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx = logging.With(ctx, "trace_id", spanContext.TraceID().String())
+		}
+
+		recorder := record(w)
+		next(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.err != nil {
+			span.RecordError(recorder.err)
+		}
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	}
+}
+
+// Logger writes an access log entry once the request is served, with its status, size, duration and user. Requests
+// that failed with an internal error are logged at the error level, along with the error.
 func Logger(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf(" %s %s %s", r.Method, r.RequestURI, r.Host)
-		next(w, r)
+		start := time.Now()
+		recorder := record(w)
+		next(recorder, r)
+
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+		}
+		if recorder.userId != "" {
+			attrs = append(attrs, "user_id", recorder.userId)
+		}
+		if recorder.err != nil {
+			logging.FromContext(r.Context()).Error("request", append(attrs, "error", recorder.err)...)
+			return
+		}
+		logging.FromContext(r.Context()).Info("request", attrs...)
 	}
 }
 
-func Authenticate(next http.HandlerFunc) http.HandlerFunc {
+// Metrics records the status and duration of the request under the URI template of its route.
+func Metrics(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := record(w)
+		next(recorder, r)
+
+		metrics.ObserveRequest(route, r.Method, recorder.status, time.Since(start))
+	}
+}
+
+// Accounts tells whether the account of an authenticated request is suspended.
+type Accounts interface {
+	IsSuspended(ctx context.Context, userId string) (bool, error)
+}
+
+var errAccountSuspended = errorType.NewCodedError("account_suspended", "account suspended")
+
+// Authenticate answers 401 to requests without a valid token. Unless accounts is nil, it also answers 403 to requests
+// of suspended accounts, whose tokens were issued before the suspension, and 401 to the ones of deleted accounts.
+func Authenticate(accounts Accounts, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := authentication.TokenValidate(r); err != nil {
 			response.Error(w, http.StatusUnauthorized, err)
 			return
 		}
+		if userId, err := authentication.ExtractUserId(r); err == nil {
+			if recorder, ok := w.(*responseRecorder); ok {
+				recorder.userId = userId
+			}
+			trace.SpanFromContext(r.Context()).SetAttributes(semconv.UserID(userId))
+			r = r.WithContext(logging.With(r.Context(), "user_id", userId))
+
+			if accounts != nil {
+				suspended, err := accounts.IsSuspended(r.Context(), userId)
+				switch {
+				case errors.Is(err, repository.ErrNotFound):
+					response.Error(w, http.StatusUnauthorized, err)
+					return
+				case err != nil:
+					response.Error(w, http.StatusInternalServerError, err)
+					return
+				case suspended:
+					response.Error(w, http.StatusForbidden, errAccountSuspended)
+					return
+				}
+			}
+		}
+		next(w, r)
+	}
+}
+
+var errMetricsToken = errorType.NewCodedError("invalid_metrics_token", "missing or invalid metrics token")
+
+// MetricsToken answers 401 to requests without the MetricsToken of the configuration as bearer token. Requests are let
+// through when there's none, which leaves metrics open to whoever reaches the API.
+func MetricsToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := config.FromContext(r.Context()).MetricsToken
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			response.Error(w, http.StatusUnauthorized, errMetricsToken)
+			return
+		}
+		next(w, r)
+	}
+}
+
+var errRateLimited = errorType.NewCodedError("rate_limited", "rate limit exceeded")
+
+// RateLimit takes a token from the bucket of the authenticated user, or of the client address for anonymous
+// requests, under policy, and answers 429 once it's empty. Responses carry the RateLimit-* headers of the bucket.
+// Requests are let through when the store fails, so an outage of the store doesn't take the API down.
+func RateLimit(store ratelimit.Store, policy ratelimit.Policy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := "ip:" + clientIP(r)
+		if userId, err := authentication.ExtractUserId(r); err == nil {
+			key = "user:" + userId
+		}
+
+		result, err := store.Take(r.Context(), policy.Name+":"+key, policy)
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("rate limit store failed", "error", err)
+			next(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Period.Seconds())))
+		header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			response.Error(w, http.StatusTooManyRequests, errRateLimited)
+			return
+		}
 		next(w, r)
 	}
 }
+
+// clientIP returns the address of the client: the last one of X-Forwarded-For when the reverse proxy setting it is
+// trusted, the address of the connection otherwise.
+func clientIP(r *http.Request) string {
+	if config.FromContext(r.Context()).RateLimit.TrustForwardedFor {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			addresses := strings.Split(forwarded[len(forwarded)-1], ",")
+			if address := strings.TrimSpace(addresses[len(addresses)-1]); address != "" {
+				return address
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// seconds rounds d up to whole seconds, as rate limit headers expect.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// responseRecorder keeps what the access log needs from a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	userId      string
+	err         error
+	wroteHeader bool
+}
+
+// record returns w when it already is a recorder, so the middlewares of a route share a single one.
+func record(w http.ResponseWriter) *responseRecorder {
+	if recorder, ok := w.(*responseRecorder); ok {
+		return recorder
+	}
+
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	rr.wroteHeader = true
+	n, err := rr.ResponseWriter.Write(data)
+	rr.bytes += n
+
+	return n, err
+}
+
+// RecordError keeps the internal error of the response, see response.Error.
+func (rr *responseRecorder) RecordError(err error) {
+	rr.err = err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+func validRequestID(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestId {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/authentication"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/ratelimit"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/response"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(func(w http.ResponseWriter, r *http.Request) {
+		seen = w.Header().Get(RequestIDHeader)
+	})
+
+	t.Run("Should honor the incoming request id", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/post", nil)
+		r.Header.Set(RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if seen != "abc-123" || w.Header().Get(RequestIDHeader) != "abc-123" {
+			t.Errorf("RequestID should echo the incoming id. Got: %q", w.Header().Get(RequestIDHeader))
+		}
+	})
+
+	t.Run("Should generate an id for missing or invalid ones", func(t *testing.T) {
+		for _, incoming := range []string{"", "has spaces", strings.Repeat("a", 129)} {
+			r := httptest.NewRequest(http.MethodGet, "/api/post", nil)
+			r.Header.Set(RequestIDHeader, incoming)
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if id := w.Header().Get(RequestIDHeader); len(id) != 32 || id == incoming {
+				t.Errorf("RequestID should generate a new id for %q. Got: %q", incoming, id)
+			}
+		}
+	})
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("Should log status, size and request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := httptest.NewRequest(http.MethodPost, "/api/post", nil)
+		r = r.WithContext(logging.WithLogger(r.Context(), logging.New(&buf, "info")))
+		r.Header.Set(RequestIDHeader, "abc-123")
+		handler := RequestID(Logger(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("hello"))
+		}))
+		handler(httptest.NewRecorder(), r)
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("Logger should write a JSON record. Got: %s", buf.String())
+		}
+		if record["status"] != float64(http.StatusCreated) || record["bytes"] != float64(5) ||
+			record["request_id"] != "abc-123" || record["path"] != "/api/post" {
+			t.Errorf("Logger should log the request. Got: %v", record)
+		}
+		if _, ok := record["user_id"]; ok {
+			t.Errorf("Logger should not log a user id for anonymous requests. Got: %v", record)
+		}
+	})
+
+	t.Run("Should log internal errors hidden from the client", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := httptest.NewRequest(http.MethodGet, "/api/post", nil)
+		r = r.WithContext(logging.WithLogger(r.Context(), logging.New(&buf, "info")))
+		handler := Logger(func(w http.ResponseWriter, r *http.Request) {
+			response.Error(w, http.StatusInternalServerError, errors.New("connection refused"))
+		})
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("Logger should write a JSON record. Got: %s", buf.String())
+		}
+		if record["level"] != "ERROR" || record["error"] != "connection refused" {
+			t.Errorf("Logger should log the internal error. Got: %v", record)
+		}
+		if strings.Contains(w.Body.String(), "connection refused") {
+			t.Errorf("Logger should not reveal the internal error. Got: %s", w.Body.String())
+		}
+	})
+}
+
+func TestTrace(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer provider.Shutdown(t.Context())
+
+	t.Run("Should continue the incoming trace on a span named after the route", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/post/1", nil)
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		handler := Trace("/api/post/{postId}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		handler(httptest.NewRecorder(), r)
+
+		ended := spans.Ended()
+		if len(ended) != 1 {
+			t.Fatalf("Trace should end one span. Got: %d", len(ended))
+		}
+		span := ended[0]
+		if span.Name() != "GET /api/post/{postId}" {
+			t.Errorf("Trace should name the span after the route template. Got: %q", span.Name())
+		}
+		if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+			span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+			t.Errorf("Trace should continue the incoming trace. Got: %v, parent %v", span.SpanContext(), span.Parent())
+		}
+		if span.Status().Code != codes.Error {
+			t.Errorf("Trace should mark server errors. Got: %v", span.Status())
+		}
+	})
+}
+
+// accounts is an Accounts of the suspended state of each known user.
+type accounts map[string]bool
+
+func (a accounts) IsSuspended(_ context.Context, userId string) (bool, error) {
+	suspended, ok := a[userId]
+	if !ok {
+		return false, repository.ErrNotFound
+	}
+
+	return suspended, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	cfg := config.Default()
+	cfg.SecretKey = "0123456789abcdef0123456789abcdef"
+	active := "eedf21bf-dde8-4c85-b50b-89a1cba87c2e"
+	suspended := "0d4f5a1c-5b5f-4a8e-9a43-3a1c1d0a4f11"
+	deleted := "c3b4a5d6-1e2f-4a3b-9c8d-7e6f5a4b3c33"
+	handler := Authenticate(accounts{active: false, suspended: true}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	request := func(userId string) *httptest.ResponseRecorder {
+		token, err := authentication.CreateToken(cfg.SecretKey, userId)
+		if err != nil {
+			t.Fatalf("CreateToken should not return an error. Error: %v", err)
+		}
+		r := httptest.NewRequestWithContext(config.WithConfig(t.Context(), cfg), http.MethodGet, "/api/post", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	t.Run("Should let requests of active accounts through", func(t *testing.T) {
+		if w := request(active); w.Code != http.StatusNoContent {
+			t.Errorf("Authenticate should let the request through. Got: %d", w.Code)
+		}
+	})
+
+	t.Run("Should answer 403 to suspended accounts with a token issued before", func(t *testing.T) {
+		w := request(suspended)
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), `"code":"account_suspended"`) {
+			t.Errorf("Authenticate should reject suspended accounts. Got: %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Should answer 401 to deleted accounts", func(t *testing.T) {
+		if w := request(deleted); w.Code != http.StatusUnauthorized {
+			t.Errorf("Authenticate should reject deleted accounts. Got: %d", w.Code)
+		}
+	})
+
+	t.Run("Should answer 401 without a valid token", func(t *testing.T) {
+		r := httptest.NewRequestWithContext(config.WithConfig(t.Context(), cfg), http.MethodGet, "/api/post", nil)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authenticate should reject requests without a token. Got: %d", w.Code)
+		}
+	})
+}
+
+func TestMetricsToken(t *testing.T) {
+	handler := MetricsToken(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	request := func(token, authorization string) int {
+		cfg := config.Default()
+		cfg.MetricsToken = token
+		r := httptest.NewRequestWithContext(config.WithConfig(t.Context(), cfg), http.MethodGet, "/metrics", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	if code := request("", ""); code != http.StatusOK {
+		t.Errorf("MetricsToken should let requests through without a token configured. Got: %d", code)
+	}
+	if code := request("scrape", "Bearer scrape"); code != http.StatusOK {
+		t.Errorf("MetricsToken should let requests with the token through. Got: %d", code)
+	}
+	if code := request("scrape", ""); code != http.StatusUnauthorized {
+		t.Errorf("MetricsToken should answer 401 to requests without the token. Got: %d", code)
+	}
+	if code := request("scrape", "Bearer other"); code != http.StatusUnauthorized {
+		t.Errorf("MetricsToken should answer 401 to requests with another token. Got: %d", code)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Period: time.Minute}
+	handler := RateLimit(ratelimit.NewMemoryStore(), policy, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	t.Run("Should set the rate limit headers", func(t *testing.T) {
+		w := request("192.0.2.1:1234")
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("RateLimit should let the first request through. Got: %d", w.Code)
+		}
+		want := map[string]string{
+			"RateLimit-Policy":    "2;w=60",
+			"RateLimit-Limit":     "2",
+			"RateLimit-Remaining": "1",
+			"RateLimit-Reset":     "30",
+		}
+		for header, value := range want {
+			if got := w.Header().Get(header); got != value {
+				t.Errorf("RateLimit should set %s to %q. Got: %q", header, value, got)
+			}
+		}
+	})
+
+	t.Run("Should answer 429 once the bucket is empty", func(t *testing.T) {
+		request("192.0.2.2:1234")
+		request("192.0.2.2:1234")
+		w := request("192.0.2.2:5678")
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+			t.Errorf("RateLimit should deny the third request. Got: %d, Retry-After %q", w.Code,
+				w.Header().Get("Retry-After"))
+		}
+		if w := request("192.0.2.3:1234"); w.Code != http.StatusNoContent {
+			t.Errorf("RateLimit should limit each client on its own. Got: %d", w.Code)
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/post", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+
+	if ip := clientIP(r); ip != "10.0.0.1" {
+		t.Errorf("clientIP should ignore X-Forwarded-For by default. Got: %q", ip)
+	}
+
+	cfg := config.Default()
+	cfg.RateLimit.TrustForwardedFor = true
+	if ip := clientIP(r.WithContext(config.WithConfig(r.Context(), cfg))); ip != "203.0.113.7" {
+		t.Errorf("clientIP should use the last X-Forwarded-For address when trusted. Got: %q", ip)
+	}
+}
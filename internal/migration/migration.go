@@ -0,0 +1,303 @@
+package migration
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/lib/pq"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are goose SQL files named <version>_<description>.sql, with the statements to apply below a
+// "-- +goose Up" line and the ones to roll back below a "-- +goose Down" line. Applied versions are recorded on the
+// goose_db_version table, so databases migrated with the goose CLI keep working.
+
+var ErrNothingToRollBack = errors.New("no migration to roll back")
+
+const (
+	// lockKey is the Postgres advisory lock held while migrating, so replicas starting together don't race.
+	lockKey = 7321458620193847
+	// undefinedTable is the Postgres error code for a missing table.
+	undefinedTable = "42P01"
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	// AppliedAt is zero while the migration is pending.
+	AppliedAt time.Time
+}
+
+func (s Status) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+// Load reads the migrations in fsys, in ascending version order.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		migration, err := parse(name, string(content))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s share a version", migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+func parse(name, content string) (Migration, error) {
+	prefix, _, _ := strings.Cut(name, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version <= 0 {
+		return Migration{}, fmt.Errorf("migration %s doesn't start with a version", name)
+	}
+
+	migration := Migration{Version: version, Name: name}
+	var section *string
+	var found bool
+	for _, line := range strings.SplitAfter(content, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section, found = &migration.Up, true
+			continue
+		case "-- +goose Down":
+			section = &migration.Down
+			continue
+		}
+		if section != nil {
+			*section += line
+		}
+	}
+	if !found {
+		return Migration{}, fmt.Errorf("migration %s has no \"-- +goose Up\" section", name)
+	}
+
+	return migration, nil
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// applied returns when each applied version was applied. A database never migrated has none.
+func applied(ctx context.Context, q querier) (map[int64]time.Time, error) {
+	rows, err := q.QueryContext(ctx, `SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp
+		FROM goose_db_version ORDER BY version_id, id desc`)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == undefinedTable {
+		return map[int64]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var appliedAt sql.NullTime
+		if err = rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+			return nil, err
+		}
+		if isApplied && version > 0 {
+			// Rows written by goose always have a timestamp, but don't let a missing one look pending.
+			versions[version] = appliedAt.Time
+			if !appliedAt.Valid {
+				versions[version] = time.Unix(0, 0)
+			}
+		}
+	}
+
+	return versions, rows.Err()
+}
+
+func pending(migrations []Migration, applied map[int64]time.Time) []Migration {
+	var pending []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Status returns every migration with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	versions, err := applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration, AppliedAt: versions[migration.Version]}
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations not applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	versions, err := applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return pending(m.migrations, versions), nil
+}
+
+// Up applies every pending migration in version order, each one in its own transaction, and returns the applied ones.
+// It stops at the first failure.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range pending(m.migrations, versions) {
+			err = inTx(ctx, conn, migration.Up,
+				"INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)", migration.Version)
+			if err != nil {
+				return fmt.Errorf("applying %s: %w", migration.Name, err)
+			}
+			logging.FromContext(ctx).Info("applied migration", "version", migration.Version, "name", migration.Name)
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down rolls back the latest applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var done Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			err = inTx(ctx, conn, migration.Down,
+				"DELETE FROM goose_db_version WHERE version_id = $1", migration.Version)
+			if err != nil {
+				return fmt.Errorf("rolling back %s: %w", migration.Name, err)
+			}
+			logging.FromContext(ctx).Info("rolled back migration", "version", migration.Version, "name", migration.Name)
+			done = migration
+
+			return nil
+		}
+
+		return ErrNothingToRollBack
+	})
+
+	return done, err
+}
+
+// locked runs fn on a connection holding the migration lock, with the version table in place.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	// The lock is released on a fresh context, so it isn't left behind when ctx is canceled.
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	createStmt := `CREATE TABLE IF NOT EXISTS goose_db_version (
+		id serial PRIMARY KEY,
+		version_id bigint NOT NULL,
+		is_applied boolean NOT NULL,
+		tstamp timestamp DEFAULT now()
+	)`
+	if _, err = conn.ExecContext(ctx, createStmt); err != nil {
+		return err
+	}
+	insertStmt := `INSERT INTO goose_db_version (version_id, is_applied)
+		SELECT 0, true WHERE NOT EXISTS (SELECT 1 FROM goose_db_version)`
+	if _, err = conn.ExecContext(ctx, insertStmt); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// inTx runs the statements of a migration and records it on the version table atomically.
+func inTx(ctx context.Context, conn *sql.Conn, statements, record string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(statements) != "" {
+		if _, err = tx.ExecContext(ctx, statements); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
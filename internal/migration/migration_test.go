@@ -0,0 +1,66 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const usersMigration = `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (id uuid PRIMARY KEY);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE users;
+-- +goose StatementEnd
+`
+
+func TestLoad(t *testing.T) {
+	t.Run("Should load the migrations in version order", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"20240311170259_create_users_table.sql":    {Data: []byte(usersMigration)},
+			"20240311170225_create_uuid_extension.sql": {Data: []byte("-- +goose Up\nCREATE EXTENSION \"uuid-ossp\";\n")},
+			"README.md": {},
+		}
+		migrations, err := Load(fsys)
+		if err != nil {
+			t.Fatalf("Load should not return an error. Error: %v", err)
+		}
+		if len(migrations) != 2 || migrations[0].Version != 20240311170225 || migrations[1].Version != 20240311170259 {
+			t.Fatalf("Load should return the migrations sorted by version. Got: %+v", migrations)
+		}
+		if users := migrations[1]; !strings.Contains(users.Up, "CREATE TABLE users") || strings.Contains(users.Up, "DROP") ||
+			!strings.Contains(users.Down, "DROP TABLE users") || strings.Contains(users.Down, "CREATE") {
+			t.Errorf("Load should split the up and down sections. Got: %+v", users)
+		}
+	})
+
+	t.Run("Should reject invalid migrations", func(t *testing.T) {
+		invalid := []fstest.MapFS{
+			{"create_users_table.sql": {Data: []byte(usersMigration)}},
+			{"1_create_users_table.sql": {Data: []byte("CREATE TABLE users (id uuid);")}},
+			{"1_create_users_table.sql": {Data: []byte(usersMigration)}, "1_create_posts_table.sql": {Data: []byte(usersMigration)}},
+		}
+		for _, fsys := range invalid {
+			if _, err := Load(fsys); err == nil {
+				t.Errorf("Load should return an error for %v", fsys)
+			}
+		}
+	})
+}
+
+func TestPending(t *testing.T) {
+	t.Run("Should return the migrations not applied yet", func(t *testing.T) {
+		migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+		got := pending(migrations, map[int64]time.Time{1: time.Now(), 3: time.Now()})
+		if len(got) != 1 || got[0].Version != 2 {
+			t.Errorf("pending should return the missing versions. Got: %+v", got)
+		}
+		if got = pending(migrations[:1], map[int64]time.Time{1: time.Now()}); len(got) != 0 {
+			t.Errorf("pending should return nothing when up to date. Got: %+v", got)
+		}
+	})
+}
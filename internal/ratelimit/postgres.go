@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// refilled is the SQL expression of the tokens of the existing bucket r, refilled up to now. $2 is the limit of the
+// policy and $3 its rate.
+const refilled = `LEAST($2::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $3::float8)`
+
+// PostgresStore keeps the buckets on the rate_limits table, so every replica shares them. The clock of the database
+// is used, so replicas don't need synchronized clocks.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take takes the token in a single statement, which updates the bucket only when it has a token to take.
+func (s *PostgresStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	takeStmt := `INSERT INTO rate_limits AS r (key, tokens, updated_at, full_at)
+		VALUES ($1, $2::float8 - 1, now(), now() + make_interval(secs => 1 / $3::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = ` + refilled + ` - 1,
+			updated_at = now(),
+			full_at = now() + make_interval(secs => ($2::float8 - ` + refilled + ` + 1) / $3::float8)
+		WHERE ` + refilled + ` >= 1
+		RETURNING tokens`
+	var tokens float64
+	err := s.db.QueryRowContext(ctx, takeStmt, key, policy.Limit, policy.rate()).Scan(&tokens)
+	if err == nil {
+		return policy.result(tokens, true), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, err
+	}
+
+	selectStmt := `SELECT ` + refilled + ` FROM rate_limits r WHERE key = $1`
+	if err = s.db.QueryRowContext(ctx, selectStmt, key, policy.Limit, policy.rate()).Scan(&tokens); err != nil {
+		return Result{}, err
+	}
+
+	return policy.result(tokens, false), nil
+}
+
+// Purge deletes the buckets that filled up again, which are the same as missing ones, and returns how many.
+func (s *PostgresStore) Purge(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE full_at <= now()")
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy is a token bucket: up to Limit requests in a burst, refilled at Limit requests per Period. Buckets are
+// shared by every route with the same policy name.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// Unlimited is the policy of routes that are never limited.
+var Unlimited = Policy{Name: "unlimited"}
+
+// Result is the state of a bucket after taking a token from it.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, when the request wasn't allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Implementations must be safe for concurrent use.
+type Store interface {
+	// Take removes a token from the bucket of key, if it has any.
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// rate is how many tokens the bucket earns per second.
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// result describes a bucket left with tokens, allowed tells whether a token was taken.
+func (p Policy) result(tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     p.duration(float64(p.Limit) - tokens),
+	}
+	if !allowed {
+		result.RetryAfter = p.duration(1 - tokens)
+	}
+
+	return result
+}
+
+// duration returns how long the bucket takes to earn tokens.
+func (p Policy) duration(tokens float64) time.Duration {
+	return time.Duration(math.Max(tokens, 0) / p.rate() * float64(time.Second))
+}
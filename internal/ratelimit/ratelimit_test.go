@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 3, Period: 3 * time.Second}
+	newStore := func() (*MemoryStore, *time.Time) {
+		now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		store := NewMemoryStore()
+		store.now = func() time.Time { return now }
+		return store, &now
+	}
+
+	t.Run("Should allow a burst up to the limit and deny the next request", func(t *testing.T) {
+		store, _ := newStore()
+		for i := range policy.Limit {
+			result, err := store.Take(t.Context(), "key", policy)
+			if err != nil || !result.Allowed || result.Remaining != policy.Limit-i-1 {
+				t.Fatalf("Take %d should be allowed with %d remaining. Got: %+v, %v", i, policy.Limit-i-1, result, err)
+			}
+		}
+
+		result, _ := store.Take(t.Context(), "key", policy)
+		if result.Allowed || result.Remaining != 0 || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+			t.Errorf("Take should be denied once the bucket is empty. Got: %+v", result)
+		}
+	})
+
+	t.Run("Should refill the bucket over time", func(t *testing.T) {
+		store, now := newStore()
+		for range policy.Limit + 1 {
+			store.Take(t.Context(), "key", policy)
+		}
+
+		*now = now.Add(time.Second)
+		if result, _ := store.Take(t.Context(), "key", policy); !result.Allowed || result.Remaining != 0 {
+			t.Errorf("Take should be allowed after a token was earned. Got: %+v", result)
+		}
+	})
+
+	t.Run("Should keep keys apart", func(t *testing.T) {
+		store, _ := newStore()
+		for range policy.Limit {
+			store.Take(t.Context(), "a", policy)
+		}
+
+		if result, _ := store.Take(t.Context(), "b", policy); !result.Allowed {
+			t.Errorf("Take should not share buckets between keys. Got: %+v", result)
+		}
+	})
+
+	t.Run("Should drop the buckets that filled up again", func(t *testing.T) {
+		store, now := newStore()
+		store.Take(t.Context(), "a", policy)
+		*now = now.Add(sweepInterval)
+		store.Take(t.Context(), "b", policy)
+
+		if _, ok := store.buckets["a"]; ok || len(store.buckets) != 1 {
+			t.Errorf("Take should sweep full buckets. Got: %d buckets", len(store.buckets))
+		}
+	})
+}
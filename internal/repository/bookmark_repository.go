@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/lib/pq"
+)
+
+type Bookmark interface {
+	Add(ctx context.Context, userId string, postId uint64) error
+	Remove(ctx context.Context, userId string, postId uint64) error
+	Fetch(ctx context.Context, userId string, limit, offset int) ([]entity.Post, error)
+	FetchBookmarked(ctx context.Context, userId string, postIds []uint64) ([]uint64, error)
+	CreateCollection(ctx context.Context, collection entity.Collection) (uint64, error)
+	FetchCollections(ctx context.Context, userId string) ([]entity.Collection, error)
+	FetchCollection(ctx context.Context, collectionId uint64) (entity.Collection, error)
+	DeleteCollection(ctx context.Context, collectionId uint64) error
+	AddToCollection(ctx context.Context, collectionId, postId uint64) error
+	RemoveFromCollection(ctx context.Context, collectionId, postId uint64) error
+	FetchCollectionPosts(ctx context.Context, collectionId uint64, viewerId string, limit, offset int) ([]entity.Post, error)
+}
+
+type BookmarkRepository struct {
+	db *sql.DB
+}
+
+func NewBookmarkRepository(db *sql.DB) *BookmarkRepository {
+	return &BookmarkRepository{db}
+}
+
+func (r BookmarkRepository) Add(ctx context.Context, userId string, postId uint64) error {
+	insertStmt := "INSERT INTO bookmarks (user_id, post_id) VALUES ($1, $2) ON CONFLICT (user_id, post_id) DO NOTHING"
+	_, err := r.db.ExecContext(ctx, insertStmt, userId, postId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r BookmarkRepository) Remove(ctx context.Context, userId string, postId uint64) error {
+	deleteStmt := "DELETE FROM bookmarks WHERE user_id=$1 AND post_id=$2"
+	_, err := r.db.ExecContext(ctx, deleteStmt, userId, postId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Fetch returns the posts bookmarked by the user that they can still see, most recently bookmarked first.
+func (r BookmarkRepository) Fetch(ctx context.Context, userId string, limit, offset int) ([]entity.Post, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+postColumns+` FROM bookmarks b
+		JOIN posts p ON p.id = b.post_id
+		JOIN users u ON u.id = p.author
+		WHERE b.user_id = $1 AND p.status = 'published' AND `+visibleTo("$1")+`
+		ORDER BY b.created_at desc, p.id desc
+		LIMIT $2 OFFSET $3`,
+		userId,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FetchBookmarked returns which of the given posts the user bookmarked.
+func (r BookmarkRepository) FetchBookmarked(ctx context.Context, userId string, postIds []uint64) ([]uint64, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT post_id FROM bookmarks WHERE user_id = $1 AND post_id = ANY($2)",
+		userId,
+		pq.Array(postIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookmarked []uint64
+
+	for rows.Next() {
+		var postId uint64
+		if err = rows.Scan(&postId); err != nil {
+			return nil, err
+		}
+
+		bookmarked = append(bookmarked, postId)
+	}
+
+	return bookmarked, rows.Err()
+}
+
+func (r BookmarkRepository) CreateCollection(ctx context.Context, collection entity.Collection) (uint64, error) {
+	var collectionId uint64
+	insertStmt := "INSERT INTO collections (owner, name) VALUES ($1, $2) RETURNING id"
+	err := r.db.QueryRowContext(ctx, insertStmt, collection.OwnerId, collection.Name).Scan(&collectionId)
+	if err != nil {
+		return 0, err
+	}
+
+	return collectionId, nil
+}
+
+func (r BookmarkRepository) FetchCollections(ctx context.Context, userId string) ([]entity.Collection, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT c.id, c.owner, c.name, (SELECT count(*) FROM collection_posts cp WHERE cp.collection_id = c.id),
+			c.created_at
+		FROM collections c
+		WHERE c.owner = $1
+		ORDER BY c.name`,
+		userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collections []entity.Collection
+
+	for rows.Next() {
+		var collection entity.Collection
+		err = rows.Scan(&collection.Id, &collection.OwnerId, &collection.Name, &collection.Posts, &collection.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		collections = append(collections, collection)
+	}
+
+	return collections, rows.Err()
+}
+
+func (r BookmarkRepository) FetchCollection(ctx context.Context, collectionId uint64) (entity.Collection, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT c.id, c.owner, c.name, (SELECT count(*) FROM collection_posts cp WHERE cp.collection_id = c.id),
+			c.created_at
+		FROM collections c
+		WHERE c.id = $1`,
+		collectionId,
+	)
+	if err != nil {
+		return entity.Collection{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return entity.Collection{}, ErrNotFound
+	}
+	var collection entity.Collection
+	err = rows.Scan(&collection.Id, &collection.OwnerId, &collection.Name, &collection.Posts, &collection.CreatedAt)
+	if err != nil {
+		return entity.Collection{}, err
+	}
+
+	return collection, nil
+}
+
+func (r BookmarkRepository) DeleteCollection(ctx context.Context, collectionId uint64) error {
+	deleteStmt := "DELETE FROM collections WHERE id=$1"
+	_, err := r.db.ExecContext(ctx, deleteStmt, collectionId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r BookmarkRepository) AddToCollection(ctx context.Context, collectionId, postId uint64) error {
+	insertStmt := `INSERT INTO collection_posts (collection_id, post_id) VALUES ($1, $2)
+		ON CONFLICT (collection_id, post_id) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, insertStmt, collectionId, postId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r BookmarkRepository) RemoveFromCollection(ctx context.Context, collectionId, postId uint64) error {
+	deleteStmt := "DELETE FROM collection_posts WHERE collection_id=$1 AND post_id=$2"
+	_, err := r.db.ExecContext(ctx, deleteStmt, collectionId, postId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r BookmarkRepository) FetchCollectionPosts(
+	ctx context.Context,
+	collectionId uint64,
+	viewerId string,
+	limit, offset int,
+) ([]entity.Post, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+postColumns+` FROM collection_posts cp
+		JOIN posts p ON p.id = cp.post_id
+		JOIN users u ON u.id = p.author
+		WHERE cp.collection_id = $1 AND p.status = 'published' AND `+visibleTo("$2")+`
+		ORDER BY cp.created_at desc, p.id desc
+		LIMIT $3 OFFSET $4`,
+		collectionId,
+		viewerId,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
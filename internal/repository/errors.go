@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/lib/pq"
+	"strings"
+)
+
+const (
+	// uniqueViolation is the SQLSTATE of unique constraint violations.
+	uniqueViolation = "23505"
+	// foreignKeyViolation is the SQLSTATE of rows referencing a missing one. Every foreign key cascades on delete, so
+	// it's only raised by inserts and updates referencing a row that doesn't exist.
+	foreignKeyViolation = "23503"
+)
+
+// ErrNotFound is returned when the row looked up, updated or deleted doesn't exist.
+var ErrNotFound = errorType.NewCodedError("not_found", "not found")
+
+// ErrConflict is returned when a row would have the same unique Field as another one, like the nick of a user.
+type ErrConflict struct {
+	Field string
+}
+
+func (ec *ErrConflict) Error() string {
+	if ec.Field == "" {
+		return "already exists"
+	}
+
+	return ec.Field + " is already taken"
+}
+
+func (ec *ErrConflict) ErrorCode() string {
+	return "conflict"
+}
+
+func (ec *ErrConflict) FieldErrors() []errorType.FieldError {
+	return []errorType.FieldError{errorType.NewFieldError(ec.Field, errorType.CodeTaken, ec.Error())}
+}
+
+// translate turns missing rows, or references to them, into ErrNotFound and unique violations into ErrConflict,
+// leaving other errors as they are.
+func translate(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return &ErrConflict{Field: conflictField(pqErr)}
+		case foreignKeyViolation:
+			return ErrNotFound
+		}
+	}
+
+	return err
+}
+
+// conflictField returns the column of a unique constraint named as Postgres names them, <table>_<column>_key.
+// Primary keys, named <table>_pkey, have none.
+func conflictField(pqErr *pq.Error) string {
+	field, ok := strings.CutSuffix(strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_"), "_key")
+	if !ok {
+		return ""
+	}
+
+	return field
+}
+
+// affected returns ErrNotFound when the statement changed no row.
+func affected(result sql.Result, err error) error {
+	if err != nil {
+		return translate(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	t.Run("Should turn missing rows into ErrNotFound", func(t *testing.T) {
+		if err := translate(fmt.Errorf("scan: %w", sql.ErrNoRows)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("translate should return ErrNotFound. Got: %v", err)
+		}
+	})
+
+	t.Run("Should turn unique violations into ErrConflict on the column", func(t *testing.T) {
+		scenarios := map[string]string{"users_nick_key": "nick", "users_email_key": "email", "users_pkey": ""}
+		for constraint, field := range scenarios {
+			err := translate(&pq.Error{Code: uniqueViolation, Table: "users", Constraint: constraint})
+			var conflict *ErrConflict
+			if !errors.As(err, &conflict) || conflict.Field != field {
+				t.Errorf("translate should return a conflict on %q for %s. Got: %v", field, constraint, err)
+			}
+		}
+	})
+
+	t.Run("Should turn references to missing rows into ErrNotFound", func(t *testing.T) {
+		err := translate(&pq.Error{Code: foreignKeyViolation, Table: "blocks", Constraint: "blocks_blocked_fkey"})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("translate should return ErrNotFound. Got: %v", err)
+		}
+	})
+
+	t.Run("Should keep other errors", func(t *testing.T) {
+		other := &pq.Error{Code: "23502", Table: "posts"}
+		if err := translate(other); err != other {
+			t.Errorf("translate should keep other errors. Got: %v", err)
+		}
+	})
+}
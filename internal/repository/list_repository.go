@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/edigar/socialnets-api/internal/entity"
+)
+
+type List interface {
+	Create(ctx context.Context, list entity.List) (uint64, error)
+	FetchById(ctx context.Context, listId uint64) (entity.List, error)
+	FetchByOwner(ctx context.Context, ownerId string, includePrivate bool) ([]entity.List, error)
+	Update(ctx context.Context, listId uint64, list entity.List) error
+	Delete(ctx context.Context, listId uint64) error
+	AddMember(ctx context.Context, listId uint64, userId string) (bool, error)
+	RemoveMember(ctx context.Context, listId uint64, userId string) error
+	FetchMembers(ctx context.Context, listId uint64) ([]entity.User, error)
+	FetchTimeline(ctx context.Context, listId uint64, viewerId string, limit, offset int) ([]entity.Post, error)
+}
+
+// listColumns must be kept in sync with scanList.
+const listColumns = `l.id, l.owner, l.name, l.private,
+	(SELECT count(*) FROM list_members lm WHERE lm.list_id = l.id), l.created_at`
+
+type ListRepository struct {
+	db *sql.DB
+}
+
+func NewListRepository(db *sql.DB) *ListRepository {
+	return &ListRepository{db}
+}
+
+func (r ListRepository) Create(ctx context.Context, list entity.List) (uint64, error) {
+	var listId uint64
+	insertStmt := "INSERT INTO lists (owner, name, private) VALUES ($1, $2, $3) RETURNING id"
+	err := r.db.QueryRowContext(ctx, insertStmt, list.OwnerId, list.Name, list.Private).Scan(&listId)
+	if err != nil {
+		return 0, err
+	}
+
+	return listId, nil
+}
+
+func (r ListRepository) FetchById(ctx context.Context, listId uint64) (entity.List, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+listColumns+" FROM lists l WHERE l.id = $1", listId)
+	if err != nil {
+		return entity.List{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return entity.List{}, ErrNotFound
+	}
+
+	return scanList(rows)
+}
+
+func (r ListRepository) FetchByOwner(ctx context.Context, ownerId string, includePrivate bool) ([]entity.List, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+listColumns+" FROM lists l WHERE l.owner = $1 AND ($2 OR NOT l.private) ORDER BY l.name",
+		ownerId,
+		includePrivate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []entity.List
+
+	for rows.Next() {
+		list, err := scanList(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+func (r ListRepository) Update(ctx context.Context, listId uint64, list entity.List) error {
+	updateStmt := "UPDATE lists SET name=$1, private=$2 WHERE id=$3"
+	_, err := r.db.ExecContext(ctx, updateStmt, list.Name, list.Private, listId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r ListRepository) Delete(ctx context.Context, listId uint64) error {
+	deleteStmt := "DELETE FROM lists WHERE id=$1"
+	_, err := r.db.ExecContext(ctx, deleteStmt, listId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddMember adds the user to the list and reports whether the user exists.
+func (r ListRepository) AddMember(ctx context.Context, listId uint64, userId string) (bool, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO list_members (list_id, user_id) SELECT $1, id FROM users WHERE id = $2
+		ON CONFLICT (list_id, user_id) DO UPDATE SET list_id = excluded.list_id`,
+		listId,
+		userId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r ListRepository) RemoveMember(ctx context.Context, listId uint64, userId string) error {
+	deleteStmt := "DELETE FROM list_members WHERE list_id=$1 AND user_id=$2"
+	_, err := r.db.ExecContext(ctx, deleteStmt, listId, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r ListRepository) FetchMembers(ctx context.Context, listId uint64) ([]entity.User, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT u.id, u.name, u.nick, u.created_at
+		FROM users u INNER JOIN list_members lm ON lm.user_id = u.id
+		WHERE lm.list_id = $1
+		ORDER BY u.nick`,
+		listId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []entity.User
+
+	for rows.Next() {
+		var user entity.User
+		if err = rows.Scan(&user.Id, &user.Name, &user.Nick, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// FetchTimeline returns the posts of the list members with the same rules as the home feed.
+func (r ListRepository) FetchTimeline(ctx context.Context, listId uint64, viewerId string, limit, offset int) ([]entity.Post, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+postColumns+` FROM posts p
+		JOIN users u ON u.id = p.author
+		JOIN list_members lm ON lm.user_id = p.author AND lm.list_id = $1
+		WHERE `+feedFilter("$2")+`
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE (b.user_id = $2 AND b.blocked = p.author) OR (b.user_id = p.author AND b.blocked = $2)
+			)
+		ORDER BY p.published_at desc, p.id desc
+		LIMIT $3 OFFSET $4`,
+		listId,
+		viewerId,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+func scanList(rows *sql.Rows) (entity.List, error) {
+	var list entity.List
+	err := rows.Scan(&list.Id, &list.OwnerId, &list.Name, &list.Private, &list.Members, &list.CreatedAt)
+
+	return list, err
+}
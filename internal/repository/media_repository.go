@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/lib/pq"
+	"slices"
+	"time"
+)
+
+type Media interface {
+	Create(ctx context.Context, attachment entity.Attachment) (uint64, error)
+	CountAvailable(ctx context.Context, ownerId string, ids []uint64) (int, error)
+	FetchByPosts(ctx context.Context, postIds []uint64) ([]entity.Attachment, error)
+	FetchByStories(ctx context.Context, storyIds []uint64) ([]entity.Attachment, error)
+	Delete(ctx context.Context, id uint64) error
+	FetchUnattached(ctx context.Context, before time.Time, afterId uint64, limit int) ([]entity.Attachment, error)
+	DeleteUnattached(ctx context.Context, id uint64, remove func() error) error
+}
+
+type MediaRepository struct {
+	db *sql.DB
+}
+
+func NewMediaRepository(db *sql.DB) *MediaRepository {
+	return &MediaRepository{db}
+}
+
+func (r MediaRepository) Create(ctx context.Context, attachment entity.Attachment) (uint64, error) {
+	var attachmentId uint64
+	insertStmt := `INSERT INTO attachments (owner, storage_key, thumbnail_key, content_type, size, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	err := r.db.QueryRowContext(
+		ctx,
+		insertStmt,
+		attachment.OwnerId,
+		attachment.Key,
+		attachment.ThumbnailKey,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.Width,
+		attachment.Height,
+	).Scan(&attachmentId)
+	if err != nil {
+		return 0, err
+	}
+
+	return attachmentId, nil
+}
+
+func (r MediaRepository) CountAvailable(ctx context.Context, ownerId string, ids []uint64) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT count(*) FROM attachments WHERE owner = $1 AND id = ANY($2) AND post_id IS NULL AND story_id IS NULL",
+		ownerId,
+		pq.Array(ids),
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// attach links the attachments of ids to the post or story, per column, created by ownerId on tx. It fails with
+// ErrConflict, rolling the creation back, unless every one of them is still an unused attachment of ownerId, as a
+// concurrent request may have taken one since they were checked.
+func attach(ctx context.Context, tx *sql.Tx, column string, id uint64, ownerId string, ids []uint64) error {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	if len(ids) == 0 {
+		return nil
+	}
+
+	updateStmt := "UPDATE attachments SET " + column + `=$1
+		WHERE owner=$2 AND id = ANY($3) AND post_id IS NULL AND story_id IS NULL`
+	result, err := tx.ExecContext(ctx, updateStmt, id, ownerId, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != int64(len(ids)) {
+		return &ErrConflict{Field: "attachmentIds"}
+	}
+
+	return nil
+}
+
+func (r MediaRepository) FetchByPosts(ctx context.Context, postIds []uint64) ([]entity.Attachment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, post_id, story_id, owner, storage_key, thumbnail_key, content_type, size, width, height, created_at
+		FROM attachments WHERE post_id = ANY($1) ORDER BY id`,
+		pq.Array(postIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+func (r MediaRepository) FetchByStories(ctx context.Context, storyIds []uint64) ([]entity.Attachment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, post_id, story_id, owner, storage_key, thumbnail_key, content_type, size, width, height, created_at
+		FROM attachments WHERE story_id = ANY($1) ORDER BY id`,
+		pq.Array(storyIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+func (r MediaRepository) Delete(ctx context.Context, id uint64) error {
+	deleteStmt := "DELETE FROM attachments WHERE id=$1"
+	_, err := r.db.ExecContext(ctx, deleteStmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FetchUnattached returns the attachments uploaded before the given time that aren't attached to any post or story, in
+// id order after afterId.
+func (r MediaRepository) FetchUnattached(ctx context.Context, before time.Time, afterId uint64, limit int) ([]entity.Attachment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, post_id, story_id, owner, storage_key, thumbnail_key, content_type, size, width, height, created_at
+		FROM attachments WHERE post_id IS NULL AND story_id IS NULL AND created_at < $1 AND id > $2
+		ORDER BY id LIMIT $3`,
+		before,
+		afterId,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+// DeleteUnattached deletes the attachment unless it got attached meanwhile, in which case it returns ErrNotFound.
+// remove runs while the row is locked, so attach waits for it, and the row is kept when remove fails.
+func (r MediaRepository) DeleteUnattached(ctx context.Context, id uint64, remove func() error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteStmt := "DELETE FROM attachments WHERE id=$1 AND post_id IS NULL AND story_id IS NULL"
+	if err = affected(tx.ExecContext(ctx, deleteStmt, id)); err != nil {
+		return err
+	}
+	if err = remove(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanAttachments(rows *sql.Rows) ([]entity.Attachment, error) {
+	var attachments []entity.Attachment
+
+	for rows.Next() {
+		var attachment entity.Attachment
+		if err := rows.Scan(
+			&attachment.Id,
+			&attachment.PostId,
+			&attachment.StoryId,
+			&attachment.OwnerId,
+			&attachment.Key,
+			&attachment.ThumbnailKey,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.Width,
+			&attachment.Height,
+			&attachment.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/lib/pq"
+)
+
+type Poll interface {
+	FetchByPost(ctx context.Context, postId uint64) (entity.Poll, error)
+	FetchByPosts(ctx context.Context, postIds []uint64, viewerId string) ([]entity.Poll, error)
+	Vote(ctx context.Context, pollId uint64, userId string, optionIds []uint64) error
+}
+
+type PollRepository struct {
+	db *sql.DB
+}
+
+func NewPollRepository(db *sql.DB) *PollRepository {
+	return &PollRepository{db}
+}
+
+// Create saves the poll of a post within the transaction creating the post, so a post is never published without it.
+func (r PollRepository) Create(ctx context.Context, tx *sql.Tx, postId uint64, poll entity.Poll) (uint64, error) {
+	var pollId uint64
+	insertStmt := `INSERT INTO polls (post_id, multiple, hide_results, expires_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	err := tx.QueryRowContext(ctx, insertStmt, postId, poll.Multiple, poll.HideResults, poll.ExpiresAt).Scan(&pollId)
+	if err != nil {
+		return 0, err
+	}
+
+	for position, option := range poll.Options {
+		optionStmt := "INSERT INTO poll_options (poll_id, position, text) VALUES ($1, $2, $3)"
+		if _, err = tx.ExecContext(ctx, optionStmt, pollId, position, option.Text); err != nil {
+			return 0, err
+		}
+	}
+
+	return pollId, nil
+}
+
+// FetchByPost returns the poll of a post with its options, without tallies.
+func (r PollRepository) FetchByPost(ctx context.Context, postId uint64) (entity.Poll, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT pl.id, pl.post_id, pl.multiple, pl.hide_results, pl.expires_at, o.id, o.text
+		FROM polls pl
+		JOIN poll_options o ON o.poll_id = pl.id
+		WHERE pl.post_id = $1
+		ORDER BY o.position`,
+		postId,
+	)
+	if err != nil {
+		return entity.Poll{}, err
+	}
+	defer rows.Close()
+
+	var poll entity.Poll
+
+	for rows.Next() {
+		var option entity.PollOption
+		err = rows.Scan(&poll.Id, &poll.PostId, &poll.Multiple, &poll.HideResults, &poll.ExpiresAt, &option.Id, &option.Text)
+		if err != nil {
+			return entity.Poll{}, err
+		}
+
+		poll.Options = append(poll.Options, option)
+	}
+	if err = rows.Err(); err != nil {
+		return entity.Poll{}, err
+	}
+	if poll.Id == 0 {
+		return entity.Poll{}, ErrNotFound
+	}
+
+	return poll, nil
+}
+
+// FetchByPosts returns the polls of the given posts with their tallies and the options chosen by the viewer.
+func (r PollRepository) FetchByPosts(ctx context.Context, postIds []uint64, viewerId string) ([]entity.Poll, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT pl.id, pl.post_id, pl.multiple, pl.hide_results, pl.expires_at,
+			(SELECT count(DISTINCT v.user_id) FROM poll_votes v WHERE v.poll_id = pl.id),
+			EXISTS (SELECT 1 FROM poll_votes v WHERE v.poll_id = pl.id AND v.user_id = $2)
+		FROM polls pl
+		WHERE pl.post_id = ANY($1)`,
+		pq.Array(postIds),
+		viewerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var polls []entity.Poll
+	index := map[uint64]int{}
+
+	for rows.Next() {
+		var poll entity.Poll
+		var voters uint64
+		err = rows.Scan(&poll.Id, &poll.PostId, &poll.Multiple, &poll.HideResults, &poll.ExpiresAt, &voters, &poll.Voted)
+		if err != nil {
+			return nil, err
+		}
+
+		poll.Voters = &voters
+		index[poll.Id] = len(polls)
+		polls = append(polls, poll)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(polls) == 0 {
+		return nil, nil
+	}
+
+	pollIds := make([]uint64, len(polls))
+	for i, poll := range polls {
+		pollIds[i] = poll.Id
+	}
+
+	optionRows, err := r.db.QueryContext(
+		ctx,
+		`SELECT o.id, o.poll_id, o.text, count(v.user_id), coalesce(bool_or(v.user_id = $2), false)
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		WHERE o.poll_id = ANY($1)
+		GROUP BY o.id
+		ORDER BY o.poll_id, o.position`,
+		pq.Array(pollIds),
+		viewerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer optionRows.Close()
+
+	for optionRows.Next() {
+		var option entity.PollOption
+		var pollId, votes uint64
+		if err = optionRows.Scan(&option.Id, &pollId, &option.Text, &votes, &option.Chosen); err != nil {
+			return nil, err
+		}
+
+		option.Votes = &votes
+		i := index[pollId]
+		polls[i].Options = append(polls[i].Options, option)
+	}
+
+	return polls, optionRows.Err()
+}
+
+// Vote replaces the votes of the user on the poll with the given options.
+func (r PollRepository) Vote(ctx context.Context, pollId uint64, userId string, optionIds []uint64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM poll_votes WHERE poll_id = $1 AND user_id = $2", pollId, userId); err != nil {
+		return err
+	}
+
+	insertStmt := "INSERT INTO poll_votes (poll_id, option_id, user_id) SELECT $1, unnest($2::int[]), $3"
+	if _, err = tx.ExecContext(ctx, insertStmt, pollId, pq.Array(optionIds), userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
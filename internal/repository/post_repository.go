@@ -1,19 +1,46 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/lib/pq"
+	"time"
 )
 
 type Post interface {
-	Create(post entity.Post) (uint64, error)
-	FetchById(postId uint64) (entity.Post, error)
-	FetchByUser(userId string) ([]entity.Post, error)
-	Update(postId uint64, post entity.Post) error
-	Delete(postId uint64) error
-	FetchUserPosts(userId string) ([]entity.Post, error)
-	LikePost(postId uint64) error
-	UnlikePost(postId uint64) error
+	Create(ctx context.Context, post entity.Post) (uint64, error)
+	FetchById(ctx context.Context, postId uint64, viewerId string) (entity.Post, error)
+	FetchByUser(ctx context.Context, userId string, limit, offset int) ([]entity.Post, error)
+	FetchFeedCandidates(ctx context.Context, userId string, since time.Time, limit int) ([]entity.FeedCandidate, error)
+	Update(ctx context.Context, postId uint64, post entity.Post) error
+	Delete(ctx context.Context, postId uint64) error
+	FetchUserPosts(ctx context.Context, userId, viewerId string) ([]entity.Post, error)
+	FetchDrafts(ctx context.Context, authorId string) ([]entity.Post, error)
+	PublishDue(ctx context.Context, limit int) ([]uint64, error)
+	FetchRevisions(ctx context.Context, postId uint64) ([]entity.Revision, error)
+	Pin(ctx context.Context, postId uint64, authorId string, max int) (bool, error)
+	Unpin(ctx context.Context, postId uint64) error
+	SetSensitive(ctx context.Context, postId uint64, sensitive bool, contentWarning string, byModerator bool) error
+	LikePost(ctx context.Context, postId uint64) error
+	UnlikePost(ctx context.Context, postId uint64) error
+	RebuildTimelines(ctx context.Context, userId string) error
+}
+
+// postColumns must be kept in sync with scanPost.
+const postColumns = `p.id, p.title, p.content, p.author, u.nick, p.likes, p.status, p.publish_at, p.published_at,
+	p.edited_at, p.visibility, p.pinned_at IS NOT NULL, p.content_warning, p.sensitive, p.sensitive_by_moderator,
+	ARRAY(SELECT mu.nick FROM post_mentions pm JOIN users mu ON mu.id = pm.user_id WHERE pm.post_id = p.id ORDER BY mu.nick),
+	p.created_at`
+
+// visibleTo filters posts of table alias p the viewer of the given placeholder may see: their own posts, public
+// posts, followers posts of authors they follow and posts they are mentioned on.
+func visibleTo(viewer string) string {
+	return `(p.author = ` + viewer + ` OR p.visibility = 'public'
+		OR (p.visibility = 'followers' AND EXISTS (
+			SELECT 1 FROM followers vf WHERE vf.user_id = p.author AND vf.follower = ` + viewer + ` AND vf.accepted
+		))
+		OR EXISTS (SELECT 1 FROM post_mentions vm WHERE vm.post_id = p.id AND vm.user_id = ` + viewer + `))`
 }
 
 type PostRepository struct {
@@ -24,135 +51,386 @@ func NewPostRepository(db *sql.DB) *PostRepository {
 	return &PostRepository{db}
 }
 
-func (r PostRepository) Create(post entity.Post) (uint64, error) {
+func (r PostRepository) Create(ctx context.Context, post entity.Post) (uint64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	var postId uint64
-	insertStmt := `INSERT INTO posts (title, content, author) VALUES ($1, $2, $3) RETURNING id`
-	err := r.db.QueryRow(insertStmt, post.Title, post.Content, post.AuthorId).Scan(&postId)
+	insertStmt := `INSERT INTO posts (title, content, author, status, publish_at, published_at, visibility, content_warning,
+		sensitive)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $4 = 'published' THEN now() END, $6, $7, $8) RETURNING id`
+	err = tx.QueryRowContext(
+		ctx,
+		insertStmt,
+		post.Title,
+		post.Content,
+		post.AuthorId,
+		post.Status,
+		post.PublishAt,
+		post.Visibility,
+		post.ContentWarning,
+		post.Sensitive,
+	).Scan(&postId)
 	if err != nil {
 		return 0, err
 	}
+	if err = saveMentions(ctx, tx, postId, post.Mentions); err != nil {
+		return 0, err
+	}
+	if err = attach(ctx, tx, "post_id", postId, post.AuthorId, post.AttachmentIds); err != nil {
+		return 0, err
+	}
+	if post.Poll != nil {
+		if _, err = (PollRepository{r.db}).Create(ctx, tx, postId, *post.Poll); err != nil {
+			return 0, err
+		}
+	}
+	if err = fanOut(ctx, tx, []uint64{postId}); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
 
 	return postId, nil
 }
 
-func (r PostRepository) FetchById(postId uint64) (entity.Post, error) {
-	row, err := r.db.Query(
-		"SELECT p.*, u.nick FROM posts p INNER JOIN users u ON u.id = p.author WHERE p.id = $1",
+func (r PostRepository) FetchById(ctx context.Context, postId uint64, viewerId string) (entity.Post, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+postColumns+" FROM posts p INNER JOIN users u ON u.id = p.author WHERE p.id = $1 AND "+visibleTo("$2"),
 		postId,
+		viewerId,
 	)
 	if err != nil {
 		return entity.Post{}, err
 	}
-	defer row.Close()
+	defer rows.Close()
 
-	var post entity.Post
-	if row.Next() {
-		if err := row.Scan(
-			&post.Id,
-			&post.Title,
-			&post.Content,
-			&post.AuthorId,
-			&post.Likes,
-			&post.CreatedAt,
-			&post.AuthorNick,
-		); err != nil {
-			return entity.Post{}, err
-		}
+	if !rows.Next() {
+		return entity.Post{}, ErrNotFound
 	}
 
-	return post, nil
+	return scanPost(rows)
 }
 
-func (r PostRepository) FetchByUser(userId string) ([]entity.Post, error) {
-	rows, err := r.db.Query(
-		`SELECT DISTINCT p.*, u.nick FROM posts p
-		LEFT JOIN users u ON u.id = p.author
-		LEFT JOIN followers f ON p.author = f.user_id WHERE u.id = $1 OR f.follower = $1
-		ORDER BY 1 desc`,
+// FetchByUser returns the home feed of the user: a page of their materialized timeline, merged with the posts of the
+// followed accounts that are too large to be fanned out. Each side is read in feed order up to the end of the page, so
+// neither scans more than limit+offset posts.
+func (r PostRepository) FetchByUser(ctx context.Context, userId string, limit, offset int) ([]entity.Post, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`WITH feed AS (
+			(SELECT t.post_id, t.published_at FROM timelines t
+			JOIN posts p ON p.id = t.post_id
+			WHERE t.user_id = $1 AND `+feedFilter("$1")+`
+			ORDER BY t.published_at desc, t.post_id desc
+			LIMIT $2 + $3)
+			UNION
+			(SELECT p.id, p.published_at FROM followers f
+			JOIN users a ON a.id = f.user_id AND NOT a.fan_out
+			JOIN posts p ON p.author = f.user_id
+			WHERE f.follower = $1 AND f.accepted AND `+feedFilter("$1")+`
+			ORDER BY p.published_at desc, p.id desc
+			LIMIT $2 + $3)
+		)
+		SELECT `+postColumns+` FROM feed
+		JOIN posts p ON p.id = feed.post_id
+		JOIN users u ON u.id = p.author
+		ORDER BY feed.published_at desc, feed.post_id desc
+		LIMIT $2 OFFSET $3`,
 		userId,
+		limit,
+		offset,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var posts []entity.Post
+	return scanPosts(rows)
+}
+
+// FetchFeedCandidates returns the posts of the home feed of the user published since the given time, newest first,
+// with their bookmarks and how much the user interacted with each author over the last 30 days.
+func (r PostRepository) FetchFeedCandidates(ctx context.Context, userId string, since time.Time, limit int) ([]entity.FeedCandidate, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+postColumns+`,
+			(SELECT count(*) FROM bookmarks b WHERE b.post_id = p.id),
+			(SELECT count(*) FROM bookmarks b JOIN posts bp ON bp.id = b.post_id
+				WHERE b.user_id = $1 AND bp.author = p.author AND b.created_at > now() - interval '30 days')
+			+ (SELECT count(*) FROM poll_votes v JOIN polls vp ON vp.id = v.poll_id JOIN posts pp ON pp.id = vp.post_id
+				WHERE v.user_id = $1 AND pp.author = p.author AND v.created_at > now() - interval '30 days')
+			+ (SELECT count(*) FROM post_mentions m JOIN posts mp ON mp.id = m.post_id
+				WHERE mp.author = $1 AND m.user_id = p.author AND mp.published_at > now() - interval '30 days')
+		FROM posts p
+		JOIN users u ON u.id = p.author
+		WHERE (
+			p.id IN (SELECT t.post_id FROM timelines t WHERE t.user_id = $1 AND t.published_at >= $2)
+			OR p.author IN (
+				SELECT f.user_id FROM followers f
+				JOIN users a ON a.id = f.user_id AND NOT a.fan_out
+				WHERE f.follower = $1 AND f.accepted
+			)
+		) AND p.published_at >= $2 AND `+feedFilter("$1")+`
+		ORDER BY p.published_at desc, p.id desc
+		LIMIT $3`,
+		userId,
+		since,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	var candidates []entity.FeedCandidate
 	for rows.Next() {
-		var post entity.Post
-		if err = rows.Scan(
-			&post.Id,
-			&post.Title,
-			&post.Content,
-			&post.AuthorId,
-			&post.Likes,
-			&post.CreatedAt,
-			&post.AuthorNick,
-		); err != nil {
+		var candidate entity.FeedCandidate
+		candidate.Post, err = scanPost(rows, &candidate.Bookmarks, &candidate.Interactions)
+		if err != nil {
 			return nil, err
 		}
 
-		posts = append(posts, post)
+		candidates = append(candidates, candidate)
 	}
 
-	return posts, nil
+	return candidates, nil
 }
 
-func (r PostRepository) Update(postId uint64, post entity.Post) error {
-	updateStmt := "UPDATE posts SET title=$1, content=$2 WHERE id=$3"
-	_, err := r.db.Exec(updateStmt, post.Title, post.Content, postId)
+// Update saves the post. When a published post changes, its previous title and content are kept as a revision.
+func (r PostRepository) Update(ctx context.Context, postId uint64, post entity.Post) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	revisionStmt := `INSERT INTO post_revisions (post_id, title, content)
+		SELECT id, title, content FROM posts
+		WHERE id=$1 AND status = 'published' AND (title <> $2 OR content <> $3)
+		FOR UPDATE`
+	if _, err = tx.ExecContext(ctx, revisionStmt, postId, post.Title, post.Content); err != nil {
+		return err
+	}
+
+	updateStmt := `UPDATE posts SET title=$1, content=$2, status=$3, publish_at=$4, visibility=$5,
+		published_at = CASE WHEN $3 = 'published' THEN coalesce(published_at, now()) END,
+		edited_at = CASE WHEN status = 'published' AND (title <> $1 OR content <> $2) THEN now() ELSE edited_at END,
+		content_warning = CASE WHEN sensitive_by_moderator AND $6 = '' THEN content_warning ELSE $6 END,
+		sensitive = $7 OR sensitive_by_moderator
+		WHERE id=$8`
+	_, err = tx.ExecContext(
+		ctx,
+		updateStmt,
+		post.Title,
+		post.Content,
+		post.Status,
+		post.PublishAt,
+		post.Visibility,
+		post.ContentWarning,
+		post.Sensitive,
+		postId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM post_mentions WHERE post_id=$1", postId); err != nil {
+		return err
+	}
+	if err = saveMentions(ctx, tx, postId, post.Mentions); err != nil {
+		return err
+	}
+	if err = fanOut(ctx, tx, []uint64{postId}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r PostRepository) Delete(postId uint64) error {
-	deleteStmt := "DELETE FROM posts WHERE id=$1"
-	_, err := r.db.Exec(deleteStmt, postId)
+// Delete removes the post together with the bookmarks, collection entries and timeline entries pointing to it. It
+// returns ErrNotFound if there's no such post.
+func (r PostRepository) Delete(ctx context.Context, postId uint64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	if _, err = tx.ExecContext(ctx, "DELETE FROM bookmarks WHERE post_id=$1", postId); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM collection_posts WHERE post_id=$1", postId); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM timelines WHERE post_id=$1", postId); err != nil {
+		return err
+	}
+	if err = affected(tx.ExecContext(ctx, "DELETE FROM posts WHERE id=$1", postId)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r PostRepository) FetchUserPosts(userId string) ([]entity.Post, error) {
-	rows, err := r.db.Query(
-		"SELECT p.*, u.nick FROM posts p JOIN users u ON u.id = p.author WHERE p.author = $1",
+func (r PostRepository) FetchUserPosts(ctx context.Context, userId, viewerId string) ([]entity.Post, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+postColumns+` FROM posts p JOIN users u ON u.id = p.author
+		WHERE p.author = $1 AND p.status = 'published' AND `+visibleTo("$2")+`
+		ORDER BY p.pinned_at desc NULLS LAST, p.published_at desc, p.id desc`,
 		userId,
+		viewerId,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var posts []entity.Post
+	return scanPosts(rows)
+}
+
+func (r PostRepository) FetchDrafts(ctx context.Context, authorId string) ([]entity.Post, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+postColumns+` FROM posts p JOIN users u ON u.id = p.author
+		WHERE p.author = $1 AND p.status <> 'published'
+		ORDER BY p.publish_at asc NULLS LAST, p.id desc`,
+		authorId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// PublishDue publishes up to limit scheduled posts whose publish time has come, fans them out and returns their ids.
+// Rows are locked with SKIP LOCKED and re-checked on update, so concurrent replicas never publish the same post twice,
+// and the fan-out shares the transaction of the update, so it happens exactly once per post.
+func (r PostRepository) PublishDue(ctx context.Context, limit int) ([]uint64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`UPDATE posts SET status = 'published', published_at = publish_at
+		WHERE status = 'scheduled' AND id IN (
+			SELECT id FROM posts
+			WHERE status = 'scheduled' AND publish_at <= now()
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postIds []uint64
 
 	for rows.Next() {
-		var post entity.Post
-		if err = rows.Scan(
-			&post.Id,
-			&post.Title,
-			&post.Content,
-			&post.AuthorId,
-			&post.Likes,
-			&post.CreatedAt,
-			&post.AuthorNick,
-		); err != nil {
+		var postId uint64
+		if err = rows.Scan(&postId); err != nil {
 			return nil, err
 		}
 
-		posts = append(posts, post)
+		postIds = append(postIds, postId)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
+	rows.Close()
 
-	return posts, nil
+	if err = fanOut(ctx, tx, postIds); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return postIds, nil
 }
 
-func (r PostRepository) LikePost(postId uint64) error {
-	updateStmt := "UPDATE posts SET likes = likes + 1 WHERE id=$1"
-	_, err := r.db.Exec(updateStmt, postId)
+func (r PostRepository) FetchRevisions(ctx context.Context, postId uint64) ([]entity.Revision, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, post_id, title, content, replaced_at FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY replaced_at desc, id desc`,
+		postId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []entity.Revision
+
+	for rows.Next() {
+		var revision entity.Revision
+		err = rows.Scan(&revision.Id, &revision.PostId, &revision.Title, &revision.Content, &revision.ReplacedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// Pin pins the post unless its author already has max pinned posts, and reports whether the post is pinned. The row
+// of the author is locked while counting, so concurrent pins of the same author can't go over max together.
+func (r PostRepository) Pin(ctx context.Context, postId uint64, authorId string, max int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "SELECT 1 FROM users WHERE id = $1 FOR UPDATE", authorId); err != nil {
+		return false, err
+	}
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE posts SET pinned_at = coalesce(pinned_at, now())
+		WHERE id = $1 AND (
+			pinned_at IS NOT NULL
+			OR (SELECT count(*) FROM posts WHERE author = $2 AND pinned_at IS NOT NULL) < $3
+		)`,
+		postId,
+		authorId,
+		max,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, tx.Commit()
+}
+
+func (r PostRepository) Unpin(ctx context.Context, postId uint64) error {
+	updateStmt := "UPDATE posts SET pinned_at = NULL WHERE id=$1"
+	_, err := r.db.ExecContext(ctx, updateStmt, postId)
 	if err != nil {
 		return err
 	}
@@ -160,13 +438,95 @@ func (r PostRepository) LikePost(postId uint64) error {
 	return nil
 }
 
-func (r PostRepository) UnlikePost(postId uint64) error {
+// SetSensitive flags or unflags the post as sensitive. Posts flagged by a moderator stay sensitive until a moderator
+// unflags them.
+func (r PostRepository) SetSensitive(ctx context.Context, postId uint64, sensitive bool, contentWarning string, byModerator bool) error {
+	updateStmt := `UPDATE posts SET sensitive=$1, content_warning=$2,
+		sensitive_by_moderator = CASE WHEN $3 THEN $1 ELSE sensitive_by_moderator END
+		WHERE id=$4`
+	_, err := r.db.ExecContext(ctx, updateStmt, sensitive, contentWarning, byModerator, postId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostRepository) LikePost(ctx context.Context, postId uint64) error {
+	updateStmt := "UPDATE posts SET likes = likes + 1 WHERE id=$1"
+	return affected(r.db.ExecContext(ctx, updateStmt, postId))
+}
+
+func (r PostRepository) UnlikePost(ctx context.Context, postId uint64) error {
 	//updateStmt := "UPDATE posts SET likes = CASE WHEN likes > 0 THEN likes - 1 ELSE 0 END WHERE id=$1"
 	updateStmt := "UPDATE posts SET likes = likes - 1 WHERE id=$1 AND likes > 0"
-	_, err := r.db.Exec(updateStmt, postId)
+	_, err := r.db.ExecContext(ctx, updateStmt, postId)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// feedFilter keeps the posts of table alias p that belong on the timelines of the viewer of the given placeholder:
+// published, visible to them and not written by someone they muted.
+func feedFilter(viewer string) string {
+	return `p.status = 'published' AND ` + visibleTo(viewer) + `
+		AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = ` + viewer + ` AND m.muted = p.author)`
+}
+
+// saveMentions links the post to the users mentioned by nick. Unknown nicks are ignored.
+func saveMentions(ctx context.Context, tx *sql.Tx, postId uint64, nicks []string) error {
+	if len(nicks) == 0 {
+		return nil
+	}
+
+	insertStmt := `INSERT INTO post_mentions (post_id, user_id)
+		SELECT $1, id FROM users WHERE lower(nick) = ANY($2)
+		ON CONFLICT (post_id, user_id) DO NOTHING`
+	_, err := tx.ExecContext(ctx, insertStmt, postId, pq.Array(nicks))
+
+	return err
+}
+
+// scanPost reads a row of postColumns followed by the extra columns of the query, if any.
+func scanPost(rows *sql.Rows, extra ...any) (entity.Post, error) {
+	var post entity.Post
+	dest := []any{
+		&post.Id,
+		&post.Title,
+		&post.Content,
+		&post.AuthorId,
+		&post.AuthorNick,
+		&post.Likes,
+		&post.Status,
+		&post.PublishAt,
+		&post.PublishedAt,
+		&post.EditedAt,
+		&post.Visibility,
+		&post.Pinned,
+		&post.ContentWarning,
+		&post.Sensitive,
+		&post.SensitiveByModerator,
+		pq.Array(&post.Mentions),
+		&post.CreatedAt,
+	}
+	err := rows.Scan(append(dest, extra...)...)
+
+	return post, err
+}
+
+func scanPosts(rows *sql.Rows) ([]entity.Post, error) {
+	var posts []entity.Post
+
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
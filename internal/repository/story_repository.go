@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/lib/pq"
+)
+
+type Story interface {
+	Create(ctx context.Context, story entity.Story) (uint64, error)
+	FetchById(ctx context.Context, storyId uint64, viewerId string) (entity.Story, error)
+	FetchRail(ctx context.Context, viewerId string) ([]entity.Story, error)
+	View(ctx context.Context, storyId uint64, viewerId string) error
+	FetchViews(ctx context.Context, storyId uint64) ([]entity.StoryView, error)
+	FetchExpired(ctx context.Context, afterId uint64, limit int) ([]uint64, error)
+	Delete(ctx context.Context, storyIds []uint64) error
+}
+
+// storyColumns must be kept in sync with scanStory. $1 is the viewer: views are only counted for the author.
+const storyColumns = `s.id, s.author, u.nick, s.content,
+	EXISTS (SELECT 1 FROM story_views sv WHERE sv.story_id = s.id AND sv.viewer = $1),
+	CASE WHEN s.author = $1 THEN (SELECT count(*) FROM story_views sv WHERE sv.story_id = s.id) END,
+	s.expires_at, s.created_at`
+
+// storyVisibleTo keeps the active stories of table alias s the viewer of $1 may see: their own and the ones of the
+// accounts they follow, unless muted or blocked in either direction.
+const storyVisibleTo = `s.expires_at > now() AND (
+		s.author = $1 OR EXISTS (
+			SELECT 1 FROM followers f WHERE f.user_id = s.author AND f.follower = $1 AND f.accepted
+		)
+	)
+	AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = $1 AND m.muted = s.author)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks b
+		WHERE (b.user_id = $1 AND b.blocked = s.author) OR (b.user_id = s.author AND b.blocked = $1)
+	)`
+
+type StoryRepository struct {
+	db *sql.DB
+}
+
+func NewStoryRepository(db *sql.DB) *StoryRepository {
+	return &StoryRepository{db}
+}
+
+func (r StoryRepository) Create(ctx context.Context, story entity.Story) (uint64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var storyId uint64
+	insertStmt := "INSERT INTO stories (author, content, expires_at) VALUES ($1, $2, $3) RETURNING id"
+	err = tx.QueryRowContext(ctx, insertStmt, story.AuthorId, story.Content, story.ExpiresAt).Scan(&storyId)
+	if err != nil {
+		return 0, err
+	}
+	if err = attach(ctx, tx, "story_id", storyId, story.AuthorId, story.AttachmentIds); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return storyId, nil
+}
+
+// FetchById returns the story as seen by the viewer, or ErrNotFound if it expired or they can't see it.
+func (r StoryRepository) FetchById(ctx context.Context, storyId uint64, viewerId string) (entity.Story, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+storyColumns+` FROM stories s
+		JOIN users u ON u.id = s.author
+		WHERE s.id = $2 AND `+storyVisibleTo,
+		viewerId,
+		storyId,
+	)
+	if err != nil {
+		return entity.Story{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return entity.Story{}, ErrNotFound
+	}
+
+	return scanStory(rows)
+}
+
+// FetchRail returns the active stories the viewer can see, grouped by author: their own first, then the authors
+// with the most recent stories. Stories of an author are ordered oldest first.
+func (r StoryRepository) FetchRail(ctx context.Context, viewerId string) ([]entity.Story, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+storyColumns+` FROM stories s
+		JOIN users u ON u.id = s.author
+		WHERE `+storyVisibleTo+`
+		ORDER BY s.author = $1 desc, max(s.created_at) OVER (PARTITION BY s.author) desc, s.author, s.created_at, s.id`,
+		viewerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []entity.Story
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		stories = append(stories, story)
+	}
+
+	return stories, nil
+}
+
+func (r StoryRepository) View(ctx context.Context, storyId uint64, viewerId string) error {
+	insertStmt := "INSERT INTO story_views (story_id, viewer) VALUES ($1, $2) ON CONFLICT (story_id, viewer) DO NOTHING"
+	_, err := r.db.ExecContext(ctx, insertStmt, storyId, viewerId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FetchViews returns who viewed the story, most recent first.
+func (r StoryRepository) FetchViews(ctx context.Context, storyId uint64) ([]entity.StoryView, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT u.id, u.nick, sv.viewed_at FROM story_views sv
+		JOIN users u ON u.id = sv.viewer
+		WHERE sv.story_id = $1
+		ORDER BY sv.viewed_at desc`,
+		storyId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []entity.StoryView
+	for rows.Next() {
+		var view entity.StoryView
+		if err = rows.Scan(&view.ViewerId, &view.ViewerNick, &view.ViewedAt); err != nil {
+			return nil, err
+		}
+
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+// FetchExpired returns the ids of up to limit stories that already expired, in order, after the story of afterId.
+func (r StoryRepository) FetchExpired(ctx context.Context, afterId uint64, limit int) ([]uint64, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id FROM stories WHERE expires_at <= now() AND id > $1 ORDER BY id LIMIT $2",
+		afterId,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var storyIds []uint64
+	for rows.Next() {
+		var storyId uint64
+		if err = rows.Scan(&storyId); err != nil {
+			return nil, err
+		}
+
+		storyIds = append(storyIds, storyId)
+	}
+
+	return storyIds, nil
+}
+
+// Delete hard-deletes stories with their views and attachment records.
+func (r StoryRepository) Delete(ctx context.Context, storyIds []uint64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM stories WHERE id = ANY($1)", pq.Array(storyIds))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanStory(rows *sql.Rows) (entity.Story, error) {
+	var story entity.Story
+	err := rows.Scan(
+		&story.Id,
+		&story.AuthorId,
+		&story.AuthorNick,
+		&story.Content,
+		&story.Seen,
+		&story.Views,
+		&story.ExpiresAt,
+		&story.CreatedAt,
+	)
+
+	return story, err
+}
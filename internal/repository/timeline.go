@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/lib/pq"
+)
+
+// Home timelines are materialized on the timelines table: published posts are fanned out to the accepted followers
+// of their authors on write. Authors with at least the FanOutMaxFollowers of the configuration followers are left out
+// of the fan-out and their posts are merged on read instead, so a single post never writes millions of rows. Which
+// authors are fanned out is kept on users.fan_out and synced by syncFanOut whenever their followers change, so reads
+// don't count followers.
+
+// backfillSize is how many recent posts of an account are copied to the timeline of a new follower.
+const backfillSize = 100
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// fanOut copies published posts to the timelines of their authors and of the followers of authors below the fan-out
+// limit. It's idempotent, so it can run again for posts already fanned out.
+func fanOut(ctx context.Context, ex execer, postIds []uint64) error {
+	if len(postIds) == 0 {
+		return nil
+	}
+
+	insertStmt := `INSERT INTO timelines (user_id, post_id, author, published_at)
+		SELECT p.author, p.id, p.author, p.published_at FROM posts p
+		WHERE p.id = ANY($1) AND p.status = 'published'
+		UNION ALL
+		SELECT f.follower, p.id, p.author, p.published_at FROM posts p
+		JOIN users a ON a.id = p.author AND a.fan_out
+		JOIN followers f ON f.user_id = p.author AND f.accepted
+		WHERE p.id = ANY($1) AND p.status = 'published'
+		ON CONFLICT (user_id, post_id) DO NOTHING`
+	_, err := ex.ExecContext(ctx, insertStmt, pq.Array(postIds))
+
+	return err
+}
+
+// backfill copies the recent posts of the author to the timeline of the user, once the user follows them.
+func backfill(ctx context.Context, ex execer, userId, authorId string) error {
+	insertStmt := `INSERT INTO timelines (user_id, post_id, author, published_at)
+		SELECT f.follower, p.id, p.author, p.published_at FROM followers f
+		JOIN LATERAL (
+			SELECT id, author, published_at FROM posts
+			WHERE author = f.user_id AND status = 'published'
+			ORDER BY published_at desc
+			LIMIT $3
+		) p ON true
+		JOIN users a ON a.id = f.user_id AND a.fan_out
+		WHERE f.user_id = $2 AND f.follower = $1 AND f.accepted
+		ON CONFLICT (user_id, post_id) DO NOTHING`
+	_, err := ex.ExecContext(ctx, insertStmt, userId, authorId, backfillSize)
+
+	return err
+}
+
+// forget removes the posts of the author from the timeline of the user.
+func forget(ctx context.Context, ex execer, userId, authorId string) error {
+	_, err := ex.ExecContext(ctx, "DELETE FROM timelines WHERE user_id = $1 AND author = $2", userId, authorId)
+
+	return err
+}
+
+// syncFanOut switches the authors between fan-out on write and merge on read as their followers count crosses
+// FanOutMaxFollowers, or every author when authorIds is nil. Authors going back to fan-out get their recent posts
+// backfilled to the timelines of their followers, which miss the posts merged on read so far.
+func syncFanOut(ctx context.Context, ex execer, authorIds []string) error {
+	syncStmt := `WITH switched AS (
+			UPDATE users SET fan_out = followers_count < $2
+			WHERE ($1::uuid[] IS NULL OR id = ANY($1)) AND fan_out <> (followers_count < $2)
+			RETURNING id, fan_out
+		)
+		INSERT INTO timelines (user_id, post_id, author, published_at)
+		SELECT f.follower, p.id, p.author, p.published_at FROM switched s
+		JOIN followers f ON f.user_id = s.id AND f.accepted
+		JOIN LATERAL (
+			SELECT id, author, published_at FROM posts
+			WHERE author = s.id AND status = 'published'
+			ORDER BY published_at desc
+			LIMIT $3
+		) p ON true
+		WHERE s.fan_out
+		ON CONFLICT (user_id, post_id) DO NOTHING`
+	maxFollowers := config.FromContext(ctx).FanOutMaxFollowers
+	_, err := ex.ExecContext(ctx, syncStmt, pq.Array(authorIds), maxFollowers, backfillSize)
+
+	return err
+}
+
+// RebuildTimelines recomputes the home timelines of userId, or of everyone when it's empty, from posts and follows, as
+// if every published post had just been fanned out. It first syncs the fan-out of every author, which is how a change
+// of FanOutMaxFollowers is applied.
+func (r PostRepository) RebuildTimelines(ctx context.Context, userId string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = syncFanOut(ctx, tx, nil); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM timelines WHERE $1 = '' OR user_id::text = $1", userId); err != nil {
+		return err
+	}
+	insertStmt := `INSERT INTO timelines (user_id, post_id, author, published_at)
+		SELECT p.author, p.id, p.author, p.published_at FROM posts p
+		WHERE p.status = 'published' AND ($1 = '' OR p.author::text = $1)
+		UNION ALL
+		SELECT f.follower, p.id, p.author, p.published_at FROM posts p
+		JOIN users a ON a.id = p.author AND a.fan_out
+		JOIN followers f ON f.user_id = p.author AND f.accepted
+		WHERE p.status = 'published' AND ($1 = '' OR f.follower::text = $1)
+		ON CONFLICT (user_id, post_id) DO NOTHING`
+	if _, err = tx.ExecContext(ctx, insertStmt, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
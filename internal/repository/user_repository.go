@@ -1,25 +1,43 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/lib/pq"
 	"time"
 )
 
 type User interface {
-	Create(user entity.User) (string, error)
-	FetchByNameOrNick(nameOrNick string) ([]entity.User, error)
-	FetchById(userId string) (entity.User, error)
-	FetchByEmail(email string) (entity.User, error)
-	Update(userId string, user entity.User) error
-	Delete(userId string) error
-	Follow(userId, follower string) error
-	Unfollow(userId, follower string) error
-	FetchFollowers(userId string) ([]entity.User, error)
-	FetchFollowing(userId string) ([]entity.User, error)
-	FetchPasswordById(userId string) (string, error)
-	UpdatePassword(userId string, passwordHash string) error
+	Create(ctx context.Context, user entity.User) (string, error)
+	FetchByNameOrNick(ctx context.Context, nameOrNick string) ([]entity.User, error)
+	FetchById(ctx context.Context, userId string) (entity.User, error)
+	FetchProfile(ctx context.Context, userId string) (entity.Profile, error)
+	FetchByEmail(ctx context.Context, email string) (entity.User, error)
+	Update(ctx context.Context, userId string, user entity.User) error
+	Delete(ctx context.Context, userId string) error
+	Follow(ctx context.Context, userId, follower string) (bool, error)
+	Unfollow(ctx context.Context, userId, follower string) error
+	FetchFollowers(ctx context.Context, userId string) ([]entity.User, error)
+	FetchFollowing(ctx context.Context, userId string) ([]entity.User, error)
+	FetchPasswordById(ctx context.Context, userId string) (string, error)
+	UpdatePassword(ctx context.Context, userId string, passwordHash string) error
+	UpdateAvatar(ctx context.Context, userId string, avatarKey string) (string, error)
+	Block(ctx context.Context, userId, blocked string) error
+	Unblock(ctx context.Context, userId, blocked string) error
+	IsBlocked(ctx context.Context, userId, otherId string) (bool, error)
+	FetchSuggestionCandidates(ctx context.Context, userId string, limit int) ([]entity.Suggestion, error)
+	Mute(ctx context.Context, userId, muted string) error
+	Unmute(ctx context.Context, userId, muted string) error
+	FetchFollowRequests(ctx context.Context, userId string) ([]entity.User, error)
+	AcceptFollowRequest(ctx context.Context, userId, follower string) (bool, error)
+	RejectFollowRequest(ctx context.Context, userId, follower string) (bool, error)
+	FetchRelationships(ctx context.Context, userId string, otherIds []string) ([]entity.Relationship, error)
+	SetSuspended(ctx context.Context, userId string, suspended bool) error
+	IsSuspended(ctx context.Context, userId string) (bool, error)
+	SetModerator(ctx context.Context, userId string, moderator bool) error
 }
 
 type UserRepository struct {
@@ -30,21 +48,23 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db}
 }
 
-func (r UserRepository) Create(user entity.User) (string, error) {
+func (r UserRepository) Create(ctx context.Context, user entity.User) (string, error) {
 	var userId string
-	insertStmt := `INSERT INTO users (name, nick, email, password) VALUES ($1, $2, $3, $4) RETURNING id`
-	err := r.db.QueryRow(insertStmt, user.Name, user.Nick, user.Email, user.Password).Scan(&userId)
+	insertStmt := `INSERT INTO users (name, nick, email, password, moderator) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	err := r.db.QueryRowContext(ctx, insertStmt, user.Name, user.Nick, user.Email, user.Password, user.Moderator).Scan(&userId)
 	if err != nil {
-		return "", err
+		return "", translate(err)
 	}
 
 	return userId, nil
 }
 
-func (r UserRepository) FetchByNameOrNick(nameOrNick string) ([]entity.User, error) {
+// FetchByNameOrNick searches users by name or nick. Like the other listings of users, it leaves out their e-mail.
+func (r UserRepository) FetchByNameOrNick(ctx context.Context, nameOrNick string) ([]entity.User, error) {
 	nameOrNick = fmt.Sprintf("%%%s%%", nameOrNick)
-	rows, err := r.db.Query(
-		"SELECT id, name, nick, email, password, created_at, updated_at FROM users WHERE name LIKE $1 OR nick LIKE $1",
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, name, nick, created_at, updated_at FROM users WHERE name LIKE $1 OR nick LIKE $1",
 		nameOrNick,
 	)
 	if err != nil {
@@ -60,8 +80,6 @@ func (r UserRepository) FetchByNameOrNick(nameOrNick string) ([]entity.User, err
 			&user.Id,
 			&user.Name,
 			&user.Nick,
-			&user.Email,
-			&user.Password,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		); err != nil {
@@ -74,9 +92,12 @@ func (r UserRepository) FetchByNameOrNick(nameOrNick string) ([]entity.User, err
 	return users, nil
 }
 
-func (r UserRepository) FetchById(userId string) (entity.User, error) {
-	row, err := r.db.Query(
-		"SELECT id, name, nick, email, password, created_at, updated_at FROM users WHERE id = $1",
+func (r UserRepository) FetchById(ctx context.Context, userId string) (entity.User, error) {
+	row, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, name, nick, email, password, bio, avatar, website, location, pronouns, protected, expand_sensitive,
+			moderator, suspended_at IS NOT NULL, created_at, updated_at
+		FROM users WHERE id = $1`,
 		userId,
 	)
 
@@ -94,18 +115,74 @@ func (r UserRepository) FetchById(userId string) (entity.User, error) {
 			&user.Nick,
 			&user.Email,
 			&user.Password,
+			&user.Bio,
+			&user.AvatarKey,
+			&user.Website,
+			&user.Location,
+			&user.Pronouns,
+			&user.Protected,
+			&user.ExpandSensitive,
+			&user.Moderator,
+			&user.Suspended,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		); err != nil {
 			return entity.User{}, err
 		}
+
+		return user, nil
 	}
 
-	return user, nil
+	return entity.User{}, ErrNotFound
 }
 
-func (r UserRepository) FetchByEmail(email string) (entity.User, error) {
-	row, err := r.db.Query("SELECT id, password FROM users WHERE email = $1", email)
+func (r UserRepository) FetchProfile(ctx context.Context, userId string) (entity.Profile, error) {
+	row, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.name, u.nick, u.email, u.bio, u.avatar, u.website, u.location, u.pronouns, u.protected,
+			u.expand_sensitive, u.created_at, u.updated_at,
+			(SELECT count(*) FROM followers WHERE user_id = u.id AND accepted),
+			(SELECT count(*) FROM followers WHERE follower = u.id AND accepted),
+			(SELECT count(*) FROM posts WHERE author = u.id AND status = 'published')
+		FROM users u WHERE u.id = $1`,
+		userId,
+	)
+	if err != nil {
+		return entity.Profile{}, err
+	}
+	defer row.Close()
+
+	var profile entity.Profile
+
+	if row.Next() {
+		if err := row.Scan(
+			&profile.Id,
+			&profile.Name,
+			&profile.Nick,
+			&profile.Email,
+			&profile.Bio,
+			&profile.AvatarKey,
+			&profile.Website,
+			&profile.Location,
+			&profile.Pronouns,
+			&profile.Protected,
+			&profile.ExpandSensitive,
+			&profile.CreatedAt,
+			&profile.UpdatedAt,
+			&profile.Followers,
+			&profile.Following,
+			&profile.Posts,
+		); err != nil {
+			return entity.Profile{}, err
+		}
+
+		return profile, nil
+	}
+
+	return entity.Profile{}, ErrNotFound
+}
+
+func (r UserRepository) FetchByEmail(ctx context.Context, email string) (entity.User, error) {
+	row, err := r.db.QueryContext(ctx, "SELECT id, password, suspended_at IS NOT NULL FROM users WHERE email = $1", email)
 	if err != nil {
 		return entity.User{}, err
 	}
@@ -113,58 +190,138 @@ func (r UserRepository) FetchByEmail(email string) (entity.User, error) {
 
 	var user entity.User
 	if row.Next() {
-		if err := row.Scan(&user.Id, &user.Password); err != nil {
+		if err := row.Scan(&user.Id, &user.Password, &user.Suspended); err != nil {
 			return entity.User{}, err
 		}
+
+		return user, nil
 	}
 
-	return user, nil
+	return entity.User{}, ErrNotFound
+}
+
+func (r UserRepository) Update(ctx context.Context, userId string, user entity.User) error {
+	updateStmt := `UPDATE users SET name=$1, nick=$2, email=$3, bio=$4, website=$5, location=$6, pronouns=$7, protected=$8,
+		expand_sensitive=$9, updated_at=$10 WHERE id=$11`
+	return affected(r.db.ExecContext(
+		ctx,
+		updateStmt,
+		user.Name,
+		user.Nick,
+		user.Email,
+		user.Bio,
+		user.Website,
+		user.Location,
+		user.Pronouns,
+		user.Protected,
+		user.ExpandSensitive,
+		time.Now(),
+		userId,
+	))
 }
 
-func (r UserRepository) Update(userId string, user entity.User) error {
-	updateStmt := "UPDATE users SET name=$1, nick=$2, email=$3, updated_at=$4 WHERE id=$5"
-	_, err := r.db.Exec(updateStmt, user.Name, user.Nick, user.Email, time.Now(), userId)
+// Delete removes the user. The accounts they followed lose a follower, so their fan-out is synced.
+func (r UserRepository) Delete(ctx context.Context, userId string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
-}
-
-func (r UserRepository) Delete(userId string) error {
-	deleteStmt := "DELETE FROM users WHERE id=$1"
-	_, err := r.db.Exec(deleteStmt, userId)
+	rows, err := tx.QueryContext(ctx, "DELETE FROM followers WHERE follower=$1 AND accepted RETURNING user_id", userId)
 	if err != nil {
 		return err
 	}
+	var followed []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		followed = append(followed, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
 
-	return nil
+	if err = affected(tx.ExecContext(ctx, "DELETE FROM users WHERE id=$1", userId)); err != nil {
+		return err
+	}
+	if err = syncFanOut(ctx, tx, followed); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r UserRepository) Follow(userId, follower string) error {
-	insertStmt := "INSERT INTO followers (user_id, follower) VALUES ($1, $2) ON CONFLICT (user_id, follower) DO NOTHING"
-	_, err := r.db.Exec(insertStmt, userId, follower)
+// Follow makes follower follow userId. It reports whether a new follow was established, which isn't the case when
+// follower already followed or requested to follow userId, nor when the request awaits the approval of userId. It
+// returns ErrNotFound when userId doesn't exist.
+func (r UserRepository) Follow(ctx context.Context, userId, follower string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Following a protected account creates a pending request that must be accepted by its owner.
+	insertStmt := `INSERT INTO followers (user_id, follower, accepted) SELECT id, $2, NOT protected FROM users WHERE id = $1
+		ON CONFLICT (user_id, follower) DO NOTHING
+		RETURNING accepted`
+	var accepted bool
+	err = tx.QueryRowContext(ctx, insertStmt, userId, follower).Scan(&accepted)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing was inserted: either the follow already exists or there is no one to follow.
+		var exists bool
+		existsStmt := "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)"
+		if err = tx.QueryRowContext(ctx, existsStmt, userId).Scan(&exists); err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, ErrNotFound
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, translate(err)
+	}
+	if err = backfill(ctx, tx, follower, userId); err != nil {
+		return false, err
+	}
+	if err = syncFanOut(ctx, tx, []string{userId}); err != nil {
+		return false, err
 	}
 
-	return nil
+	return accepted, tx.Commit()
 }
 
-func (r UserRepository) Unfollow(userId, follower string) error {
-	deleteStmt := "DELETE FROM followers WHERE user_id=$1 AND follower=$2"
-	_, err := r.db.Exec(deleteStmt, userId, follower)
+func (r UserRepository) Unfollow(ctx context.Context, userId, follower string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	deleteStmt := "DELETE FROM followers WHERE user_id=$1 AND follower=$2"
+	if _, err = tx.ExecContext(ctx, deleteStmt, userId, follower); err != nil {
+		return err
+	}
+	if err = forget(ctx, tx, follower, userId); err != nil {
+		return err
+	}
+	if err = syncFanOut(ctx, tx, []string{userId}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r UserRepository) FetchFollowers(userId string) ([]entity.User, error) {
-	rows, err := r.db.Query(`
-		SELECT u.id, u.name, u.nick, u.email, u.created_at, u.updated_at
-		FROM users u INNER JOIN followers f ON u.id = f.follower WHERE f.user_id = $1`,
+func (r UserRepository) FetchFollowers(ctx context.Context, userId string) ([]entity.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.name, u.nick, u.created_at, u.updated_at
+		FROM users u INNER JOIN followers f ON u.id = f.follower WHERE f.user_id = $1 AND f.accepted`,
 		userId,
 	)
 	if err != nil {
@@ -180,7 +337,6 @@ func (r UserRepository) FetchFollowers(userId string) ([]entity.User, error) {
 			&user.Id,
 			&user.Name,
 			&user.Nick,
-			&user.Email,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		); err != nil {
@@ -193,10 +349,10 @@ func (r UserRepository) FetchFollowers(userId string) ([]entity.User, error) {
 	return users, nil
 }
 
-func (r UserRepository) FetchFollowing(userId string) ([]entity.User, error) {
-	rows, err := r.db.Query(`
-		SELECT u.id, u.name, u.nick, u.email, u.created_at, u.updated_at
-		FROM users u INNER JOIN followers f ON u.id = f.user_id WHERE f.follower = $1`,
+func (r UserRepository) FetchFollowing(ctx context.Context, userId string) ([]entity.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.name, u.nick, u.created_at, u.updated_at
+		FROM users u INNER JOIN followers f ON u.id = f.user_id WHERE f.follower = $1 AND f.accepted`,
 		userId,
 	)
 	if err != nil {
@@ -212,7 +368,6 @@ func (r UserRepository) FetchFollowing(userId string) ([]entity.User, error) {
 			&user.Id,
 			&user.Name,
 			&user.Nick,
-			&user.Email,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		); err != nil {
@@ -225,8 +380,8 @@ func (r UserRepository) FetchFollowing(userId string) ([]entity.User, error) {
 	return users, nil
 }
 
-func (r UserRepository) FetchPasswordById(userId string) (string, error) {
-	row, err := r.db.Query("SELECT password FROM users WHERE id = $1", userId)
+func (r UserRepository) FetchPasswordById(ctx context.Context, userId string) (string, error) {
+	row, err := r.db.QueryContext(ctx, "SELECT password FROM users WHERE id = $1", userId)
 	if err != nil {
 		return "", err
 	}
@@ -238,17 +393,298 @@ func (r UserRepository) FetchPasswordById(userId string) (string, error) {
 		if err = row.Scan(&user.Password); err != nil {
 			return "", err
 		}
+
+		return user.Password, nil
 	}
 
-	return user.Password, nil
+	return "", ErrNotFound
 }
 
-func (r UserRepository) UpdatePassword(userId string, passwordHash string) error {
+func (r UserRepository) UpdatePassword(ctx context.Context, userId string, passwordHash string) error {
 	updateStmt := "UPDATE users SET password=$1, updated_at=$2 WHERE id=$3"
-	_, err := r.db.Exec(updateStmt, passwordHash, time.Now(), userId)
+	return affected(r.db.ExecContext(ctx, updateStmt, passwordHash, time.Now(), userId))
+}
+
+// UpdateAvatar sets the user avatar and returns the previous avatar key, so its blob can be removed.
+func (r UserRepository) UpdateAvatar(ctx context.Context, userId string, avatarKey string) (string, error) {
+	var previousKey string
+	updateStmt := `UPDATE users u SET avatar=$1, updated_at=$2 FROM users old
+		WHERE u.id=$3 AND old.id=u.id RETURNING old.avatar`
+	err := r.db.QueryRowContext(ctx, updateStmt, avatarKey, time.Now(), userId).Scan(&previousKey)
+	if err != nil {
+		return "", translate(err)
+	}
+
+	return previousKey, nil
+}
+
+// Block makes userId block another account, removing any follow relation between both.
+func (r UserRepository) Block(ctx context.Context, userId, blocked string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insertStmt := "INSERT INTO blocks (user_id, blocked) VALUES ($1, $2) ON CONFLICT (user_id, blocked) DO NOTHING"
+	if _, err = tx.ExecContext(ctx, insertStmt, userId, blocked); err != nil {
+		return translate(err)
+	}
+
+	deleteStmt := "DELETE FROM followers WHERE (user_id=$1 AND follower=$2) OR (user_id=$2 AND follower=$1)"
+	if _, err = tx.ExecContext(ctx, deleteStmt, userId, blocked); err != nil {
+		return err
+	}
+	if err = forget(ctx, tx, userId, blocked); err != nil {
+		return err
+	}
+	if err = forget(ctx, tx, blocked, userId); err != nil {
+		return err
+	}
+	if err = syncFanOut(ctx, tx, []string{userId, blocked}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r UserRepository) Unblock(ctx context.Context, userId, blocked string) error {
+	deleteStmt := "DELETE FROM blocks WHERE user_id=$1 AND blocked=$2"
+	_, err := r.db.ExecContext(ctx, deleteStmt, userId, blocked)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// IsBlocked reports whether any of both users blocked the other.
+func (r UserRepository) IsBlocked(ctx context.Context, userId, otherId string) (bool, error) {
+	var blocked bool
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM blocks WHERE (user_id = $1 AND blocked = $2) OR (user_id = $2 AND blocked = $1)
+		)`,
+		userId,
+		otherId,
+	).Scan(&blocked)
+	if err != nil {
+		return false, err
+	}
+
+	return blocked, nil
+}
+
+// FetchSuggestionCandidates returns accounts followed by the accounts userId follows (friends-of-friends),
+// excluding userId itself, accounts it already follows and blocked accounts in both directions.
+func (r UserRepository) FetchSuggestionCandidates(ctx context.Context, userId string, limit int) ([]entity.Suggestion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH following AS (
+			SELECT user_id FROM followers WHERE follower = $1 AND accepted
+		)
+		SELECT u.id, u.name, u.nick, u.avatar, count(*) AS mutuals, min(via.nick) AS followed_by,
+			(SELECT max(p.published_at) FROM posts p WHERE p.author = u.id AND p.status = 'published') AS last_activity
+		FROM followers f
+		INNER JOIN following fw ON fw.user_id = f.follower AND f.accepted
+		INNER JOIN users u ON u.id = f.user_id AND u.suspended_at IS NULL
+		INNER JOIN users via ON via.id = f.follower
+		WHERE f.user_id <> $1
+			AND f.user_id NOT IN (SELECT user_id FROM followers WHERE follower = $1)
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE (b.user_id = $1 AND b.blocked = f.user_id) OR (b.user_id = f.user_id AND b.blocked = $1)
+			)
+		GROUP BY u.id
+		ORDER BY mutuals DESC, last_activity DESC NULLS LAST
+		LIMIT $2`,
+		userId,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []entity.Suggestion
+
+	for rows.Next() {
+		var suggestion entity.Suggestion
+		if err = rows.Scan(
+			&suggestion.Id,
+			&suggestion.Name,
+			&suggestion.Nick,
+			&suggestion.AvatarKey,
+			&suggestion.Mutuals,
+			&suggestion.FollowedBy,
+			&suggestion.LastActivity,
+		); err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+func (r UserRepository) Mute(ctx context.Context, userId, muted string) error {
+	insertStmt := "INSERT INTO mutes (user_id, muted) VALUES ($1, $2) ON CONFLICT (user_id, muted) DO NOTHING"
+	_, err := r.db.ExecContext(ctx, insertStmt, userId, muted)
+	if err != nil {
+		return translate(err)
+	}
+
+	return nil
+}
+
+func (r UserRepository) Unmute(ctx context.Context, userId, muted string) error {
+	deleteStmt := "DELETE FROM mutes WHERE user_id=$1 AND muted=$2"
+	_, err := r.db.ExecContext(ctx, deleteStmt, userId, muted)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r UserRepository) FetchFollowRequests(ctx context.Context, userId string) ([]entity.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.name, u.nick, u.created_at, u.updated_at
+		FROM users u INNER JOIN followers f ON u.id = f.follower WHERE f.user_id = $1 AND NOT f.accepted`,
+		userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []entity.User
+
+	for rows.Next() {
+		var user entity.User
+		if err = rows.Scan(
+			&user.Id,
+			&user.Name,
+			&user.Nick,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// AcceptFollowRequest approves a pending follow. It reports false when there was no pending request.
+func (r UserRepository) AcceptFollowRequest(ctx context.Context, userId, follower string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	updateStmt := "UPDATE followers SET accepted=true WHERE user_id=$1 AND follower=$2 AND NOT accepted"
+	result, err := tx.ExecContext(ctx, updateStmt, userId, follower)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if err = backfill(ctx, tx, follower, userId); err != nil {
+		return false, err
+	}
+	if err = syncFanOut(ctx, tx, []string{userId}); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// RejectFollowRequest discards a pending follow. It reports false when there was no pending request.
+func (r UserRepository) RejectFollowRequest(ctx context.Context, userId, follower string) (bool, error) {
+	deleteStmt := "DELETE FROM followers WHERE user_id=$1 AND follower=$2 AND NOT accepted"
+	result, err := r.db.ExecContext(ctx, deleteStmt, userId, follower)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// FetchRelationships computes, in a single query, how userId relates to each of otherIds.
+func (r UserRepository) FetchRelationships(ctx context.Context, userId string, otherIds []string) ([]entity.Relationship, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id,
+			coalesce(bool_or(f.user_id = t.id AND f.accepted), false) AS following,
+			coalesce(bool_or(f.user_id = $1 AND f.accepted), false) AS followed_by,
+			coalesce(bool_or(f.user_id = t.id AND NOT f.accepted), false) AS pending,
+			coalesce(bool_or(f.user_id = $1 AND NOT f.accepted), false) AS requested,
+			EXISTS (SELECT 1 FROM blocks b WHERE b.user_id = $1 AND b.blocked = t.id) AS blocking,
+			EXISTS (SELECT 1 FROM blocks b WHERE b.user_id = t.id AND b.blocked = $1) AS blocked_by,
+			EXISTS (SELECT 1 FROM mutes m WHERE m.user_id = $1 AND m.muted = t.id) AS muting
+		FROM unnest($2::uuid[]) AS t(id)
+		LEFT JOIN followers f
+			ON (f.user_id = t.id AND f.follower = $1) OR (f.user_id = $1 AND f.follower = t.id)
+		GROUP BY t.id`,
+		userId,
+		pq.Array(otherIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relationships []entity.Relationship
+
+	for rows.Next() {
+		var relationship entity.Relationship
+		if err = rows.Scan(
+			&relationship.Id,
+			&relationship.Following,
+			&relationship.FollowedBy,
+			&relationship.Pending,
+			&relationship.Requested,
+			&relationship.Blocking,
+			&relationship.BlockedBy,
+			&relationship.Muting,
+		); err != nil {
+			return nil, err
+		}
+
+		relationships = append(relationships, relationship)
+	}
+
+	return relationships, nil
+}
+
+// SetSuspended suspends or reinstates the account. Suspending an account already suspended keeps its original date.
+func (r UserRepository) SetSuspended(ctx context.Context, userId string, suspended bool) error {
+	updateStmt := `UPDATE users SET suspended_at = CASE WHEN $2 THEN COALESCE(suspended_at, now()) END WHERE id = $1`
+	return affected(r.db.ExecContext(ctx, updateStmt, userId, suspended))
+}
+
+func (r UserRepository) IsSuspended(ctx context.Context, userId string) (bool, error) {
+	var suspended bool
+	err := r.db.QueryRowContext(ctx, "SELECT suspended_at IS NOT NULL FROM users WHERE id = $1", userId).Scan(&suspended)
+
+	return suspended, translate(err)
+}
+
+func (r UserRepository) SetModerator(ctx context.Context, userId string, moderator bool) error {
+	return affected(r.db.ExecContext(ctx, "UPDATE users SET moderator = $2 WHERE id = $1", userId, moderator))
+}
@@ -2,25 +2,83 @@ package response
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"log/slog"
 	"net/http"
+	"strings"
 )
 
+// Problem is an RFC 7807 problem details object, extended with the stable Code of the error and, for validation
+// errors, the failures of each field.
+type Problem struct {
+	Type   string                 `json:"type"`
+	Title  string                 `json:"title"`
+	Status int                    `json:"status"`
+	Detail string                 `json:"detail,omitempty"`
+	Code   string                 `json:"code"`
+	Errors []errorType.FieldError `json:"errors,omitempty"`
+}
+
+// ErrorRecorder is implemented by response writers that keep the internal error of the response, so it's logged
+// along with the request.
+type ErrorRecorder interface {
+	RecordError(err error)
+}
+
 func JSON(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("Content-Type", "application/json")
+	write(w, statusCode, "application/json", data)
+}
+
+// Error writes err as an application/problem+json response. Errors of 5xx statuses may tell about the database or the
+// code, so clients only get their status, while the error is handed to the ErrorRecorder of w, or logged.
+func Error(w http.ResponseWriter, statusCode int, err error) {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Code:   code(statusCode, err),
+	}
+
+	if statusCode >= http.StatusInternalServerError {
+		if recorder, ok := w.(ErrorRecorder); ok {
+			recorder.RecordError(err)
+		} else {
+			slog.Error("internal error", "error", err)
+		}
+	} else {
+		problem.Detail = err.Error()
+		var validation interface{ FieldErrors() []errorType.FieldError }
+		if errors.As(err, &validation) {
+			problem.Errors = validation.FieldErrors()
+		}
+	}
+
+	write(w, statusCode, "application/problem+json", problem)
+}
+
+// code returns the code of err when it has one, validation_failed for validation errors and the snake case text of
+// statusCode otherwise, like not_found.
+func code(statusCode int, err error) string {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	var validation interface{ FieldErrors() []errorType.FieldError }
+	if errors.As(err, &validation) {
+		return "validation_failed"
+	}
+
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(statusCode)), " ", "_")
+}
+
+func write(w http.ResponseWriter, statusCode int, contentType string, data any) {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(statusCode)
 
 	if data != nil {
 		if err := json.NewEncoder(w).Encode(data); err != nil {
-			log.Fatal(err)
+			slog.Error("failed to encode response", "error", err)
 		}
 	}
 }
-
-func Error(w http.ResponseWriter, statusCode int, err error) {
-	JSON(w, statusCode, struct {
-		Error string `json:"error"`
-	}{
-		Error: err.Error(),
-	})
-}
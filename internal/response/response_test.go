@@ -0,0 +1,82 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type errorRecorder struct {
+	*httptest.ResponseRecorder
+	err error
+}
+
+func (er *errorRecorder) RecordError(err error) {
+	er.err = err
+}
+
+func TestError(t *testing.T) {
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) Problem {
+		t.Helper()
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+			t.Errorf("Error should answer application/problem+json. Got: %q", contentType)
+		}
+		var problem Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("Error should write a JSON problem. Error: %v", err)
+		}
+		return problem
+	}
+
+	t.Run("Should report the fields of validation errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Error(w, http.StatusBadRequest, errorType.NewErrorPostValidation(
+			errorType.NewFieldError("title", errorType.CodeRequired, "title is required"),
+			errorType.NewFieldError("content", errorType.CodeRequired, "content is required"),
+		))
+
+		problem := decode(t, w)
+		if problem.Status != http.StatusBadRequest || problem.Title != "Bad Request" || problem.Code != "validation_failed" ||
+			problem.Detail != "title is required; content is required" || len(problem.Errors) != 2 ||
+			problem.Errors[1].Field != "content" {
+			t.Errorf("Error should describe every invalid field. Got: %+v", problem)
+		}
+	})
+
+	t.Run("Should use the code of coded errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		notFound := errorType.NewCodedError("post_not_found", "post not found")
+		Error(w, http.StatusNotFound, fmt.Errorf("loading post: %w", notFound))
+
+		if problem := decode(t, w); problem.Code != "post_not_found" {
+			t.Errorf("Error should use the code of the error. Got: %q", problem.Code)
+		}
+	})
+
+	t.Run("Should derive the code from the status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Error(w, http.StatusForbidden, errors.New("access denied"))
+
+		if problem := decode(t, w); problem.Code != "forbidden" || problem.Detail != "access denied" {
+			t.Errorf("Error should derive the code from the status. Got: %+v", problem)
+		}
+	})
+
+	t.Run("Should hide internal errors from clients", func(t *testing.T) {
+		w := &errorRecorder{ResponseRecorder: httptest.NewRecorder()}
+		internal := errors.New(`pq: relation "posts" does not exist`)
+		Error(w, http.StatusInternalServerError, internal)
+
+		problem := decode(t, w.ResponseRecorder)
+		if problem.Detail != "" || problem.Code != "internal_server_error" {
+			t.Errorf("Error should not reveal internal errors. Got: %+v", problem)
+		}
+		if w.err != internal {
+			t.Errorf("Error should hand internal errors to the recorder. Got: %v", w.err)
+		}
+	})
+}
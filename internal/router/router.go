@@ -1,11 +1,13 @@
 package router
 
 import (
+	"github.com/edigar/socialnets-api/internal/middleware"
+	"github.com/edigar/socialnets-api/internal/ratelimit"
 	"github.com/edigar/socialnets-api/internal/router/routes"
 	"github.com/gorilla/mux"
 )
 
-func Generate() *mux.Router {
+func Generate(store ratelimit.Store, accounts middleware.Accounts) *mux.Router {
 	r := mux.NewRouter()
-	return routes.Setup(r)
+	return routes.Setup(r, store, accounts)
 }
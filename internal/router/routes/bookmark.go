@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"github.com/edigar/socialnets-api/internal/controller"
+	"net/http"
+)
+
+var bookmarkRoutes = []Route{
+	{
+		URI:                    "/api/post/{postId}/bookmark",
+		Method:                 http.MethodPost,
+		Function:               controller.Bookmark,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/post/{postId}/unbookmark",
+		Method:                 http.MethodPost,
+		Function:               controller.Unbookmark,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/bookmark",
+		Method:                 http.MethodGet,
+		Function:               controller.GetBookmarks,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/collection",
+		Method:                 http.MethodPost,
+		Function:               controller.PostCollection,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/collection",
+		Method:                 http.MethodGet,
+		Function:               controller.GetCollections,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/collection/{collectionId}",
+		Method:                 http.MethodGet,
+		Function:               controller.GetCollection,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/collection/{collectionId}",
+		Method:                 http.MethodDelete,
+		Function:               controller.DeleteCollection,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/collection/{collectionId}/post/{postId}",
+		Method:                 http.MethodPut,
+		Function:               controller.AddToCollection,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/collection/{collectionId}/post/{postId}",
+		Method:                 http.MethodDelete,
+		Function:               controller.RemoveFromCollection,
+		AuthenticationRequired: true,
+	},
+}
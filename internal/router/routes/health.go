@@ -2,12 +2,30 @@ package routes
 
 import (
 	"github.com/edigar/socialnets-api/internal/controller"
+	"github.com/edigar/socialnets-api/internal/ratelimit"
 	"net/http"
 )
 
-var healthRoute = Route{
-	URI:                    "/health",
-	Method:                 http.MethodGet,
-	Function:               controller.HealthCheck,
-	AuthenticationRequired: false,
+var healthRoutes = []Route{
+	{
+		URI:                    "/health",
+		Method:                 http.MethodGet,
+		Function:               controller.Liveness,
+		AuthenticationRequired: false,
+		RateLimit:              ratelimit.Unlimited,
+	},
+	{
+		URI:                    "/health/live",
+		Method:                 http.MethodGet,
+		Function:               controller.Liveness,
+		AuthenticationRequired: false,
+		RateLimit:              ratelimit.Unlimited,
+	},
+	{
+		URI:                    "/health/ready",
+		Method:                 http.MethodGet,
+		Function:               controller.Readiness,
+		AuthenticationRequired: false,
+		RateLimit:              ratelimit.Unlimited,
+	},
 }
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"github.com/edigar/socialnets-api/internal/controller"
+	"net/http"
+)
+
+var listRoutes = []Route{
+	{
+		URI:                    "/api/list",
+		Method:                 http.MethodPost,
+		Function:               controller.PostList,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/{userId}/lists",
+		Method:                 http.MethodGet,
+		Function:               controller.GetUserLists,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/list/{listId}",
+		Method:                 http.MethodGet,
+		Function:               controller.GetList,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/list/{listId}",
+		Method:                 http.MethodPut,
+		Function:               controller.UpdateList,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/list/{listId}",
+		Method:                 http.MethodDelete,
+		Function:               controller.DeleteList,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/list/{listId}/members",
+		Method:                 http.MethodGet,
+		Function:               controller.GetListMembers,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/list/{listId}/members/{userId}",
+		Method:                 http.MethodPut,
+		Function:               controller.AddListMember,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/list/{listId}/members/{userId}",
+		Method:                 http.MethodDelete,
+		Function:               controller.RemoveListMember,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/list/{listId}/timeline",
+		Method:                 http.MethodGet,
+		Function:               controller.GetListTimeline,
+		AuthenticationRequired: true,
+	},
+}
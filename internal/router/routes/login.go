@@ -10,4 +10,5 @@ var loginRoute = Route{
 	Method:                 http.MethodPost,
 	Function:               controller.Login,
 	AuthenticationRequired: false,
+	RateLimit:              loginLimit,
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/edigar/socialnets-api/internal/controller"
+	"net/http"
+)
+
+var mediaRoutes = []Route{
+	{
+		URI:                    "/api/media",
+		Method:                 http.MethodPost,
+		Function:               controller.PostMedia,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/media/{key}",
+		Method:                 http.MethodGet,
+		Function:               controller.GetMedia,
+		AuthenticationRequired: false,
+	},
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/edigar/socialnets-api/internal/metrics"
+	"github.com/edigar/socialnets-api/internal/middleware"
+	"net/http"
+)
+
+var metricsRoute = Route{
+	URI:                    "/metrics",
+	Method:                 http.MethodGet,
+	Function:               middleware.MetricsToken(metrics.Handler().ServeHTTP),
+	AuthenticationRequired: false,
+}
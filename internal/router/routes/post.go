@@ -18,6 +18,12 @@ var postRoutes = []Route{
 		Function:               controller.GetPosts,
 		AuthenticationRequired: true,
 	},
+	{
+		URI:                    "/api/post/drafts",
+		Method:                 http.MethodGet,
+		Function:               controller.GetDrafts,
+		AuthenticationRequired: true,
+	},
 	{
 		URI:                    "/api/post/{postId}",
 		Method:                 http.MethodGet,
@@ -36,6 +42,12 @@ var postRoutes = []Route{
 		Function:               controller.DeletePost,
 		AuthenticationRequired: true,
 	},
+	{
+		URI:                    "/api/post/{postId}/revisions",
+		Method:                 http.MethodGet,
+		Function:               controller.GetRevisions,
+		AuthenticationRequired: true,
+	},
 	{
 		URI:                    "/api/user/{userId}/posts",
 		Method:                 http.MethodGet,
@@ -54,4 +66,28 @@ var postRoutes = []Route{
 		Function:               controller.UnlikePost,
 		AuthenticationRequired: true,
 	},
+	{
+		URI:                    "/api/post/{postId}/pin",
+		Method:                 http.MethodPost,
+		Function:               controller.PinPost,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/post/{postId}/unpin",
+		Method:                 http.MethodPost,
+		Function:               controller.UnpinPost,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/post/{postId}/sensitive",
+		Method:                 http.MethodPut,
+		Function:               controller.SetPostSensitive,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/post/{postId}/vote",
+		Method:                 http.MethodPost,
+		Function:               controller.VotePoll,
+		AuthenticationRequired: true,
+	},
 }
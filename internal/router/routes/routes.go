@@ -2,8 +2,18 @@ package routes
 
 import (
 	"github.com/edigar/socialnets-api/internal/middleware"
+	"github.com/edigar/socialnets-api/internal/ratelimit"
 	"github.com/gorilla/mux"
 	"net/http"
+	"time"
+)
+
+// Rate limit policies. Routes without one get readLimit for GET and writeLimit for the other methods.
+var (
+	loginLimit  = ratelimit.Policy{Name: "login", Limit: 5, Period: time.Minute}
+	signupLimit = ratelimit.Policy{Name: "signup", Limit: 3, Period: time.Hour}
+	readLimit   = ratelimit.Policy{Name: "read", Limit: 300, Period: time.Minute}
+	writeLimit  = ratelimit.Policy{Name: "write", Limit: 60, Period: time.Minute}
 )
 
 type Route struct {
@@ -11,21 +21,45 @@ type Route struct {
 	Method                 string
 	Function               func(w http.ResponseWriter, r *http.Request)
 	AuthenticationRequired bool
+	RateLimit              ratelimit.Policy
 }
 
-func Setup(r *mux.Router) *mux.Router {
+// Setup registers every route on r. Requests are rate limited against store, and authenticated requests are rejected
+// when accounts tells their account is suspended, unless they are nil.
+func Setup(r *mux.Router, store ratelimit.Store, accounts middleware.Accounts) *mux.Router {
 	routes := userRoutes
 	routes = append(routes, loginRoute)
 	routes = append(routes, postRoutes...)
-	routes = append(routes, healthRoute)
+	routes = append(routes, mediaRoutes...)
+	routes = append(routes, bookmarkRoutes...)
+	routes = append(routes, listRoutes...)
+	routes = append(routes, storyRoutes...)
+	routes = append(routes, healthRoutes...)
+	routes = append(routes, metricsRoute)
 
 	for _, route := range routes {
+		handler := route.Function
 		if route.AuthenticationRequired {
-			r.HandleFunc(route.URI, middleware.Logger(middleware.Authenticate(route.Function))).Methods(route.Method)
-		} else {
-			r.HandleFunc(route.URI, middleware.Logger(route.Function)).Methods(route.Method)
+			handler = middleware.Authenticate(accounts, handler)
+		}
+		if policy := route.rateLimit(); store != nil && policy != ratelimit.Unlimited {
+			handler = middleware.RateLimit(store, policy, handler)
 		}
+		handler = middleware.Metrics(route.URI, handler)
+		handler = middleware.Trace(route.URI, middleware.RequestID(middleware.Logger(handler)))
+		r.HandleFunc(route.URI, handler).Methods(route.Method)
 	}
 
 	return r
 }
+
+func (route Route) rateLimit() ratelimit.Policy {
+	switch {
+	case route.RateLimit != ratelimit.Policy{}:
+		return route.RateLimit
+	case route.Method == http.MethodGet:
+		return readLimit
+	default:
+		return writeLimit
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/edigar/socialnets-api/internal/controller"
+	"net/http"
+)
+
+var storyRoutes = []Route{
+	{
+		URI:                    "/api/stories",
+		Method:                 http.MethodPost,
+		Function:               controller.PostStory,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/stories",
+		Method:                 http.MethodGet,
+		Function:               controller.GetStories,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/stories/{storyId}/view",
+		Method:                 http.MethodPost,
+		Function:               controller.ViewStory,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/stories/{storyId}/views",
+		Method:                 http.MethodGet,
+		Function:               controller.GetStoryViews,
+		AuthenticationRequired: true,
+	},
+}
@@ -11,6 +11,7 @@ var userRoutes = []Route{
 		Method:                 http.MethodPost,
 		Function:               controller.PostUser,
 		AuthenticationRequired: false,
+		RateLimit:              signupLimit,
 	},
 	{
 		URI:                    "/api/user",
@@ -18,6 +19,36 @@ var userRoutes = []Route{
 		Function:               controller.GetUsers,
 		AuthenticationRequired: true,
 	},
+	{
+		URI:                    "/api/user/suggestions",
+		Method:                 http.MethodGet,
+		Function:               controller.GetSuggestions,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/relationships",
+		Method:                 http.MethodGet,
+		Function:               controller.GetRelationships,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/follow-requests",
+		Method:                 http.MethodGet,
+		Function:               controller.GetFollowRequests,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/follow-requests/{followerId}/accept",
+		Method:                 http.MethodPost,
+		Function:               controller.AcceptFollowRequest,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/follow-requests/{followerId}/reject",
+		Method:                 http.MethodPost,
+		Function:               controller.RejectFollowRequest,
+		AuthenticationRequired: true,
+	},
 	{
 		URI:                    "/api/user/{userId}",
 		Method:                 http.MethodGet,
@@ -66,4 +97,40 @@ var userRoutes = []Route{
 		Function:               controller.UpdatePassword,
 		AuthenticationRequired: true,
 	},
+	{
+		URI:                    "/api/user/{userId}/avatar",
+		Method:                 http.MethodPut,
+		Function:               controller.UpdateAvatar,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/{userId}/block",
+		Method:                 http.MethodPost,
+		Function:               controller.Block,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/{userId}/unblock",
+		Method:                 http.MethodPost,
+		Function:               controller.Unblock,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/{userId}/mute",
+		Method:                 http.MethodPost,
+		Function:               controller.Mute,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/{userId}/unmute",
+		Method:                 http.MethodPost,
+		Function:               controller.Unmute,
+		AuthenticationRequired: true,
+	},
+	{
+		URI:                    "/api/user/{userId}/relationship",
+		Method:                 http.MethodGet,
+		Function:               controller.GetRelationship,
+		AuthenticationRequired: true,
+	},
 }
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/database"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/ratelimit"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/storage"
+	"github.com/edigar/socialnets-api/internal/usecase"
+	"time"
+)
+
+// PublishScheduledPosts publishes scheduled posts whose publish time has come.
+func PublishScheduledPosts(interval time.Duration) Job {
+	return Job{
+		Name:     "publish-scheduled-posts",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			db, err := database.Connect()
+			if err != nil {
+				return err
+			}
+
+			postUseCase := usecase.NewPostUseCase(repository.NewPostRepository(db))
+			published, err := postUseCase.PublishDue(ctx)
+			if len(published) > 0 {
+				logging.FromContext(ctx).Info("published scheduled posts", "count", len(published))
+			}
+
+			return err
+		},
+	}
+}
+
+// PurgeExpiredStories hard-deletes expired stories and their media.
+func PurgeExpiredStories(interval time.Duration) Job {
+	return Job{
+		Name:     "purge-expired-stories",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			db, err := database.Connect()
+			if err != nil {
+				return err
+			}
+
+			cfg := config.FromContext(ctx)
+			blobStore, err := storage.New(cfg.Storage)
+			if err != nil {
+				return err
+			}
+			mediaUseCase := usecase.NewMediaUseCase(repository.NewMediaRepository(db), blobStore, cfg.MediaMaxSize)
+			storyUseCase := usecase.NewStoryUseCase(repository.NewStoryRepository(db))
+			purged, err := storyUseCase.PurgeExpired(ctx, mediaUseCase)
+			if purged > 0 {
+				logging.FromContext(ctx).Info("purged expired stories", "count", purged)
+			}
+
+			return err
+		},
+	}
+}
+
+// PurgeUnattachedMedia deletes uploads that were never attached to a post or story, and their blobs.
+func PurgeUnattachedMedia(interval time.Duration) Job {
+	return Job{
+		Name:     "purge-unattached-media",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			db, err := database.Connect()
+			if err != nil {
+				return err
+			}
+
+			cfg := config.FromContext(ctx)
+			blobStore, err := storage.New(cfg.Storage)
+			if err != nil {
+				return err
+			}
+			mediaUseCase := usecase.NewMediaUseCase(repository.NewMediaRepository(db), blobStore, cfg.MediaMaxSize)
+			purged, err := mediaUseCase.PurgeUnattached(ctx)
+			if purged > 0 {
+				logging.FromContext(ctx).Info("purged unattached media", "count", purged)
+			}
+
+			return err
+		},
+	}
+}
+
+// PurgeRateLimits deletes the rate limit buckets kept on the database that filled up again.
+func PurgeRateLimits(interval time.Duration) Job {
+	return Job{
+		Name:     "purge-rate-limits",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			db, err := database.Connect()
+			if err != nil {
+				return err
+			}
+
+			purged, err := ratelimit.NewPostgresStore(db).Purge(ctx)
+			if purged > 0 {
+				logging.FromContext(ctx).Debug("purged rate limit buckets", "count", purged)
+			}
+
+			return err
+		},
+	}
+}
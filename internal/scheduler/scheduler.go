@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"sync"
+	"time"
+)
+
+// Job is a task run periodically inside the API process.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start runs every job on its own ticker until ctx is canceled. The returned function blocks until all jobs stop.
+// Jobs must be safe to run concurrently from several replicas: each one is responsible for its own locking.
+func Start(ctx context.Context, jobs ...Job) (wait func()) {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			run(ctx, job)
+		}(job)
+	}
+
+	return wg.Wait
+}
+
+func run(ctx context.Context, job Job) {
+	ctx = logging.With(ctx, "job", job.Name)
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		runOnce(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce runs the job once, traced on a span of its own.
+func runOnce(ctx context.Context, job Job) {
+	ctx, span := tracing.Start(ctx, "job "+job.Name)
+	defer span.End()
+
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logging.FromContext(ctx).Error("job failed", "error", err)
+	}
+}
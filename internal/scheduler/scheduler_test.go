@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStart(t *testing.T) {
+	t.Run("Should run jobs periodically until context is canceled", func(t *testing.T) {
+		var runs atomic.Int32
+		ctx, cancel := context.WithCancel(context.Background())
+		wait := Start(ctx, Job{
+			Name:     "counter",
+			Interval: time.Millisecond,
+			Run: func(ctx context.Context) error {
+				if runs.Add(1) == 3 {
+					cancel()
+				}
+				return nil
+			},
+		})
+
+		done := make(chan struct{})
+		go func() {
+			wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Start should stop jobs when context is canceled")
+		}
+		if runs.Load() < 3 {
+			t.Errorf("Start should run the job periodically. Runs: %v", runs.Load())
+		}
+	})
+}
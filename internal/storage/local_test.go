@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should put, get and delete a blob", func(t *testing.T) {
+		store, err := NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewLocalStore should not return an error. Error: %v", err)
+		}
+
+		if err = store.Put(ctx, "media/a/b.png", strings.NewReader("content"), 7, "image/png"); err != nil {
+			t.Fatalf("Put should not return an error. Error: %v", err)
+		}
+
+		reader, contentType, err := store.Get(ctx, "media/a/b.png")
+		if err != nil {
+			t.Fatalf("Get should not return an error for a stored blob. Error: %v", err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(data) != "content" {
+			t.Errorf("Get should return stored content. Expected: %v. Got: %v", "content", string(data))
+		}
+		if contentType != "image/png" {
+			t.Errorf("Get should return content type by extension. Expected: %v. Got: %v", "image/png", contentType)
+		}
+
+		if err = store.Delete(ctx, "media/a/b.png"); err != nil {
+			t.Errorf("Delete should not return an error. Error: %v", err)
+		}
+		if _, _, err = store.Get(ctx, "media/a/b.png"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get should return ErrNotFound after delete. Got: %v", err)
+		}
+	})
+
+	t.Run("Should reject keys escaping the root directory", func(t *testing.T) {
+		store, _ := NewLocalStore(t.TempDir())
+		if err := store.Put(ctx, "../outside", strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put should return an error for a key with '..'")
+		}
+	})
+
+	t.Run("Should ping only while the root directory exists", func(t *testing.T) {
+		root := filepath.Join(t.TempDir(), "media")
+		store, _ := NewLocalStore(root)
+		if err := store.Ping(ctx); err != nil {
+			t.Errorf("Ping should not return an error for an existing root. Error: %v", err)
+		}
+		os.Remove(root)
+		if err := store.Ping(ctx); err == nil {
+			t.Errorf("Ping should return an error once the root is gone")
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible server.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if sha256Hex(body) != r.Header.Get("X-Amz-Content-Sha256") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[r.URL.Path])
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead:
+		if r.URL.Path != "/bucket" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	t.Run("Should put, get and delete an object", func(t *testing.T) {
+		store, err := NewS3Store(server.URL, "bucket", "us-east-1", "access", "secret")
+		if err != nil {
+			t.Fatalf("NewS3Store should not return an error. Error: %v", err)
+		}
+
+		if err = store.Put(ctx, "media/1.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+			t.Fatalf("Put should not return an error. Error: %v", err)
+		}
+		if _, ok := fake.objects["/bucket/media/1.jpg"]; !ok {
+			t.Errorf("Put should store object using path-style addressing. Objects: %v", fake.objects)
+		}
+
+		reader, contentType, err := store.Get(ctx, "media/1.jpg")
+		if err != nil {
+			t.Fatalf("Get should not return an error. Error: %v", err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(data) != "jpeg" || contentType != "image/jpeg" {
+			t.Errorf("Get should return stored object. Got: %v (%v)", string(data), contentType)
+		}
+
+		if err = store.Delete(ctx, "media/1.jpg"); err != nil {
+			t.Errorf("Delete should not return an error. Error: %v", err)
+		}
+		if _, _, err = store.Get(ctx, "media/1.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get should return ErrNotFound after delete. Got: %v", err)
+		}
+	})
+
+	t.Run("Should ping the bucket", func(t *testing.T) {
+		store, _ := NewS3Store(server.URL, "bucket", "us-east-1", "access", "secret")
+		if err := store.Ping(ctx); err != nil {
+			t.Errorf("Ping should not return an error for an existing bucket. Error: %v", err)
+		}
+		store, _ = NewS3Store(server.URL, "missing", "us-east-1", "access", "secret")
+		if err := store.Ping(ctx); err == nil {
+			t.Errorf("Ping should return an error for a missing bucket")
+		}
+	})
+
+	t.Run("Should return an error when the server rejects the request", func(t *testing.T) {
+		store, _ := NewS3Store(server.URL, "bucket", "us-east-1", "wrong", "secret")
+		if err := store.Put(ctx, "media/2.jpg", strings.NewReader("x"), 1, "image/jpeg"); err == nil {
+			t.Errorf("Put should return an error for a forbidden response")
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// Sign returns the signature granting access to the blob of key until expires, a Unix time, signed with secretKey.
+func Sign(secretKey, key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that signature was made by Sign for key and expires, and that it didn't expire yet.
+func Verify(secretKey, key string, expires int64, signature string) error {
+	if secretKey == "" || time.Now().Unix() >= expires {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secretKey, key, expires))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignature(t *testing.T) {
+	secretKey := "0123456789abcdef0123456789abcdef"
+	expires := time.Now().Add(time.Hour).Unix()
+
+	t.Run("Should accept signatures made for the key and expiry", func(t *testing.T) {
+		if err := Verify(secretKey, "a.png", expires, Sign(secretKey, "a.png", expires)); err != nil {
+			t.Errorf("Verify should accept a valid signature. Error: %v", err)
+		}
+	})
+
+	t.Run("Should reject other keys, expiries and secrets", func(t *testing.T) {
+		signature := Sign(secretKey, "a.png", expires)
+		scenarios := map[string]error{
+			"key":    Verify(secretKey, "b.png", expires, signature),
+			"expiry": Verify(secretKey, "a.png", expires+1, signature),
+			"secret": Verify("another secret key, also 32 bytes", "a.png", expires, signature),
+			"empty":  Verify(secretKey, "a.png", expires, ""),
+		}
+		for name, err := range scenarios {
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify should reject a signature of another %s. Got: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Should reject expired signatures", func(t *testing.T) {
+		expired := time.Now().Add(-time.Second).Unix()
+		if err := Verify(secretKey, "a.png", expired, Sign(secretKey, "a.png", expired)); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Verify should reject an expired signature. Got: %v", err)
+		}
+	})
+}
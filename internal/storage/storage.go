@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/config"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore persists opaque binary objects (uploaded media, thumbnails) under a key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	Delete(ctx context.Context, key string) error
+	// Ping checks the store is reachable.
+	Ping(ctx context.Context) error
+}
+
+// New builds the BlobStore selected by the storage driver.
+func New(config config.StorageConfig) (BlobStore, error) {
+	switch config.Driver {
+	case "local":
+		return NewLocalStore(config.LocalDir)
+	case "s3":
+		return NewS3Store(
+			config.Endpoint,
+			config.Bucket,
+			config.Region,
+			config.AccessKey,
+			config.SecretKey,
+		)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.Driver)
+	}
+}
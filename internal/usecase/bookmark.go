@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"slices"
+)
+
+var ErrCollectionNotFound = newNotFound("collection_not_found", "collection not found")
+
+type BookmarkUseCase struct {
+	bookmarkRepository repository.Bookmark
+	postRepository     repository.Post
+}
+
+func NewBookmarkUseCase(bookmarkRepository repository.Bookmark, postRepository repository.Post) *BookmarkUseCase {
+	return &BookmarkUseCase{
+		bookmarkRepository: bookmarkRepository,
+		postRepository:     postRepository,
+	}
+}
+
+// Add bookmarks a post the user can see.
+func (b *BookmarkUseCase) Add(ctx context.Context, userId string, postId uint64) error {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.Add")
+	defer span.End()
+
+	if err := b.checkVisible(ctx, userId, postId); err != nil {
+		return err
+	}
+
+	return b.bookmarkRepository.Add(ctx, userId, postId)
+}
+
+func (b *BookmarkUseCase) Remove(ctx context.Context, userId string, postId uint64) error {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.Remove")
+	defer span.End()
+
+	return b.bookmarkRepository.Remove(ctx, userId, postId)
+}
+
+// Get returns a page of the user bookmarks, most recent first.
+func (b *BookmarkUseCase) Get(ctx context.Context, userId string, page, limit int) ([]entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.Get")
+	defer span.End()
+
+	limit, offset := paginate(page, limit)
+	posts, err := b.bookmarkRepository.Fetch(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		posts[i].Bookmarked = true
+	}
+
+	return posts, nil
+}
+
+// LoadBookmarked sets Bookmarked on the posts the user bookmarked.
+func (b *BookmarkUseCase) LoadBookmarked(ctx context.Context, userId string, posts []entity.Post) error {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.LoadBookmarked")
+	defer span.End()
+
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIds := make([]uint64, len(posts))
+	for i, post := range posts {
+		postIds[i] = post.Id
+	}
+
+	bookmarked, err := b.bookmarkRepository.FetchBookmarked(ctx, userId, postIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Bookmarked = slices.Contains(bookmarked, posts[i].Id)
+	}
+
+	return nil
+}
+
+func (b *BookmarkUseCase) CreateCollection(ctx context.Context, collection *entity.Collection) error {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.CreateCollection")
+	defer span.End()
+
+	err := collection.Prepare()
+	if err != nil {
+		return err
+	}
+
+	collection.Id, err = b.bookmarkRepository.CreateCollection(ctx, *collection)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (b *BookmarkUseCase) GetCollections(ctx context.Context, userId string) ([]entity.Collection, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.GetCollections")
+	defer span.End()
+
+	collections, err := b.bookmarkRepository.FetchCollections(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+// GetCollection returns a collection and a page of its posts. Collections are private to their owner.
+func (b *BookmarkUseCase) GetCollection(
+	ctx context.Context,
+	userId string,
+	collectionId uint64,
+	page, limit int,
+) (entity.Collection, []entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.GetCollection")
+	defer span.End()
+
+	collection, err := b.ownCollection(ctx, userId, collectionId)
+	if err != nil {
+		return entity.Collection{}, nil, err
+	}
+
+	limit, offset := paginate(page, limit)
+	posts, err := b.bookmarkRepository.FetchCollectionPosts(ctx, collectionId, userId, limit, offset)
+	if err != nil {
+		return entity.Collection{}, nil, err
+	}
+
+	return collection, posts, nil
+}
+
+func (b *BookmarkUseCase) DeleteCollection(ctx context.Context, userId string, collectionId uint64) error {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.DeleteCollection")
+	defer span.End()
+
+	if _, err := b.ownCollection(ctx, userId, collectionId); err != nil {
+		return err
+	}
+
+	return b.bookmarkRepository.DeleteCollection(ctx, collectionId)
+}
+
+func (b *BookmarkUseCase) AddToCollection(ctx context.Context, userId string, collectionId, postId uint64) error {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.AddToCollection")
+	defer span.End()
+
+	if _, err := b.ownCollection(ctx, userId, collectionId); err != nil {
+		return err
+	}
+	if err := b.checkVisible(ctx, userId, postId); err != nil {
+		return err
+	}
+
+	return b.bookmarkRepository.AddToCollection(ctx, collectionId, postId)
+}
+
+func (b *BookmarkUseCase) RemoveFromCollection(ctx context.Context, userId string, collectionId, postId uint64) error {
+	ctx, span := tracing.Start(ctx, "BookmarkUseCase.RemoveFromCollection")
+	defer span.End()
+
+	if _, err := b.ownCollection(ctx, userId, collectionId); err != nil {
+		return err
+	}
+
+	return b.bookmarkRepository.RemoveFromCollection(ctx, collectionId, postId)
+}
+
+// ownCollection fetches a collection of the user. Someone else's collection is reported as not found.
+func (b *BookmarkUseCase) ownCollection(ctx context.Context, userId string, collectionId uint64) (entity.Collection, error) {
+	collection, err := b.bookmarkRepository.FetchCollection(ctx, collectionId)
+	if err != nil {
+		return entity.Collection{}, notFound(err, ErrCollectionNotFound)
+	}
+	if collection.OwnerId != userId {
+		return entity.Collection{}, ErrCollectionNotFound
+	}
+
+	return collection, nil
+}
+
+func (b *BookmarkUseCase) checkVisible(ctx context.Context, userId string, postId uint64) error {
+	post, err := NewPostUseCase(b.postRepository).GetById(ctx, postId, userId)
+	if err != nil {
+		return err
+	}
+	if post.Status != entity.PostPublished {
+		return ErrPostNotFound
+	}
+
+	return nil
+}
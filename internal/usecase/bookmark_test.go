@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/usecase/mock"
+	"slices"
+	"testing"
+)
+
+func TestBookmarks(t *testing.T) {
+	userId := usecase.MockPosts[0].AuthorId
+	defer func() { usecase.MockBookmarks = map[string][]uint64{} }()
+
+	t.Run("Should bookmark and list posts with pagination", func(t *testing.T) {
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		for _, post := range usecase.MockPosts {
+			if err := bookmarkUseCase.Add(t.Context(), userId, post.Id); err != nil {
+				t.Errorf("Add should not return an error. Error: %v", err)
+			}
+		}
+
+		posts, err := bookmarkUseCase.Get(t.Context(), userId, 1, 2)
+		if err != nil || len(posts) != 2 || posts[0].Id != usecase.MockPosts[2].Id {
+			t.Errorf("Get should return the first page, most recent first. Got: %v. Error: %v", posts, err)
+		}
+		for _, post := range posts {
+			if !post.Bookmarked {
+				t.Errorf("Get should mark posts as bookmarked. Post: %v", post)
+			}
+		}
+		if posts, _ = bookmarkUseCase.Get(t.Context(), userId, 2, 2); len(posts) != 1 {
+			t.Errorf("Get should return the remaining posts on the second page. Got: %v", posts)
+		}
+	})
+
+	t.Run("Should not bookmark a post the user can't see", func(t *testing.T) {
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		draft := usecase.MockDrafts[0]
+		if err := bookmarkUseCase.Add(t.Context(), "another-user", draft.Id); !errors.Is(err, ErrPostNotFound) {
+			t.Errorf("Add should return ErrPostNotFound for a draft. Got: %v", err)
+		}
+	})
+
+	t.Run("Should mark bookmarked posts", func(t *testing.T) {
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		if err := bookmarkUseCase.Remove(t.Context(), userId, usecase.MockPosts[1].Id); err != nil {
+			t.Errorf("Remove should not return an error. Error: %v", err)
+		}
+
+		posts := slices.Clone(usecase.MockPosts)
+		if err := bookmarkUseCase.LoadBookmarked(t.Context(), userId, posts); err != nil {
+			t.Errorf("LoadBookmarked should not return an error. Error: %v", err)
+		}
+		if !posts[0].Bookmarked || posts[1].Bookmarked || !posts[2].Bookmarked {
+			t.Errorf("LoadBookmarked should mark only bookmarked posts. Got: %v", posts)
+		}
+	})
+
+	t.Run("Should remove bookmarks when a post is deleted", func(t *testing.T) {
+		posts := usecase.MockPosts
+		usecase.MockPosts = append(slices.Clone(posts), entity.Post{Id: 30, AuthorId: userId, Status: entity.PostPublished})
+		defer func() { usecase.MockPosts = posts }()
+
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		_ = bookmarkUseCase.Add(t.Context(), userId, 30)
+		_ = bookmarkUseCase.AddToCollection(t.Context(), userId, 1, 30)
+		if err := NewPostUseCase(usecase.NewMockPostRepository()).Delete(t.Context(), 30, userId); err != nil {
+			t.Errorf("Delete should not return an error. Error: %v", err)
+		}
+		if slices.Contains(usecase.MockBookmarks[userId], 30) || slices.Contains(usecase.MockCollectionPosts[1], 30) {
+			t.Errorf("Delete should remove bookmarks of the post. Bookmarks: %v", usecase.MockBookmarks[userId])
+		}
+	})
+}
+
+func TestCollections(t *testing.T) {
+	owner := usecase.MockCollections[0].OwnerId
+	defer func() { usecase.MockCollectionPosts = map[uint64][]uint64{} }()
+
+	t.Run("Should create a collection", func(t *testing.T) {
+		collection := entity.Collection{OwnerId: owner, Name: "  Read later "}
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		if err := bookmarkUseCase.CreateCollection(t.Context(), &collection); err != nil {
+			t.Errorf("CreateCollection should not return an error. Error: %v", err)
+		}
+		if collection.Id != usecase.NEW_COLLECTION_ID || collection.Name != "Read later" {
+			t.Errorf("CreateCollection should set id and trim name. Got: %v", collection)
+		}
+	})
+
+	t.Run("Should not create a collection without name", func(t *testing.T) {
+		collection := entity.Collection{OwnerId: owner, Name: "  "}
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		var ecv *errorType.ErrorCollectionValidation
+		if err := bookmarkUseCase.CreateCollection(t.Context(), &collection); !errors.As(err, &ecv) {
+			t.Errorf("CreateCollection should return ErrorCollectionValidation. Got: %v", err)
+		}
+	})
+
+	t.Run("Should add and list posts of a collection", func(t *testing.T) {
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		if err := bookmarkUseCase.AddToCollection(t.Context(), owner, 1, usecase.MockPosts[1].Id); err != nil {
+			t.Errorf("AddToCollection should not return an error. Error: %v", err)
+		}
+
+		collection, posts, err := bookmarkUseCase.GetCollection(t.Context(), owner, 1, 1, 10)
+		if err != nil || collection.Posts != 1 || len(posts) != 1 || posts[0].Id != usecase.MockPosts[1].Id {
+			t.Errorf("GetCollection should return the collection posts. Got: %v %v. Error: %v", collection, posts, err)
+		}
+	})
+
+	t.Run("Should hide collections from other users", func(t *testing.T) {
+		bookmarkUseCase := NewBookmarkUseCase(usecase.NewMockBookmarkRepository(), usecase.NewMockPostRepository())
+		if _, _, err := bookmarkUseCase.GetCollection(t.Context(), "another-user", 1, 1, 10); !errors.Is(err, ErrCollectionNotFound) {
+			t.Errorf("GetCollection should return ErrCollectionNotFound for another user. Got: %v", err)
+		}
+		if err := bookmarkUseCase.AddToCollection(t.Context(), "another-user", 1, usecase.MockPosts[0].Id); !errors.Is(err, ErrCollectionNotFound) {
+			t.Errorf("AddToCollection should return ErrCollectionNotFound for another user. Got: %v", err)
+		}
+	})
+}
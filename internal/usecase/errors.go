@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"errors"
+	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
+)
+
+// newNotFound returns an error telling what wasn't found, which is also a repository.ErrNotFound.
+func newNotFound(code, message string) *errorType.CodedError {
+	return &errorType.CodedError{Code: code, Message: message, Err: repository.ErrNotFound}
+}
+
+// notFound replaces repository.ErrNotFound with notFoundErr, which tells what wasn't found.
+func notFound(err error, notFoundErr error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFoundErr
+	}
+
+	return err
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"github.com/edigar/socialnets-api/internal/entity"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// rankedFeedWindow is how far back the candidates of the ranked feed go.
+	rankedFeedWindow = 3 * 24 * time.Hour
+	// rankedFeedPoolSize is how many candidates are scored, at most, to build the ranked feed.
+	rankedFeedPoolSize = 500
+	// maxAuthorRun is how many posts of the same author may follow each other on the ranked feed.
+	maxAuthorRun = 2
+)
+
+// FeedScorer scores a candidate of the ranked feed at a given time. Higher scores come first.
+type FeedScorer interface {
+	Score(candidate entity.FeedCandidate, now time.Time) float64
+}
+
+// EngagementScorer is the default FeedScorer: engagement and author affinity, log scaled so a few viral posts or
+// a single close friend don't take over the feed, decayed by the age of the post. There are no replies nor reposts
+// yet, so bookmarks stand in for them as the engagement beyond likes, and for affinity along poll votes and mentions.
+type EngagementScorer struct {
+	// HalfLife is the age at which the score of a post is halved.
+	HalfLife time.Duration
+	// BookmarkWeight is how many likes a bookmark is worth.
+	BookmarkWeight float64
+	// AffinityWeight is how much the interactions of the user with the author boost the score.
+	AffinityWeight float64
+}
+
+func NewEngagementScorer() EngagementScorer {
+	return EngagementScorer{
+		HalfLife:       12 * time.Hour,
+		BookmarkWeight: 2,
+		AffinityWeight: 0.5,
+	}
+}
+
+func (s EngagementScorer) Score(candidate entity.FeedCandidate, now time.Time) float64 {
+	published := candidate.Post.CreatedAt
+	if candidate.Post.PublishedAt != nil {
+		published = *candidate.Post.PublishedAt
+	}
+	age := max(now.Sub(published), 0)
+	recency := math.Pow(0.5, age.Hours()/s.HalfLife.Hours())
+
+	engagement := float64(candidate.Post.Likes) + s.BookmarkWeight*float64(candidate.Bookmarks)
+	affinity := s.AffinityWeight * math.Log1p(float64(candidate.Interactions))
+
+	return recency * (1 + math.Log1p(engagement)) * (1 + affinity)
+}
+
+// rankFeed orders candidates by score, newest first on ties, without more than maxAuthorRun posts of the same
+// author in a row while posts of other authors are left.
+func rankFeed(candidates []entity.FeedCandidate, scorer FeedScorer, now time.Time) []entity.Post {
+	scores := make(map[uint64]float64, len(candidates))
+	for _, candidate := range candidates {
+		scores[candidate.Post.Id] = scorer.Score(candidate, now)
+	}
+
+	pending := make([]entity.Post, 0, len(candidates))
+	for _, candidate := range candidates {
+		pending = append(pending, candidate.Post)
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		if scores[pending[i].Id] != scores[pending[j].Id] {
+			return scores[pending[i].Id] > scores[pending[j].Id]
+		}
+		return pending[i].Id > pending[j].Id
+	})
+
+	ranked := make([]entity.Post, 0, len(pending))
+	run := 0
+	for len(pending) > 0 {
+		next := 0
+		if run >= maxAuthorRun {
+			last := ranked[len(ranked)-1].AuthorId
+			for i, post := range pending {
+				if post.AuthorId != last {
+					next = i
+					break
+				}
+			}
+		}
+
+		post := pending[next]
+		pending = append(pending[:next], pending[next+1:]...)
+		if len(ranked) > 0 && ranked[len(ranked)-1].AuthorId == post.AuthorId {
+			run++
+		} else {
+			run = 1
+		}
+		ranked = append(ranked, post)
+	}
+
+	return ranked
+}
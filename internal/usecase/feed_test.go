@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/usecase/mock"
+	"testing"
+	"time"
+)
+
+var feedNow = time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC)
+
+func feedCandidate(id uint64, author string, age time.Duration, likes, bookmarks, interactions uint64) entity.FeedCandidate {
+	published := feedNow.Add(-age)
+	return entity.FeedCandidate{
+		Post: entity.Post{
+			Id:          id,
+			AuthorId:    author,
+			Likes:       likes,
+			PublishedAt: &published,
+		},
+		Bookmarks:    bookmarks,
+		Interactions: interactions,
+	}
+}
+
+func rankedIds(posts []entity.Post) []uint64 {
+	ids := make([]uint64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+	}
+
+	return ids
+}
+
+// likesScorer scores candidates by their likes only, to test the ranking apart from the default scorer.
+type likesScorer struct{}
+
+func (likesScorer) Score(candidate entity.FeedCandidate, now time.Time) float64 {
+	return float64(candidate.Post.Likes)
+}
+
+func TestEngagementScorer(t *testing.T) {
+	scorer := NewEngagementScorer()
+
+	t.Run("Should halve the score of a post after the half-life", func(t *testing.T) {
+		fresh := scorer.Score(feedCandidate(1, "a", 0, 10, 0, 0), feedNow)
+		old := scorer.Score(feedCandidate(2, "a", scorer.HalfLife, 10, 0, 0), feedNow)
+		if diff := fresh/2 - old; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("Score should halve after the half-life. Got: %v and %v", fresh, old)
+		}
+	})
+
+	t.Run("Should favor engagement and affinity", func(t *testing.T) {
+		base := scorer.Score(feedCandidate(1, "a", time.Hour, 0, 0, 0), feedNow)
+		liked := scorer.Score(feedCandidate(2, "a", time.Hour, 5, 0, 0), feedNow)
+		bookmarked := scorer.Score(feedCandidate(3, "a", time.Hour, 5, 1, 0), feedNow)
+		friend := scorer.Score(feedCandidate(4, "a", time.Hour, 0, 0, 10), feedNow)
+		if !(liked > base && bookmarked > liked && friend > base) {
+			t.Errorf("Score should grow with likes, bookmarks and interactions. Got: %v, %v, %v, %v",
+				base,
+				liked,
+				bookmarked,
+				friend,
+			)
+		}
+	})
+
+	t.Run("Should not boost posts published in the future", func(t *testing.T) {
+		now := scorer.Score(feedCandidate(1, "a", 0, 3, 0, 0), feedNow)
+		future := scorer.Score(feedCandidate(2, "a", -time.Hour, 3, 0, 0), feedNow)
+		if now != future {
+			t.Errorf("Score should treat future posts as just published. Got: %v and %v", now, future)
+		}
+	})
+
+	t.Run("Should rank a fresh post above a popular old one", func(t *testing.T) {
+		candidates := []entity.FeedCandidate{
+			feedCandidate(1, "a", 48*time.Hour, 100, 10, 0),
+			feedCandidate(2, "b", time.Hour, 3, 0, 0),
+			feedCandidate(3, "c", 6*time.Hour, 20, 2, 5),
+		}
+
+		ids := rankedIds(rankFeed(candidates, scorer, feedNow))
+		if ids[0] != 3 || ids[1] != 2 || ids[2] != 1 {
+			t.Errorf("rankFeed should order by score. Got: %v", ids)
+		}
+	})
+}
+
+func TestRankFeed(t *testing.T) {
+	t.Run("Should order by score and newest first on ties", func(t *testing.T) {
+		candidates := []entity.FeedCandidate{
+			feedCandidate(1, "a", 0, 5, 0, 0),
+			feedCandidate(2, "b", 0, 9, 0, 0),
+			feedCandidate(3, "c", 0, 5, 0, 0),
+		}
+
+		ids := rankedIds(rankFeed(candidates, likesScorer{}, feedNow))
+		if ids[0] != 2 || ids[1] != 3 || ids[2] != 1 {
+			t.Errorf("rankFeed should order by score, then by id. Got: %v", ids)
+		}
+	})
+
+	t.Run("Should not put more than two posts of the same author in a row", func(t *testing.T) {
+		candidates := []entity.FeedCandidate{
+			feedCandidate(1, "a", 0, 9, 0, 0),
+			feedCandidate(2, "a", 0, 8, 0, 0),
+			feedCandidate(3, "a", 0, 7, 0, 0),
+			feedCandidate(4, "a", 0, 6, 0, 0),
+			feedCandidate(5, "b", 0, 1, 0, 0),
+		}
+
+		ids := rankedIds(rankFeed(candidates, likesScorer{}, feedNow))
+		expected := []uint64{1, 2, 5, 3, 4}
+		for i := range expected {
+			if ids[i] != expected[i] {
+				t.Errorf("rankFeed should interleave other authors. Expected: %v. Got: %v", expected, ids)
+				break
+			}
+		}
+	})
+}
+
+func TestGetRanked(t *testing.T) {
+	defer func(candidates []entity.FeedCandidate) { usecase.MockFeedCandidates = candidates }(usecase.MockFeedCandidates)
+	usecase.MockFeedCandidates = []entity.FeedCandidate{
+		feedCandidate(1, "a", 0, 1, 0, 0),
+		feedCandidate(2, "b", 0, 3, 0, 0),
+		feedCandidate(3, "c", 0, 2, 0, 0),
+	}
+
+	t.Run("Should return a page of the feed ranked by the scorer", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository()).WithScorer(likesScorer{})
+		posts, err := postUseCase.GetRanked(t.Context(), "user", 1, 2)
+		if err != nil {
+			t.Fatalf("GetRanked should not return an error. Got: %v", err)
+		}
+		ids := rankedIds(posts)
+		if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+			t.Errorf("GetRanked should return the first page ranked. Got: %v", ids)
+		}
+
+		posts, err = postUseCase.GetRanked(t.Context(), "user", 3, 2)
+		if err != nil || len(posts) != 0 {
+			t.Errorf("GetRanked should return an empty page past the end. Got: %v, %v", posts, err)
+		}
+	})
+
+	t.Run("Should return the repository error", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		posts, err := postUseCase.GetRanked(t.Context(), usecase.POST_ERROR, 1, 20)
+		if err == nil || posts != nil {
+			t.Errorf("GetRanked should return the repository error. Got: %v, %v", posts, err)
+		}
+	})
+}
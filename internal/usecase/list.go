@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/tracing"
+)
+
+var (
+	ErrListNotFound   = newNotFound("list_not_found", "list not found")
+	ErrMemberNotFound = newNotFound("member_not_found", "user not found")
+)
+
+type ListUseCase struct {
+	listRepository repository.List
+}
+
+func NewListUseCase(listRepository repository.List) *ListUseCase {
+	return &ListUseCase{
+		listRepository: listRepository,
+	}
+}
+
+func (l *ListUseCase) Create(ctx context.Context, list *entity.List) error {
+	ctx, span := tracing.Start(ctx, "ListUseCase.Create")
+	defer span.End()
+
+	err := list.Prepare()
+	if err != nil {
+		return err
+	}
+
+	list.Id, err = l.listRepository.Create(ctx, *list)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetLists returns the lists of the owner that the viewer can see.
+func (l *ListUseCase) GetLists(ctx context.Context, ownerId, viewerId string) ([]entity.List, error) {
+	ctx, span := tracing.Start(ctx, "ListUseCase.GetLists")
+	defer span.End()
+
+	lists, err := l.listRepository.FetchByOwner(ctx, ownerId, ownerId == viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+func (l *ListUseCase) GetList(ctx context.Context, listId uint64, viewerId string) (entity.List, error) {
+	ctx, span := tracing.Start(ctx, "ListUseCase.GetList")
+	defer span.End()
+
+	list, err := l.listRepository.FetchById(ctx, listId)
+	if err != nil {
+		return entity.List{}, notFound(err, ErrListNotFound)
+	}
+	if !list.VisibleTo(viewerId) {
+		return entity.List{}, ErrListNotFound
+	}
+
+	return list, nil
+}
+
+func (l *ListUseCase) Update(ctx context.Context, ownerId string, listId uint64, list entity.List) error {
+	ctx, span := tracing.Start(ctx, "ListUseCase.Update")
+	defer span.End()
+
+	if err := list.Prepare(); err != nil {
+		return err
+	}
+	if _, err := l.ownList(ctx, ownerId, listId); err != nil {
+		return err
+	}
+
+	return l.listRepository.Update(ctx, listId, list)
+}
+
+func (l *ListUseCase) Delete(ctx context.Context, ownerId string, listId uint64) error {
+	ctx, span := tracing.Start(ctx, "ListUseCase.Delete")
+	defer span.End()
+
+	if _, err := l.ownList(ctx, ownerId, listId); err != nil {
+		return err
+	}
+
+	return l.listRepository.Delete(ctx, listId)
+}
+
+func (l *ListUseCase) AddMember(ctx context.Context, ownerId string, listId uint64, userId string) error {
+	ctx, span := tracing.Start(ctx, "ListUseCase.AddMember")
+	defer span.End()
+
+	if _, err := l.ownList(ctx, ownerId, listId); err != nil {
+		return err
+	}
+
+	added, err := l.listRepository.AddMember(ctx, listId, userId)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+func (l *ListUseCase) RemoveMember(ctx context.Context, ownerId string, listId uint64, userId string) error {
+	ctx, span := tracing.Start(ctx, "ListUseCase.RemoveMember")
+	defer span.End()
+
+	if _, err := l.ownList(ctx, ownerId, listId); err != nil {
+		return err
+	}
+
+	return l.listRepository.RemoveMember(ctx, listId, userId)
+}
+
+func (l *ListUseCase) GetMembers(ctx context.Context, listId uint64, viewerId string) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "ListUseCase.GetMembers")
+	defer span.End()
+
+	if _, err := l.GetList(ctx, listId, viewerId); err != nil {
+		return nil, err
+	}
+
+	members, err := l.listRepository.FetchMembers(ctx, listId)
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// GetTimeline returns a page of posts written by the list members, following the home feed rules.
+func (l *ListUseCase) GetTimeline(ctx context.Context, listId uint64, viewerId string, page, limit int) ([]entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "ListUseCase.GetTimeline")
+	defer span.End()
+
+	if _, err := l.GetList(ctx, listId, viewerId); err != nil {
+		return nil, err
+	}
+
+	limit, offset := paginate(page, limit)
+	posts, err := l.listRepository.FetchTimeline(ctx, listId, viewerId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// ownList fetches a list of the owner. Lists of other users are reported as not found when private and denied
+// otherwise.
+func (l *ListUseCase) ownList(ctx context.Context, ownerId string, listId uint64) (entity.List, error) {
+	list, err := l.GetList(ctx, listId, ownerId)
+	if err != nil {
+		return entity.List{}, err
+	}
+	if list.OwnerId != ownerId {
+		return entity.List{}, ErrAccessDenied
+	}
+
+	return list, nil
+}
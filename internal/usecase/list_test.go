@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/usecase/mock"
+	"testing"
+)
+
+func TestLists(t *testing.T) {
+	owner := usecase.MockLists[0].OwnerId
+	member := usecase.MockPosts[1].AuthorId
+	defer func() { usecase.MockListMembers = map[uint64][]string{} }()
+
+	t.Run("Should create a list", func(t *testing.T) {
+		list := entity.List{OwnerId: owner, Name: " News ", Private: true}
+		listUseCase := NewListUseCase(usecase.NewMockListRepository())
+		if err := listUseCase.Create(t.Context(), &list); err != nil {
+			t.Errorf("Create should not return an error. Error: %v", err)
+		}
+		if list.Id != usecase.NEW_LIST_ID || list.Name != "News" {
+			t.Errorf("Create should set id and trim name. Got: %v", list)
+		}
+
+		var elv *errorType.ErrorListValidation
+		if err := listUseCase.Create(t.Context(), &entity.List{OwnerId: owner}); !errors.As(err, &elv) {
+			t.Errorf("Create should return ErrorListValidation without name. Got: %v", err)
+		}
+	})
+
+	t.Run("Should hide private lists from other users", func(t *testing.T) {
+		listUseCase := NewListUseCase(usecase.NewMockListRepository())
+		if lists, _ := listUseCase.GetLists(t.Context(), owner, "another-user"); len(lists) != 1 || lists[0].Private {
+			t.Errorf("GetLists should return only public lists to other users. Got: %v", lists)
+		}
+		if lists, _ := listUseCase.GetLists(t.Context(), owner, owner); len(lists) != 2 {
+			t.Errorf("GetLists should return every list to the owner. Got: %v", lists)
+		}
+		if _, err := listUseCase.GetList(t.Context(), usecase.MockLists[0].Id, "another-user"); !errors.Is(err, ErrListNotFound) {
+			t.Errorf("GetList should return ErrListNotFound for a private list. Got: %v", err)
+		}
+	})
+
+	t.Run("Should manage members and build the list timeline", func(t *testing.T) {
+		listId := usecase.MockLists[0].Id
+		listUseCase := NewListUseCase(usecase.NewMockListRepository())
+		if err := listUseCase.AddMember(t.Context(), owner, listId, member); err != nil {
+			t.Errorf("AddMember should not return an error. Error: %v", err)
+		}
+		if err := listUseCase.AddMember(t.Context(), owner, listId, "unknown-user"); !errors.Is(err, ErrMemberNotFound) {
+			t.Errorf("AddMember should return ErrMemberNotFound for an unknown user. Got: %v", err)
+		}
+
+		posts, err := listUseCase.GetTimeline(t.Context(), listId, owner, 1, 1)
+		if err != nil || len(posts) != 1 || posts[0].AuthorId != member {
+			t.Errorf("GetTimeline should return a page of posts of members. Got: %v. Error: %v", posts, err)
+		}
+
+		if err = listUseCase.RemoveMember(t.Context(), owner, listId, member); err != nil {
+			t.Errorf("RemoveMember should not return an error. Error: %v", err)
+		}
+		if posts, _ = listUseCase.GetTimeline(t.Context(), listId, owner, 1, 10); len(posts) != 0 {
+			t.Errorf("GetTimeline should not return posts of removed members. Got: %v", posts)
+		}
+	})
+
+	t.Run("Should not change someone else's list", func(t *testing.T) {
+		listUseCase := NewListUseCase(usecase.NewMockListRepository())
+		publicList := usecase.MockLists[1]
+		if err := listUseCase.AddMember(t.Context(), "another-user", publicList.Id, member); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("AddMember should return ErrAccessDenied on a public list of another user. Got: %v", err)
+		}
+		if err := listUseCase.Delete(t.Context(), "another-user", usecase.MockLists[0].Id); !errors.Is(err, ErrListNotFound) {
+			t.Errorf("Delete should return ErrListNotFound on a private list of another user. Got: %v", err)
+		}
+	})
+}
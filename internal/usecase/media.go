@@ -0,0 +1,361 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/storage"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"github.com/edigar/socialnets-api/pkg/imaging"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	thumbnailSize = 320
+	avatarSize    = 400
+	// avatarSuffix ends the keys of avatars, which are public unlike the media of posts and stories.
+	avatarSuffix = "_avatar.jpg"
+)
+
+// mediaURLLifetime is how long signed media URLs are valid at least. Expiries are rounded to it, so the URL of a blob
+// stays the same, and cacheable, for a while.
+const mediaURLLifetime = time.Hour
+
+// unattachedMediaLifetime is how long uploads wait to be attached to a post or story before they are purged.
+const unattachedMediaLifetime = 24 * time.Hour
+
+var ErrMediaTooLarge = errorType.NewCodedError("media_too_large", "media too large")
+
+type MediaUseCase struct {
+	mediaRepository repository.Media
+	blobStore       storage.BlobStore
+	maxSize         int64
+	secretKey       string
+}
+
+func NewMediaUseCase(mediaRepository repository.Media, blobStore storage.BlobStore, maxSize int64) *MediaUseCase {
+	return &MediaUseCase{
+		mediaRepository: mediaRepository,
+		blobStore:       blobStore,
+		maxSize:         maxSize,
+	}
+}
+
+// WithSigning makes media URLs signed with secretKey, which Open requires for anything but avatars. Attachments are
+// only handed out along the posts and stories the viewer can see, so their signed URLs don't outlive that check for
+// long.
+func (m *MediaUseCase) WithSigning(secretKey string) *MediaUseCase {
+	m.secretKey = secretKey
+	return m
+}
+
+func (m *MediaUseCase) Upload(ctx context.Context, ownerId string, data []byte) (entity.Attachment, error) {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.Upload")
+	defer span.End()
+
+	if int64(len(data)) > m.maxSize {
+		return entity.Attachment{}, ErrMediaTooLarge
+	}
+
+	processed, err := imaging.Process(data, thumbnailSize)
+	if err != nil {
+		return entity.Attachment{}, imageError(err)
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	attachment := entity.Attachment{
+		OwnerId:      ownerId,
+		Key:          name + extension(processed.ContentType),
+		ThumbnailKey: name + "_thumb.jpg",
+		ContentType:  processed.ContentType,
+		Size:         int64(len(processed.Data)),
+		Width:        processed.Width,
+		Height:       processed.Height,
+	}
+
+	if err = m.blobStore.Put(ctx, attachment.Key, bytes.NewReader(processed.Data), attachment.Size, attachment.ContentType); err != nil {
+		return entity.Attachment{}, err
+	}
+	thumbnail := bytes.NewReader(processed.Thumbnail)
+	if err = m.blobStore.Put(ctx, attachment.ThumbnailKey, thumbnail, thumbnail.Size(), "image/jpeg"); err != nil {
+		m.blobStore.Delete(ctx, attachment.Key)
+		return entity.Attachment{}, err
+	}
+
+	attachment.Id, err = m.mediaRepository.Create(ctx, attachment)
+	if err != nil {
+		m.removeBlobs(ctx, attachment)
+		return entity.Attachment{}, err
+	}
+	attachment.SetURLs(m.url)
+
+	return attachment, nil
+}
+
+// UploadAvatar stores a square-bounded version of an image and returns its key.
+func (m *MediaUseCase) UploadAvatar(ctx context.Context, data []byte) (string, error) {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.UploadAvatar")
+	defer span.End()
+
+	if int64(len(data)) > m.maxSize {
+		return "", ErrMediaTooLarge
+	}
+
+	processed, err := imaging.Process(data, avatarSize)
+	if err != nil {
+		return "", imageError(err)
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return "", err
+	}
+
+	key := name + avatarSuffix
+	avatar := bytes.NewReader(processed.Thumbnail)
+	if err = m.blobStore.Put(ctx, key, avatar, avatar.Size(), "image/jpeg"); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// RemoveBlob deletes a stored blob that isn't tracked as an attachment, like a replaced avatar.
+func (m *MediaUseCase) RemoveBlob(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.RemoveBlob")
+	defer span.End()
+
+	if key == "" {
+		return nil
+	}
+
+	return m.blobStore.Delete(ctx, key)
+}
+
+// CheckAvailable ensures every id is an attachment uploaded by ownerId and not yet used by another post.
+func (m *MediaUseCase) CheckAvailable(ctx context.Context, ownerId string, ids []uint64) error {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.CheckAvailable")
+	defer span.End()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	count, err := m.mediaRepository.CountAvailable(ctx, ownerId, unique(ids))
+	if err != nil {
+		return err
+	}
+	if count != len(unique(ids)) {
+		return errorType.NewErrorMediaValidation(
+			errorType.NewFieldError("attachmentIds", errorType.CodeInvalid, "attachment not found or already in use"),
+		)
+	}
+
+	return nil
+}
+
+// LoadAttachments fills Attachments on every post with a single query.
+func (m *MediaUseCase) LoadAttachments(ctx context.Context, posts []entity.Post) error {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.LoadAttachments")
+	defer span.End()
+
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIds := make([]uint64, len(posts))
+	index := make(map[uint64]int, len(posts))
+	for i, post := range posts {
+		postIds[i] = post.Id
+		index[post.Id] = i
+	}
+
+	attachments, err := m.mediaRepository.FetchByPosts(ctx, postIds)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		attachment.SetURLs(m.url)
+		i := index[*attachment.PostId]
+		posts[i].Attachments = append(posts[i].Attachments, attachment)
+	}
+
+	return nil
+}
+
+// LoadStoryAttachments fills Attachments on every story with a single query.
+func (m *MediaUseCase) LoadStoryAttachments(ctx context.Context, stories []entity.Story) error {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.LoadStoryAttachments")
+	defer span.End()
+
+	if len(stories) == 0 {
+		return nil
+	}
+
+	storyIds := make([]uint64, len(stories))
+	index := make(map[uint64]int, len(stories))
+	for i, story := range stories {
+		storyIds[i] = story.Id
+		index[story.Id] = i
+	}
+
+	attachments, err := m.mediaRepository.FetchByStories(ctx, storyIds)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		attachment.SetURLs(m.url)
+		i := index[*attachment.StoryId]
+		stories[i].Attachments = append(stories[i].Attachments, attachment)
+	}
+
+	return nil
+}
+
+// Purge removes attachments and their stored blobs. It's used after the owning post or story is deleted.
+func (m *MediaUseCase) Purge(ctx context.Context, attachments []entity.Attachment) error {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.Purge")
+	defer span.End()
+
+	var errs []error
+	for _, attachment := range attachments {
+		if err := m.removeBlobs(ctx, attachment); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := m.mediaRepository.Delete(ctx, attachment.Id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// PurgeUnattached deletes the uploads that weren't attached to a post or story within unattachedMediaLifetime, along
+// with their blobs, and returns how many were deleted. Uploads whose blobs couldn't be removed are kept for the next run.
+func (m *MediaUseCase) PurgeUnattached(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.PurgeUnattached")
+	defer span.End()
+
+	before := time.Now().Add(-unattachedMediaLifetime)
+	purged := 0
+	var errs []error
+	var afterId uint64
+	for {
+		attachments, err := m.mediaRepository.FetchUnattached(ctx, before, afterId, purgeBatchSize)
+		if err != nil || len(attachments) == 0 {
+			return purged, errors.Join(append(errs, err)...)
+		}
+		afterId = attachments[len(attachments)-1].Id
+
+		for _, attachment := range attachments {
+			err = m.mediaRepository.DeleteUnattached(ctx, attachment.Id, func() error {
+				return m.removeBlobs(ctx, attachment)
+			})
+			if errors.Is(err, repository.ErrNotFound) {
+				// Attached since it was fetched.
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("purging attachment %d: %w", attachment.Id, err))
+				continue
+			}
+			purged++
+		}
+		if len(attachments) < purgeBatchSize {
+			return purged, errors.Join(errs...)
+		}
+	}
+}
+
+// Open returns the blob of key. Blobs other than avatars require the signature and expiry of their URL.
+func (m *MediaUseCase) Open(ctx context.Context, key string, expires int64, signature string) (io.ReadCloser, string, error) {
+	ctx, span := tracing.Start(ctx, "MediaUseCase.Open")
+	defer span.End()
+
+	if !strings.HasSuffix(key, avatarSuffix) {
+		if err := storage.Verify(m.secretKey, key, expires, signature); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return m.blobStore.Get(ctx, key)
+}
+
+// url returns the URL of the blob of key, signed when media is.
+func (m *MediaUseCase) url(key string) string {
+	if m.secretKey == "" {
+		return "/media/" + key
+	}
+
+	expires := time.Now().Truncate(mediaURLLifetime).Add(2 * mediaURLLifetime).Unix()
+
+	return fmt.Sprintf("/media/%s?expires=%d&signature=%s", key, expires, storage.Sign(m.secretKey, key, expires))
+}
+
+func (m *MediaUseCase) removeBlobs(ctx context.Context, attachment entity.Attachment) error {
+	return errors.Join(
+		m.blobStore.Delete(ctx, attachment.Key),
+		m.blobStore.Delete(ctx, attachment.ThumbnailKey),
+	)
+}
+
+func imageError(err error) error {
+	if errors.Is(err, imaging.ErrUnsupportedType) {
+		return errorType.NewErrorMediaValidation(
+			errorType.NewFieldError("file", errorType.CodeNotAllowed, "only jpeg, png and gif images are allowed"),
+		)
+	}
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return errorType.NewErrorMediaValidation(errorType.NewFieldError("file", errorType.CodeTooLong, "image dimensions are too large"))
+	}
+
+	return errorType.NewErrorMediaValidation(errorType.NewFieldError("file", errorType.CodeInvalid, "invalid image"))
+}
+
+func randomName() (string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(name), nil
+}
+
+func extension(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".jpg"
+	}
+}
+
+func unique(ids []uint64) []uint64 {
+	seen := make(map[uint64]bool, len(ids))
+	var result []uint64
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
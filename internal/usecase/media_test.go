@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/storage"
+	"github.com/edigar/socialnets-api/internal/usecase/mock"
+	"image"
+	"image/png"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func pngImage(width, height int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+
+	return buf.Bytes()
+}
+
+func TestUpload(t *testing.T) {
+	t.Run("Should store image and thumbnail", func(t *testing.T) {
+		store := usecase.NewMockBlobStore()
+		mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), store, 1<<20)
+		attachment, err := mediaUseCase.Upload(context.Background(), usecase.MockUsers[0].Id, pngImage(640, 480))
+		if err != nil {
+			t.Fatalf("Upload should not return an error for a valid image. Error: %v", err)
+		}
+
+		if attachment.Id != usecase.NEW_ATTACHMENT_ID {
+			t.Errorf("Upload should set attachment id. Expected: %v. Got: %v", usecase.NEW_ATTACHMENT_ID, attachment.Id)
+		}
+		if attachment.ContentType != "image/png" || attachment.Width != 640 || attachment.Height != 480 {
+			t.Errorf("Upload should set content type and dimensions. Got: %v", attachment)
+		}
+		if _, ok := store.Blobs[attachment.Key]; !ok {
+			t.Errorf("Upload should store image on blob store. Key: %v", attachment.Key)
+		}
+		if _, ok := store.Blobs[attachment.ThumbnailKey]; !ok {
+			t.Errorf("Upload should store thumbnail on blob store. Key: %v", attachment.ThumbnailKey)
+		}
+		if attachment.URL != "/media/"+attachment.Key {
+			t.Errorf("Upload should set attachment url. Got: %v", attachment.URL)
+		}
+	})
+
+	t.Run("Should reject content bigger than max size", func(t *testing.T) {
+		mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), usecase.NewMockBlobStore(), 10)
+		_, err := mediaUseCase.Upload(context.Background(), usecase.MockUsers[0].Id, pngImage(10, 10))
+		if !errors.Is(err, ErrMediaTooLarge) {
+			t.Errorf("Upload should return ErrMediaTooLarge. Got: %v", err)
+		}
+	})
+
+	t.Run("Should reject non image content", func(t *testing.T) {
+		store := usecase.NewMockBlobStore()
+		mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), store, 1<<20)
+		_, err := mediaUseCase.Upload(context.Background(), usecase.MockUsers[0].Id, []byte("%PDF-1.4 not an image"))
+		var emv *errorType.ErrorMediaValidation
+		if !errors.As(err, &emv) {
+			t.Errorf("Upload should return ErrorMediaValidation for non image content. Got: %v", err)
+		}
+		if len(store.Blobs) != 0 {
+			t.Errorf("Upload should not store anything for invalid content. Blobs: %v", len(store.Blobs))
+		}
+	})
+}
+
+func TestCheckAvailable(t *testing.T) {
+	mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), usecase.NewMockBlobStore(), 1<<20)
+
+	t.Run("Should accept owned and unattached media", func(t *testing.T) {
+		if err := mediaUseCase.CheckAvailable(t.Context(), usecase.MockUsers[0].Id, []uint64{2, 2}); err != nil {
+			t.Errorf("CheckAvailable should not return an error for available media. Error: %v", err)
+		}
+	})
+
+	t.Run("Should reject media attached to another post or owned by someone else", func(t *testing.T) {
+		scenarios := []struct {
+			ownerId string
+			ids     []uint64
+		}{
+			{usecase.MockUsers[0].Id, []uint64{1}},
+			{usecase.MockUsers[1].Id, []uint64{2}},
+			{usecase.MockUsers[0].Id, []uint64{2, 99}},
+		}
+
+		for _, scenario := range scenarios {
+			err := mediaUseCase.CheckAvailable(t.Context(), scenario.ownerId, scenario.ids)
+			var emv *errorType.ErrorMediaValidation
+			if !errors.As(err, &emv) {
+				t.Errorf("CheckAvailable should return ErrorMediaValidation. Scenario: %v. Got: %v", scenario, err)
+			}
+		}
+	})
+}
+
+func TestLoadAttachments(t *testing.T) {
+	t.Run("Should fill attachments of each post", func(t *testing.T) {
+		mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), usecase.NewMockBlobStore(), 1<<20)
+		posts := []entity.Post{{Id: 1}, {Id: 2}}
+		if err := mediaUseCase.LoadAttachments(t.Context(), posts); err != nil {
+			t.Fatalf("LoadAttachments should not return an error. Error: %v", err)
+		}
+
+		if len(posts[0].Attachments) != 1 || posts[0].Attachments[0].URL != "/media/1.jpg" {
+			t.Errorf("LoadAttachments should set attachments of post 1. Got: %v", posts[0].Attachments)
+		}
+		if len(posts[1].Attachments) != 0 {
+			t.Errorf("LoadAttachments should not set attachments of post 2. Got: %v", posts[1].Attachments)
+		}
+	})
+}
+
+func TestPurge(t *testing.T) {
+	t.Run("Should delete blobs and attachments", func(t *testing.T) {
+		originalAttachments := append([]entity.Attachment(nil), usecase.MockAttachments...)
+		store := usecase.NewMockBlobStore()
+		store.Blobs["1.jpg"] = []byte("a")
+		store.Blobs["1_thumb.jpg"] = []byte("b")
+
+		mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), store, 1<<20)
+		if err := mediaUseCase.Purge(context.Background(), usecase.MockAttachments[:1]); err != nil {
+			t.Errorf("Purge should not return an error. Error: %v", err)
+		}
+		if len(store.Blobs) != 0 {
+			t.Errorf("Purge should delete blobs. Blobs left: %v", len(store.Blobs))
+		}
+		if len(usecase.MockAttachments) != 1 {
+			t.Errorf("Purge should delete attachment rows. Attachments: %v", usecase.MockAttachments)
+		}
+
+		usecase.MockAttachments = originalAttachments
+	})
+}
+
+func TestOpen(t *testing.T) {
+	secretKey := "0123456789abcdef0123456789abcdef"
+	store := usecase.NewMockBlobStore()
+	store.Blobs["1.jpg"] = []byte("image")
+	store.Blobs["a"+avatarSuffix] = []byte("avatar")
+	mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), store, 1<<20).WithSigning(secretKey)
+
+	t.Run("Should open media through its signed URL", func(t *testing.T) {
+		posts := []entity.Post{{Id: 1}}
+		if err := mediaUseCase.LoadAttachments(t.Context(), posts); err != nil {
+			t.Fatalf("LoadAttachments should not return an error. Error: %v", err)
+		}
+		mediaURL, err := url.Parse(posts[0].Attachments[0].URL)
+		if err != nil || mediaURL.Path != "/media/1.jpg" {
+			t.Fatalf("LoadAttachments should set a signed url. Got: %v", posts[0].Attachments[0].URL)
+		}
+
+		expires, _ := strconv.ParseInt(mediaURL.Query().Get("expires"), 10, 64)
+		blob, _, err := mediaUseCase.Open(t.Context(), "1.jpg", expires, mediaURL.Query().Get("signature"))
+		if err != nil {
+			t.Fatalf("Open should accept the signed url. Error: %v", err)
+		}
+		data, _ := io.ReadAll(blob)
+		blob.Close()
+		if string(data) != "image" {
+			t.Errorf("Open should return the blob. Got: %v", string(data))
+		}
+		if _, _, err = mediaUseCase.Open(t.Context(), "1_thumb.jpg", expires, mediaURL.Query().Get("signature")); !errors.Is(err, storage.ErrInvalidSignature) {
+			t.Errorf("Open should reject the signature of another blob. Got: %v", err)
+		}
+	})
+
+	t.Run("Should reject media without signature", func(t *testing.T) {
+		if _, _, err := mediaUseCase.Open(t.Context(), "1.jpg", 0, ""); !errors.Is(err, storage.ErrInvalidSignature) {
+			t.Errorf("Open should reject an unsigned url. Got: %v", err)
+		}
+		expired := time.Now().Add(-time.Minute).Unix()
+		signature := storage.Sign(secretKey, "1.jpg", expired)
+		if _, _, err := mediaUseCase.Open(t.Context(), "1.jpg", expired, signature); !errors.Is(err, storage.ErrInvalidSignature) {
+			t.Errorf("Open should reject an expired url. Got: %v", err)
+		}
+	})
+
+	t.Run("Should open avatars without signature", func(t *testing.T) {
+		blob, _, err := mediaUseCase.Open(t.Context(), "a"+avatarSuffix, 0, "")
+		if err != nil {
+			t.Fatalf("Open should not require a signature for avatars. Error: %v", err)
+		}
+		blob.Close()
+	})
+}
+
+func TestPurgeUnattached(t *testing.T) {
+	originalAttachments := usecase.MockAttachments
+	defer func() { usecase.MockAttachments = originalAttachments }()
+	owner := usecase.MockUsers[0].Id
+	usecase.MockAttachments = append(slices.Clone(originalAttachments),
+		entity.Attachment{Id: 10, OwnerId: owner, Key: "10.jpg", ThumbnailKey: "10_thumb.jpg", CreatedAt: time.Now().Add(-48 * time.Hour)},
+		entity.Attachment{Id: 11, OwnerId: owner, Key: "11.jpg", ThumbnailKey: "11_thumb.jpg", CreatedAt: time.Now().Add(-48 * time.Hour)},
+		entity.Attachment{Id: 12, OwnerId: owner, Key: "12.jpg", ThumbnailKey: "12_thumb.jpg", CreatedAt: time.Now()},
+	)
+	store := usecase.NewMockBlobStore()
+	for _, attachment := range usecase.MockAttachments {
+		store.Blobs[attachment.Key] = []byte("image")
+		store.Blobs[attachment.ThumbnailKey] = []byte("thumbnail")
+	}
+	store.DeleteErrors = map[string]error{"11.jpg": errors.New("storage unavailable")}
+
+	mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), store, 1<<20)
+	purged, err := mediaUseCase.PurgeUnattached(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "storage unavailable") {
+		t.Errorf("PurgeUnattached should return the failures. Got: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("PurgeUnattached should count the purged uploads. Got: %v", purged)
+	}
+
+	var ids []uint64
+	for _, attachment := range usecase.MockAttachments {
+		ids = append(ids, attachment.Id)
+	}
+	if !slices.Equal(ids, []uint64{1, 11, 12}) {
+		t.Errorf("PurgeUnattached should delete only old unattached uploads whose blobs were removed. Left: %v", ids)
+	}
+	if _, ok := store.Blobs["10.jpg"]; ok {
+		t.Errorf("PurgeUnattached should delete the blobs of purged uploads")
+	}
+}
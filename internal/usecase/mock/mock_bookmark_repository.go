@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"slices"
+)
+
+type MockBookmarkRepository struct{}
+
+func NewMockBookmarkRepository() *MockBookmarkRepository {
+	return &MockBookmarkRepository{}
+}
+
+const NEW_COLLECTION_ID = 2
+
+// MockBookmarks maps users to the ids of the posts they bookmarked, most recent first.
+var MockBookmarks = map[string][]uint64{}
+
+var MockCollections = []entity.Collection{
+	{
+		Id:      1,
+		OwnerId: "93226a19-86d6-4ad7-a215-d5999c2870c4",
+		Name:    "Favorites",
+	},
+}
+
+// MockCollectionPosts maps collections to the ids of their posts, most recent first.
+var MockCollectionPosts = map[uint64][]uint64{}
+
+func (mr MockBookmarkRepository) Add(_ context.Context, userId string, postId uint64) error {
+	if !slices.Contains(MockBookmarks[userId], postId) {
+		MockBookmarks[userId] = append([]uint64{postId}, MockBookmarks[userId]...)
+	}
+
+	return nil
+}
+
+func (mr MockBookmarkRepository) Remove(_ context.Context, userId string, postId uint64) error {
+	MockBookmarks[userId] = slices.DeleteFunc(MockBookmarks[userId], func(id uint64) bool { return id == postId })
+
+	return nil
+}
+
+func (mr MockBookmarkRepository) Fetch(_ context.Context, userId string, limit, offset int) ([]entity.Post, error) {
+	if userId == POST_ERROR {
+		return nil, errors.New("driver: bad connection")
+	}
+
+	return page(MockBookmarks[userId], limit, offset), nil
+}
+
+func (mr MockBookmarkRepository) FetchBookmarked(_ context.Context, userId string, postIds []uint64) ([]uint64, error) {
+	var bookmarked []uint64
+	for _, postId := range MockBookmarks[userId] {
+		if slices.Contains(postIds, postId) {
+			bookmarked = append(bookmarked, postId)
+		}
+	}
+
+	return bookmarked, nil
+}
+
+func (mr MockBookmarkRepository) CreateCollection(_ context.Context, collection entity.Collection) (uint64, error) {
+	return NEW_COLLECTION_ID, nil
+}
+
+func (mr MockBookmarkRepository) FetchCollections(_ context.Context, userId string) ([]entity.Collection, error) {
+	var collections []entity.Collection
+	for _, collection := range MockCollections {
+		if collection.OwnerId == userId {
+			collections = append(collections, collection)
+		}
+	}
+
+	return collections, nil
+}
+
+func (mr MockBookmarkRepository) FetchCollection(_ context.Context, collectionId uint64) (entity.Collection, error) {
+	for _, collection := range MockCollections {
+		if collection.Id == collectionId {
+			collection.Posts = uint64(len(MockCollectionPosts[collectionId]))
+			return collection, nil
+		}
+	}
+
+	return entity.Collection{}, repository.ErrNotFound
+}
+
+func (mr MockBookmarkRepository) DeleteCollection(_ context.Context, collectionId uint64) error {
+	MockCollections = slices.DeleteFunc(MockCollections, func(collection entity.Collection) bool {
+		return collection.Id == collectionId
+	})
+	delete(MockCollectionPosts, collectionId)
+
+	return nil
+}
+
+func (mr MockBookmarkRepository) AddToCollection(_ context.Context, collectionId, postId uint64) error {
+	if !slices.Contains(MockCollectionPosts[collectionId], postId) {
+		MockCollectionPosts[collectionId] = append([]uint64{postId}, MockCollectionPosts[collectionId]...)
+	}
+
+	return nil
+}
+
+func (mr MockBookmarkRepository) RemoveFromCollection(_ context.Context, collectionId, postId uint64) error {
+	MockCollectionPosts[collectionId] = slices.DeleteFunc(
+		MockCollectionPosts[collectionId],
+		func(id uint64) bool { return id == postId },
+	)
+
+	return nil
+}
+
+func (mr MockBookmarkRepository) FetchCollectionPosts(
+	_ context.Context,
+	collectionId uint64,
+	viewerId string,
+	limit, offset int,
+) ([]entity.Post, error) {
+	return page(MockCollectionPosts[collectionId], limit, offset), nil
+}
+
+// page returns the MockPosts with the given ids, paginated.
+func page(postIds []uint64, limit, offset int) []entity.Post {
+	var posts []entity.Post
+	for _, postId := range postIds {
+		for _, post := range MockPosts {
+			if post.Id == postId {
+				posts = append(posts, post)
+			}
+		}
+	}
+	if offset >= len(posts) {
+		return nil
+	}
+
+	return posts[offset:min(offset+limit, len(posts))]
+}
+
+// forgetPost removes a deleted post from bookmarks and collections.
+func forgetPost(postId uint64) {
+	for userId, postIds := range MockBookmarks {
+		MockBookmarks[userId] = slices.DeleteFunc(postIds, func(id uint64) bool { return id == postId })
+	}
+	for collectionId, postIds := range MockCollectionPosts {
+		MockCollectionPosts[collectionId] = slices.DeleteFunc(postIds, func(id uint64) bool { return id == postId })
+	}
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"slices"
+)
+
+type MockListRepository struct{}
+
+func NewMockListRepository() *MockListRepository {
+	return &MockListRepository{}
+}
+
+const NEW_LIST_ID = 3
+
+var MockLists = []entity.List{
+	{
+		Id:      1,
+		OwnerId: "93226a19-86d6-4ad7-a215-d5999c2870c4",
+		Name:    "Friends",
+		Private: true,
+	},
+	{
+		Id:      2,
+		OwnerId: "93226a19-86d6-4ad7-a215-d5999c2870c4",
+		Name:    "Writers",
+	},
+}
+
+// MockListMembers maps lists to the ids of their members.
+var MockListMembers = map[uint64][]string{}
+
+func (mr MockListRepository) Create(_ context.Context, list entity.List) (uint64, error) {
+	return NEW_LIST_ID, nil
+}
+
+func (mr MockListRepository) FetchById(_ context.Context, listId uint64) (entity.List, error) {
+	for _, list := range MockLists {
+		if list.Id == listId {
+			list.Members = uint64(len(MockListMembers[listId]))
+			return list, nil
+		}
+	}
+
+	return entity.List{}, repository.ErrNotFound
+}
+
+func (mr MockListRepository) FetchByOwner(_ context.Context, ownerId string, includePrivate bool) ([]entity.List, error) {
+	var lists []entity.List
+	for _, list := range MockLists {
+		if list.OwnerId == ownerId && (includePrivate || !list.Private) {
+			lists = append(lists, list)
+		}
+	}
+
+	return lists, nil
+}
+
+func (mr MockListRepository) Update(_ context.Context, listId uint64, list entity.List) error {
+	for i := range MockLists {
+		if MockLists[i].Id == listId {
+			MockLists[i].Name = list.Name
+			MockLists[i].Private = list.Private
+		}
+	}
+
+	return nil
+}
+
+func (mr MockListRepository) Delete(_ context.Context, listId uint64) error {
+	MockLists = slices.DeleteFunc(MockLists, func(list entity.List) bool { return list.Id == listId })
+	delete(MockListMembers, listId)
+
+	return nil
+}
+
+func (mr MockListRepository) AddMember(_ context.Context, listId uint64, userId string) (bool, error) {
+	known := slices.ContainsFunc(MockUsers, func(user entity.User) bool { return user.Id == userId }) ||
+		slices.ContainsFunc(MockPosts, func(post entity.Post) bool { return post.AuthorId == userId })
+	if !known {
+		return false, nil
+	}
+	if !slices.Contains(MockListMembers[listId], userId) {
+		MockListMembers[listId] = append(MockListMembers[listId], userId)
+	}
+
+	return true, nil
+}
+
+func (mr MockListRepository) RemoveMember(_ context.Context, listId uint64, userId string) error {
+	MockListMembers[listId] = slices.DeleteFunc(MockListMembers[listId], func(id string) bool { return id == userId })
+
+	return nil
+}
+
+func (mr MockListRepository) FetchMembers(_ context.Context, listId uint64) ([]entity.User, error) {
+	var users []entity.User
+	for _, userId := range MockListMembers[listId] {
+		users = append(users, entity.User{Id: userId})
+	}
+
+	return users, nil
+}
+
+func (mr MockListRepository) FetchTimeline(_ context.Context, listId uint64, viewerId string, limit, offset int) ([]entity.Post, error) {
+	var postIds []uint64
+	for _, post := range MockPosts {
+		if slices.Contains(MockListMembers[listId], post.AuthorId) && visible(post, viewerId) {
+			postIds = append(postIds, post.Id)
+		}
+	}
+
+	return page(postIds, limit, offset), nil
+}
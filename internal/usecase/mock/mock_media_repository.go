@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/storage"
+	"io"
+	"slices"
+	"time"
+)
+
+type MockMediaRepository struct{}
+
+func NewMockMediaRepository() *MockMediaRepository {
+	return &MockMediaRepository{}
+}
+
+const NEW_ATTACHMENT_ID = 3
+
+var attachedPostId = uint64(1)
+
+var MockAttachments = []entity.Attachment{
+	{
+		Id:           1,
+		PostId:       &attachedPostId,
+		OwnerId:      "93226a19-86d6-4ad7-a215-d5999c2870c4",
+		Key:          "1.jpg",
+		ThumbnailKey: "1_thumb.jpg",
+		ContentType:  "image/jpeg",
+	},
+	{
+		Id:           2,
+		OwnerId:      "93226a19-86d6-4ad7-a215-d5999c2870c4",
+		Key:          "2.png",
+		ThumbnailKey: "2_thumb.jpg",
+		ContentType:  "image/png",
+	},
+}
+
+func (mr MockMediaRepository) Create(_ context.Context, attachment entity.Attachment) (uint64, error) {
+	return NEW_ATTACHMENT_ID, nil
+}
+
+func (mr MockMediaRepository) CountAvailable(_ context.Context, ownerId string, ids []uint64) (int, error) {
+	count := 0
+	for _, attachment := range MockAttachments {
+		if attachment.OwnerId == ownerId && attachment.PostId == nil && attachment.StoryId == nil &&
+			slices.Contains(ids, attachment.Id) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (mr MockMediaRepository) FetchByPosts(_ context.Context, postIds []uint64) ([]entity.Attachment, error) {
+	var attachments []entity.Attachment
+	for _, attachment := range MockAttachments {
+		if attachment.PostId != nil && slices.Contains(postIds, *attachment.PostId) {
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	return attachments, nil
+}
+
+func (mr MockMediaRepository) FetchByStories(_ context.Context, storyIds []uint64) ([]entity.Attachment, error) {
+	var attachments []entity.Attachment
+	for _, attachment := range MockAttachments {
+		if attachment.StoryId != nil && slices.Contains(storyIds, *attachment.StoryId) {
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	return attachments, nil
+}
+
+func (mr MockMediaRepository) Delete(_ context.Context, id uint64) error {
+	for i, attachment := range MockAttachments {
+		if attachment.Id == id {
+			MockAttachments = append(MockAttachments[:i], MockAttachments[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("attachment not found")
+}
+
+func (mr MockMediaRepository) FetchUnattached(_ context.Context, before time.Time, afterId uint64, limit int) ([]entity.Attachment, error) {
+	var attachments []entity.Attachment
+	for _, attachment := range MockAttachments {
+		if attachment.PostId == nil && attachment.StoryId == nil && attachment.CreatedAt.Before(before) &&
+			attachment.Id > afterId && len(attachments) < limit {
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	return attachments, nil
+}
+
+func (mr MockMediaRepository) DeleteUnattached(_ context.Context, id uint64, remove func() error) error {
+	for i, attachment := range MockAttachments {
+		if attachment.Id == id && attachment.PostId == nil && attachment.StoryId == nil {
+			if err := remove(); err != nil {
+				return err
+			}
+			MockAttachments = append(MockAttachments[:i], MockAttachments[i+1:]...)
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+// MockBlobStore keeps blobs in memory. Deleting a key of DeleteErrors fails with its error.
+type MockBlobStore struct {
+	Blobs        map[string][]byte
+	DeleteErrors map[string]error
+}
+
+func NewMockBlobStore() *MockBlobStore {
+	return &MockBlobStore{Blobs: map[string][]byte{}}
+}
+
+func (s *MockBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.Blobs[key] = data
+
+	return nil
+}
+
+func (s *MockBlobStore) Get(_ context.Context, key string) (io.ReadCloser, string, error) {
+	data, ok := s.Blobs[key]
+	if !ok {
+		return nil, "", storage.ErrNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), "", nil
+}
+
+func (s *MockBlobStore) Delete(_ context.Context, key string) error {
+	if err := s.DeleteErrors[key]; err != nil {
+		return err
+	}
+	delete(s.Blobs, key)
+
+	return nil
+}
+
+func (s *MockBlobStore) Ping(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"slices"
+)
+
+type MockPollRepository struct{}
+
+func NewMockPollRepository() *MockPollRepository {
+	return &MockPollRepository{}
+}
+
+// MockPolls hold polls without tallies, which are computed from MockPollVotes.
+var MockPolls = []entity.Poll{}
+
+// MockPollVotes maps polls to the options chosen by each user.
+var MockPollVotes = map[uint64]map[string][]uint64{}
+
+func (mr MockPollRepository) FetchByPost(_ context.Context, postId uint64) (entity.Poll, error) {
+	for _, poll := range MockPolls {
+		if poll.PostId == postId {
+			return poll, nil
+		}
+	}
+
+	return entity.Poll{}, repository.ErrNotFound
+}
+
+func (mr MockPollRepository) FetchByPosts(_ context.Context, postIds []uint64, viewerId string) ([]entity.Poll, error) {
+	var polls []entity.Poll
+	for _, poll := range MockPolls {
+		if !slices.Contains(postIds, poll.PostId) {
+			continue
+		}
+
+		votes := MockPollVotes[poll.Id]
+		voters := uint64(len(votes))
+		poll.Voters = &voters
+		_, poll.Voted = votes[viewerId]
+		poll.Options = slices.Clone(poll.Options)
+		for i, option := range poll.Options {
+			var count uint64
+			for _, chosen := range votes {
+				if slices.Contains(chosen, option.Id) {
+					count++
+				}
+			}
+			poll.Options[i].Votes = &count
+			poll.Options[i].Chosen = slices.Contains(votes[viewerId], option.Id)
+		}
+
+		polls = append(polls, poll)
+	}
+
+	return polls, nil
+}
+
+func (mr MockPollRepository) Vote(_ context.Context, pollId uint64, userId string, optionIds []uint64) error {
+	if MockPollVotes[pollId] == nil {
+		MockPollVotes[pollId] = map[string][]uint64{}
+	}
+	MockPollVotes[pollId][userId] = optionIds
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package usecase
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"slices"
+	"time"
 )
 
 type MockPostRepository struct{}
@@ -22,6 +25,7 @@ var MockPosts = []entity.Post{
 		Content:  "Content 1",
 		AuthorId: "93226a19-86d6-4ad7-a215-d5999c2870c4",
 		Likes:    0,
+		Status:   entity.PostPublished,
 	},
 	{
 		Id:       2,
@@ -29,6 +33,7 @@ var MockPosts = []entity.Post{
 		Content:  "Content 2",
 		AuthorId: "d9b56fd4-31b7-4bd5-958f-99028ca5e79a",
 		Likes:    0,
+		Status:   entity.PostPublished,
 	},
 	{
 		Id:       3,
@@ -36,43 +41,87 @@ var MockPosts = []entity.Post{
 		Content:  "Content 3",
 		AuthorId: "d9b56fd4-31b7-4bd5-958f-99028ca5e79a",
 		Likes:    0,
+		Status:   entity.PostPublished,
 	},
 }
 
-func (mr MockPostRepository) Create(post entity.Post) (uint64, error) {
+func (mr MockPostRepository) Create(_ context.Context, post entity.Post) (uint64, error) {
 	return NEW_POST_ID, nil
 }
 
-func (mr MockPostRepository) FetchById(postId uint64) (entity.Post, error) {
-	for _, post := range MockPosts {
+func (mr MockPostRepository) FetchById(_ context.Context, postId uint64, viewerId string) (entity.Post, error) {
+	for _, post := range append(MockPosts, MockDrafts...) {
 		if post.Id == postId {
+			if !visible(post, viewerId) {
+				return entity.Post{}, repository.ErrNotFound
+			}
 			return post, nil
 		}
 	}
 
-	return entity.Post{}, sql.ErrNoRows
+	return entity.Post{}, repository.ErrNotFound
+}
+
+// MockPostFollowers maps authors to their approved followers, for posts visibility.
+var MockPostFollowers = map[string][]string{}
+
+// MockMentionedIds maps posts to the ids of the users mentioned on them.
+var MockMentionedIds = map[uint64][]string{}
+
+func visible(post entity.Post, viewerId string) bool {
+	if post.AuthorId == viewerId || post.Visibility == "" || post.Visibility == entity.VisibilityPublic {
+		return true
+	}
+	if slices.Contains(MockMentionedIds[post.Id], viewerId) {
+		return true
+	}
+
+	return post.Visibility == entity.VisibilityFollowers && slices.Contains(MockPostFollowers[post.AuthorId], viewerId)
 }
 
-func (mr MockPostRepository) FetchByUser(userId string) ([]entity.Post, error) {
+func (mr MockPostRepository) FetchByUser(_ context.Context, userId string, limit, offset int) ([]entity.Post, error) {
 	if userId == POST_ERROR {
 		return nil, errors.New("driver: bad connection")
 	}
 
-	return MockPosts, nil
+	return page(homeFeed(userId), limit, offset), nil
 }
 
-func (mr MockPostRepository) Update(postId uint64, post entity.Post) error {
+// MockFeedCandidates are the candidates of the ranked feed returned by FetchFeedCandidates.
+var MockFeedCandidates []entity.FeedCandidate
+
+func (mr MockPostRepository) FetchFeedCandidates(_ context.Context, userId string, since time.Time, limit int) ([]entity.FeedCandidate, error) {
+	if userId == POST_ERROR {
+		return nil, errors.New("driver: bad connection")
+	}
+
+	return MockFeedCandidates, nil
+}
+
+func (mr MockPostRepository) Update(_ context.Context, postId uint64, post entity.Post) error {
 	for i, mockPost := range MockPosts {
 		if mockPost.Id == postId {
+			if mockPost.Status == entity.PostPublished && (mockPost.Title != post.Title || mockPost.Content != post.Content) {
+				MockRevisions[postId] = append([]entity.Revision{{
+					Id:      uint64(len(MockRevisions[postId]) + 1),
+					PostId:  postId,
+					Title:   mockPost.Title,
+					Content: mockPost.Content,
+				}}, MockRevisions[postId]...)
+			}
 			MockPosts[i].Title = post.Title
 			MockPosts[i].Content = post.Content
+			MockPosts[i].Status = post.Status
+			MockPosts[i].Visibility = post.Visibility
+			MockPosts[i].ContentWarning = post.ContentWarning
+			MockPosts[i].Sensitive = post.Sensitive
 		}
 	}
 
 	return nil
 }
 
-func (r MockPostRepository) Delete(postId uint64) error {
+func (r MockPostRepository) Delete(_ context.Context, postId uint64) error {
 	index := 99
 	for i, post := range MockPosts {
 		if postId == post.Id {
@@ -82,28 +131,127 @@ func (r MockPostRepository) Delete(postId uint64) error {
 
 	if index != 99 {
 		MockPosts = append(MockPosts[:index], MockPosts[index+1:]...)
+		forgetPost(postId)
 		return nil
 	}
 
-	return sql.ErrNoRows
+	return repository.ErrNotFound
 }
 
-func (mr MockPostRepository) FetchUserPosts(userId string) ([]entity.Post, error) {
+func (mr MockPostRepository) FetchUserPosts(_ context.Context, userId, viewerId string) ([]entity.Post, error) {
 	if userId == POST_ERROR {
 		return nil, errors.New("driver: bad connection")
 	}
 
 	var posts []entity.Post
 	for _, post := range MockPosts {
-		if post.AuthorId == userId {
+		if post.AuthorId == userId && visible(post, viewerId) {
 			posts = append(posts, post)
 		}
 	}
+	slices.SortStableFunc(posts, func(a, b entity.Post) int {
+		if a.Pinned == b.Pinned {
+			return 0
+		}
+		if a.Pinned {
+			return -1
+		}
+		return 1
+	})
 
 	return posts, nil
 }
 
-func (mr MockPostRepository) LikePost(postId uint64) error {
+var MockDrafts = []entity.Post{
+	{
+		Id:       10,
+		Title:    "Draft",
+		Content:  "Draft content",
+		AuthorId: "93226a19-86d6-4ad7-a215-d5999c2870c4",
+		Status:   entity.PostDraft,
+	},
+}
+
+// MockScheduledDue is how many scheduled posts are due to be published.
+var MockScheduledDue = 0
+
+func (mr MockPostRepository) FetchDrafts(_ context.Context, authorId string) ([]entity.Post, error) {
+	if authorId == POST_ERROR {
+		return nil, errors.New("driver: bad connection")
+	}
+
+	var posts []entity.Post
+	for _, post := range MockDrafts {
+		if post.AuthorId == authorId {
+			posts = append(posts, post)
+		}
+	}
+
+	return posts, nil
+}
+
+func (mr MockPostRepository) PublishDue(_ context.Context, limit int) ([]uint64, error) {
+	var postIds []uint64
+	for MockScheduledDue > 0 && len(postIds) < limit {
+		postIds = append(postIds, uint64(1000+MockScheduledDue))
+		MockScheduledDue--
+	}
+
+	return postIds, nil
+}
+
+// MockRevisions holds the revisions of each post, newest first.
+var MockRevisions = map[uint64][]entity.Revision{}
+
+func (mr MockPostRepository) FetchRevisions(_ context.Context, postId uint64) ([]entity.Revision, error) {
+	return MockRevisions[postId], nil
+}
+
+func (mr MockPostRepository) Pin(_ context.Context, postId uint64, authorId string, max int) (bool, error) {
+	pinned := 0
+	for _, post := range MockPosts {
+		if post.AuthorId == authorId && post.Pinned {
+			pinned++
+		}
+	}
+	for i, post := range MockPosts {
+		if post.Id == postId {
+			if !post.Pinned && pinned >= max {
+				return false, nil
+			}
+			MockPosts[i].Pinned = true
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (mr MockPostRepository) Unpin(_ context.Context, postId uint64) error {
+	for i, post := range MockPosts {
+		if post.Id == postId {
+			MockPosts[i].Pinned = false
+		}
+	}
+
+	return nil
+}
+
+func (mr MockPostRepository) SetSensitive(_ context.Context, postId uint64, sensitive bool, contentWarning string, byModerator bool) error {
+	for i, mockPost := range MockPosts {
+		if mockPost.Id == postId {
+			MockPosts[i].Sensitive = sensitive
+			MockPosts[i].ContentWarning = contentWarning
+			if byModerator {
+				MockPosts[i].SensitiveByModerator = sensitive
+			}
+		}
+	}
+
+	return nil
+}
+
+func (mr MockPostRepository) LikePost(_ context.Context, postId uint64) error {
 	for i, post := range MockPosts {
 		if postId == post.Id {
 			MockPosts[i].Likes++
@@ -111,10 +259,10 @@ func (mr MockPostRepository) LikePost(postId uint64) error {
 		}
 	}
 
-	return sql.ErrNoRows
+	return repository.ErrNotFound
 }
 
-func (mr MockPostRepository) UnlikePost(postId uint64) error {
+func (mr MockPostRepository) UnlikePost(_ context.Context, postId uint64) error {
 	for i, post := range MockPosts {
 		if postId == post.Id && post.Likes > 0 {
 			MockPosts[i].Likes--
@@ -122,5 +270,13 @@ func (mr MockPostRepository) UnlikePost(postId uint64) error {
 		}
 	}
 
-	return sql.ErrNoRows
+	return repository.ErrNotFound
+}
+
+func (mr MockPostRepository) RebuildTimelines(_ context.Context, userId string) error {
+	if userId == POST_ERROR {
+		return errors.New("driver: bad connection")
+	}
+
+	return nil
 }
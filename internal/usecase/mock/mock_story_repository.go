@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"slices"
+	"time"
+)
+
+type MockStoryRepository struct{}
+
+func NewMockStoryRepository() *MockStoryRepository {
+	return &MockStoryRepository{}
+}
+
+const NEW_STORY_ID = 10
+const STORY_ERROR = "STORY_ERROR"
+
+// MockStories are the stored stories, active or expired.
+var MockStories []entity.Story
+
+// MockStoryFollowers maps authors to the followers that can see their stories.
+var MockStoryFollowers = map[string][]string{}
+
+// MockStoryViews maps stories to the ids of their viewers.
+var MockStoryViews = map[uint64][]string{}
+
+func storyVisible(story entity.Story, viewerId string) bool {
+	if !story.ExpiresAt.After(time.Now()) {
+		return false
+	}
+
+	return story.AuthorId == viewerId || slices.Contains(MockStoryFollowers[story.AuthorId], viewerId)
+}
+
+func (mr MockStoryRepository) Create(_ context.Context, story entity.Story) (uint64, error) {
+	if story.AuthorId == STORY_ERROR {
+		return 0, errors.New("driver: bad connection")
+	}
+
+	return NEW_STORY_ID, nil
+}
+
+func (mr MockStoryRepository) FetchById(_ context.Context, storyId uint64, viewerId string) (entity.Story, error) {
+	if viewerId == STORY_ERROR {
+		return entity.Story{}, errors.New("driver: bad connection")
+	}
+	for _, story := range MockStories {
+		if story.Id == storyId && storyVisible(story, viewerId) {
+			return story, nil
+		}
+	}
+
+	return entity.Story{}, repository.ErrNotFound
+}
+
+func (mr MockStoryRepository) FetchRail(_ context.Context, viewerId string) ([]entity.Story, error) {
+	if viewerId == STORY_ERROR {
+		return nil, errors.New("driver: bad connection")
+	}
+	var stories []entity.Story
+	for _, story := range MockStories {
+		if storyVisible(story, viewerId) {
+			story.Seen = slices.Contains(MockStoryViews[story.Id], viewerId)
+			stories = append(stories, story)
+		}
+	}
+
+	return stories, nil
+}
+
+func (mr MockStoryRepository) View(_ context.Context, storyId uint64, viewerId string) error {
+	if !slices.Contains(MockStoryViews[storyId], viewerId) {
+		MockStoryViews[storyId] = append(MockStoryViews[storyId], viewerId)
+	}
+
+	return nil
+}
+
+func (mr MockStoryRepository) FetchViews(_ context.Context, storyId uint64) ([]entity.StoryView, error) {
+	var views []entity.StoryView
+	for _, viewerId := range MockStoryViews[storyId] {
+		views = append(views, entity.StoryView{ViewerId: viewerId})
+	}
+
+	return views, nil
+}
+
+func (mr MockStoryRepository) FetchExpired(_ context.Context, afterId uint64, limit int) ([]uint64, error) {
+	var storyIds []uint64
+	for _, story := range MockStories {
+		if !story.ExpiresAt.After(time.Now()) && story.Id > afterId && len(storyIds) < limit {
+			storyIds = append(storyIds, story.Id)
+		}
+	}
+
+	return storyIds, nil
+}
+
+func (mr MockStoryRepository) Delete(_ context.Context, storyIds []uint64) error {
+	MockStories = slices.DeleteFunc(MockStories, func(story entity.Story) bool {
+		return slices.Contains(storyIds, story.Id)
+	})
+	for _, storyId := range storyIds {
+		delete(MockStoryViews, storyId)
+	}
+
+	return nil
+}
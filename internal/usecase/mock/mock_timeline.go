@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"slices"
+)
+
+// MockTimelines maps users to the ids of the posts fanned out to their home timeline, newest first.
+var MockTimelines = map[string][]uint64{}
+
+// MockMergedAuthors are the authors with too many followers to be fanned out, whose posts are merged on read.
+var MockMergedAuthors = map[string]bool{}
+
+// authorPosts returns the ids of the published posts of the author.
+func authorPosts(authorId string) []uint64 {
+	var postIds []uint64
+	for _, post := range MockPosts {
+		if post.AuthorId == authorId && post.Status == entity.PostPublished {
+			postIds = append(postIds, post.Id)
+		}
+	}
+
+	return postIds
+}
+
+// addToTimeline copies the posts to the timeline of the user, skipping the ones it already has.
+func addToTimeline(userId string, postIds []uint64) {
+	for _, postId := range postIds {
+		if !slices.Contains(MockTimelines[userId], postId) {
+			MockTimelines[userId] = append(MockTimelines[userId], postId)
+		}
+	}
+	slices.SortFunc(MockTimelines[userId], newestFirst)
+}
+
+// backfill copies the posts of the author to the timeline of a new follower, unless the author is merged on read.
+func backfill(userId, authorId string) {
+	if !MockMergedAuthors[authorId] {
+		addToTimeline(userId, authorPosts(authorId))
+	}
+}
+
+// forget removes the posts of the author from the timeline of the user.
+func forget(userId, authorId string) {
+	MockTimelines[userId] = slices.DeleteFunc(MockTimelines[userId], func(postId uint64) bool {
+		return slices.Contains(authorPosts(authorId), postId)
+	})
+}
+
+// syncFanOut merges the author on read once they reach FanOutMaxFollowers, and backfills their posts to the
+// timelines of their followers when they drop below it.
+func syncFanOut(ctx context.Context, authorId string) {
+	merged := len(MockPostFollowers[authorId]) >= config.FromContext(ctx).FanOutMaxFollowers
+	if merged == MockMergedAuthors[authorId] {
+		return
+	}
+
+	if merged {
+		MockMergedAuthors[authorId] = true
+		return
+	}
+	delete(MockMergedAuthors, authorId)
+	for _, follower := range MockPostFollowers[authorId] {
+		addToTimeline(follower, authorPosts(authorId))
+	}
+}
+
+// newestFirst orders post ids from the newest post, which has the greatest id.
+func newestFirst(a, b uint64) int {
+	return cmp.Compare(b, a)
+}
+
+// homeFeed returns the ids of the posts of the home feed of the user: their timeline and the posts of the merged
+// authors they follow, newest first.
+func homeFeed(userId string) []uint64 {
+	postIds := slices.Clone(MockTimelines[userId])
+	for authorId := range MockMergedAuthors {
+		if slices.Contains(MockPostFollowers[authorId], userId) {
+			postIds = append(postIds, authorPosts(authorId)...)
+		}
+	}
+	slices.SortFunc(postIds, newestFirst)
+
+	return slices.Compact(postIds)
+}
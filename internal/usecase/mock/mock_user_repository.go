@@ -1,9 +1,11 @@
 package usecase
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"slices"
 	"strings"
 )
 
@@ -16,6 +18,9 @@ func NewMockUserRepository() *MockUserRepository {
 const NEW_USER_ID = "6705a6cd-eb7b-488b-9e94-7685c95f2707"
 const USER_ERROR = "error"
 
+// UNKNOWN_USER_ID is an id no user has, as relations to it are rejected by the database.
+const UNKNOWN_USER_ID = "00000000-0000-4000-8000-000000000000"
+
 var MockUsers = []entity.User{
 	{
 		Id:       "93226a19-86d6-4ad7-a215-d5999c2870c4",
@@ -40,45 +45,76 @@ var MockUsers = []entity.User{
 	},
 }
 
-func (mr MockUserRepository) Create(user entity.User) (string, error) {
+// Listed returns the user as listings of users return them, without their e-mail and password.
+func Listed(user entity.User) entity.User {
+	user.Email = ""
+	user.Password = ""
+
+	return user
+}
+
+func (mr MockUserRepository) Create(_ context.Context, user entity.User) (string, error) {
 	return NEW_USER_ID, nil
 }
 
-func (mr MockUserRepository) FetchByNameOrNick(nameOrNick string) ([]entity.User, error) {
+func (mr MockUserRepository) FetchByNameOrNick(_ context.Context, nameOrNick string) ([]entity.User, error) {
 	if nameOrNick == USER_ERROR {
 		return nil, errors.New("driver: bad connection")
 	}
 	var users []entity.User
 	for _, user := range MockUsers {
 		if strings.Contains(user.Name, nameOrNick) || strings.Contains(user.Nick, nameOrNick) {
-			users = append(users, user)
+			users = append(users, Listed(user))
 		}
 	}
 
 	return users, nil
 }
 
-func (mr MockUserRepository) FetchById(userId string) (entity.User, error) {
+func (mr MockUserRepository) FetchById(_ context.Context, userId string) (entity.User, error) {
 	for _, user := range MockUsers {
 		if user.Id == userId {
 			return user, nil
 		}
 	}
 
-	return entity.User{}, sql.ErrNoRows
+	return entity.User{}, repository.ErrNotFound
 }
 
-func (mr MockUserRepository) FetchByEmail(email string) (entity.User, error) {
+func (mr MockUserRepository) FetchProfile(_ context.Context, userId string) (entity.Profile, error) {
+	if userId == USER_ERROR {
+		return entity.Profile{}, errors.New("driver: bad connection")
+	}
+	for _, user := range MockUsers {
+		if user.Id == userId {
+			return entity.Profile{
+				Id:        user.Id,
+				Name:      user.Name,
+				Nick:      user.Nick,
+				Email:     user.Email,
+				Bio:       user.Bio,
+				AvatarKey: user.AvatarKey,
+				Followers: 2,
+				Following: 1,
+				Posts:     3,
+			}, nil
+		}
+	}
+
+	return entity.Profile{}, repository.ErrNotFound
+}
+
+func (mr MockUserRepository) FetchByEmail(_ context.Context, email string) (entity.User, error) {
 	for _, user := range MockUsers {
 		if user.Email == email {
 			return user, nil
 		}
 	}
 
-	return entity.User{}, sql.ErrNoRows
+	return entity.User{}, repository.ErrNotFound
 }
 
-func (mr MockUserRepository) Update(userId string, user entity.User) error {
+func (mr MockUserRepository) Update(_ context.Context, userId string, user entity.User) error {
 	if userId == USER_ERROR {
 		return errors.New("driver: bad connection")
 	}
@@ -87,13 +123,14 @@ func (mr MockUserRepository) Update(userId string, user entity.User) error {
 			MockUsers[i].Name = user.Name
 			MockUsers[i].Nick = user.Nick
 			MockUsers[i].Email = user.Email
+			MockUsers[i].ExpandSensitive = user.ExpandSensitive
 		}
 	}
 
 	return nil
 }
 
-func (mr MockUserRepository) Delete(userId string) error {
+func (mr MockUserRepository) Delete(_ context.Context, userId string) error {
 	if userId == USER_ERROR {
 		return errors.New("driver: bad connection")
 	}
@@ -101,46 +138,250 @@ func (mr MockUserRepository) Delete(userId string) error {
 	return nil
 }
 
-func (mr MockUserRepository) Follow(userId, follower string) error {
-	return errors.New("driver: bad connection")
+func (mr MockUserRepository) Follow(ctx context.Context, userId, follower string) (bool, error) {
+	if userId == USER_ERROR {
+		return false, errors.New("driver: bad connection")
+	}
+	if userId == UNKNOWN_USER_ID {
+		return false, repository.ErrNotFound
+	}
+	if slices.Contains(MockPostFollowers[userId], follower) {
+		return false, nil
+	}
+	MockPostFollowers[userId] = append(MockPostFollowers[userId], follower)
+	backfill(follower, userId)
+	syncFanOut(ctx, userId)
+
+	return true, nil
 }
 
-func (mr MockUserRepository) Unfollow(userId, follower string) error {
-	return errors.New("driver: bad connection")
+func (mr MockUserRepository) Unfollow(ctx context.Context, userId, follower string) error {
+	if userId == USER_ERROR {
+		return errors.New("driver: bad connection")
+	}
+	MockPostFollowers[userId] = slices.DeleteFunc(MockPostFollowers[userId], func(id string) bool { return id == follower })
+	forget(follower, userId)
+	syncFanOut(ctx, userId)
+
+	return nil
 }
 
-func (mr MockUserRepository) FetchFollowers(userId string) ([]entity.User, error) {
+func (mr MockUserRepository) FetchFollowers(_ context.Context, userId string) ([]entity.User, error) {
 	if userId == USER_ERROR {
 		return nil, errors.New("driver: bad connection")
 	}
 
-	return []entity.User{MockUsers[1], MockUsers[2]}, nil
+	return []entity.User{Listed(MockUsers[1]), Listed(MockUsers[2])}, nil
 }
 
-func (mr MockUserRepository) FetchFollowing(userId string) ([]entity.User, error) {
+func (mr MockUserRepository) FetchFollowing(_ context.Context, userId string) ([]entity.User, error) {
 	if userId == USER_ERROR {
 		return nil, errors.New("driver: bad connection")
 	}
 
-	return []entity.User{MockUsers[1], MockUsers[2]}, nil
+	return []entity.User{Listed(MockUsers[1]), Listed(MockUsers[2])}, nil
 }
 
-func (mr MockUserRepository) FetchPasswordById(userId string) (string, error) {
+func (mr MockUserRepository) FetchPasswordById(_ context.Context, userId string) (string, error) {
 	for _, user := range MockUsers {
 		if user.Id == userId {
 			return user.Password, nil
 		}
 	}
 
-	return "", sql.ErrNoRows
+	return "", repository.ErrNotFound
 }
 
-func (mr MockUserRepository) UpdatePassword(userId string, passwordHash string) error {
+func (mr MockUserRepository) UpdatePassword(_ context.Context, userId string, passwordHash string) error {
 	for i, user := range MockUsers {
 		if user.Id == userId {
 			MockUsers[i].Password = passwordHash
+			return nil
 		}
 	}
 
+	return repository.ErrNotFound
+}
+
+func (mr MockUserRepository) UpdateAvatar(_ context.Context, userId string, avatarKey string) (string, error) {
+	for i, user := range MockUsers {
+		if user.Id == userId {
+			previousKey := user.AvatarKey
+			MockUsers[i].AvatarKey = avatarKey
+			return previousKey, nil
+		}
+	}
+
+	return "", repository.ErrNotFound
+}
+
+var MockBlocks = map[string]string{}
+
+var MockSuggestions = []entity.Suggestion{
+	{Id: "a", Nick: "a", Mutuals: 3, FollowedBy: "beltrano"},
+	{Id: "b", Nick: "b", Mutuals: 1, FollowedBy: "john"},
+	{Id: "c", Nick: "c", Mutuals: 2, FollowedBy: "beltrano"},
+}
+
+func (mr MockUserRepository) Block(_ context.Context, userId, blocked string) error {
+	if userId == USER_ERROR {
+		return errors.New("driver: bad connection")
+	}
+	if blocked == UNKNOWN_USER_ID {
+		return repository.ErrNotFound
+	}
+	MockBlocks[userId] = blocked
+
+	return nil
+}
+
+func (mr MockUserRepository) Unblock(_ context.Context, userId, blocked string) error {
+	if userId == USER_ERROR {
+		return errors.New("driver: bad connection")
+	}
+	delete(MockBlocks, userId)
+
+	return nil
+}
+
+func (mr MockUserRepository) IsBlocked(_ context.Context, userId, otherId string) (bool, error) {
+	return MockBlocks[userId] == otherId || MockBlocks[otherId] == userId, nil
+}
+
+func (mr MockUserRepository) FetchSuggestionCandidates(_ context.Context, userId string, limit int) ([]entity.Suggestion, error) {
+	if userId == USER_ERROR {
+		return nil, errors.New("driver: bad connection")
+	}
+
+	suggestions := append([]entity.Suggestion(nil), MockSuggestions...)
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
+
+var MockMutes = map[string]string{}
+
+// MockFollowRequests maps a protected user id to the follower waiting for approval.
+var MockFollowRequests = map[string]string{}
+
+func (mr MockUserRepository) Mute(_ context.Context, userId, muted string) error {
+	if userId == USER_ERROR {
+		return errors.New("driver: bad connection")
+	}
+	if muted == UNKNOWN_USER_ID {
+		return repository.ErrNotFound
+	}
+	MockMutes[userId] = muted
+
 	return nil
 }
+
+func (mr MockUserRepository) Unmute(_ context.Context, userId, muted string) error {
+	if userId == USER_ERROR {
+		return errors.New("driver: bad connection")
+	}
+	delete(MockMutes, userId)
+
+	return nil
+}
+
+func (mr MockUserRepository) FetchFollowRequests(_ context.Context, userId string) ([]entity.User, error) {
+	if userId == USER_ERROR {
+		return nil, errors.New("driver: bad connection")
+	}
+
+	var users []entity.User
+	for _, user := range MockUsers {
+		if MockFollowRequests[userId] == user.Id {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (mr MockUserRepository) AcceptFollowRequest(_ context.Context, userId, follower string) (bool, error) {
+	if MockFollowRequests[userId] != follower {
+		return false, nil
+	}
+	delete(MockFollowRequests, userId)
+
+	return true, nil
+}
+
+func (mr MockUserRepository) RejectFollowRequest(_ context.Context, userId, follower string) (bool, error) {
+	if MockFollowRequests[userId] != follower {
+		return false, nil
+	}
+	delete(MockFollowRequests, userId)
+
+	return true, nil
+}
+
+func (mr MockUserRepository) FetchRelationships(_ context.Context, userId string, otherIds []string) ([]entity.Relationship, error) {
+	if userId == USER_ERROR {
+		return nil, errors.New("driver: bad connection")
+	}
+
+	var relationships []entity.Relationship
+	for _, id := range otherIds {
+		for _, user := range MockUsers {
+			if user.Id == id {
+				relationships = append(relationships, entity.Relationship{
+					Id:        id,
+					Following: true,
+					Pending:   MockFollowRequests[id] == userId,
+					Blocking:  MockBlocks[userId] == id,
+					BlockedBy: MockBlocks[id] == userId,
+					Muting:    MockMutes[userId] == id,
+				})
+				break
+			}
+		}
+	}
+
+	return relationships, nil
+}
+
+func (mr MockUserRepository) SetSuspended(_ context.Context, userId string, suspended bool) error {
+	if userId == USER_ERROR {
+		return errors.New("driver: bad connection")
+	}
+	for i, user := range MockUsers {
+		if user.Id == userId {
+			MockUsers[i].Suspended = suspended
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+func (mr MockUserRepository) IsSuspended(_ context.Context, userId string) (bool, error) {
+	if userId == USER_ERROR {
+		return false, errors.New("driver: bad connection")
+	}
+	for _, user := range MockUsers {
+		if user.Id == userId {
+			return user.Suspended, nil
+		}
+	}
+
+	return false, repository.ErrNotFound
+}
+
+func (mr MockUserRepository) SetModerator(_ context.Context, userId string, moderator bool) error {
+	if userId == USER_ERROR {
+		return errors.New("driver: bad connection")
+	}
+	for i, user := range MockUsers {
+		if user.Id == userId {
+			MockUsers[i].Moderator = moderator
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
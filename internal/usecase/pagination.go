@@ -0,0 +1,21 @@
+package usecase
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// paginate turns a 1-based page and a page size into a limit and offset, applying defaults and bounds.
+func paginate(page, limit int) (int, int) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	return limit, (page - 1) * limit
+}
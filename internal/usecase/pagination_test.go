@@ -0,0 +1,20 @@
+package usecase
+
+import "testing"
+
+func TestPaginate(t *testing.T) {
+	t.Run("Should apply defaults and bounds", func(t *testing.T) {
+		scenarios := []struct{ page, limit, expectedLimit, expectedOffset int }{
+			{0, 0, DefaultPageSize, 0},
+			{3, 10, 10, 20},
+			{1, 1000, MaxPageSize, 0},
+			{-1, 5, 5, 0},
+		}
+		for _, scenario := range scenarios {
+			limit, offset := paginate(scenario.page, scenario.limit)
+			if limit != scenario.expectedLimit || offset != scenario.expectedOffset {
+				t.Errorf("paginate should bound page and limit. Scenario: %v. Got: %v, %v", scenario, limit, offset)
+			}
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"slices"
+	"time"
+)
+
+var (
+	ErrPollNotFound = newNotFound("poll_not_found", "poll not found")
+	ErrPollClosed   = errorType.NewCodedError("poll_closed", "poll is closed")
+)
+
+type PollUseCase struct {
+	pollRepository repository.Poll
+	postRepository repository.Post
+}
+
+func NewPollUseCase(pollRepository repository.Poll, postRepository repository.Post) *PollUseCase {
+	return &PollUseCase{
+		pollRepository: pollRepository,
+		postRepository: postRepository,
+	}
+}
+
+// Vote records the choice of the user on the poll of a post they can see, replacing any previous vote.
+func (p *PollUseCase) Vote(ctx context.Context, userId string, postId uint64, optionIds []uint64) error {
+	ctx, span := tracing.Start(ctx, "PollUseCase.Vote")
+	defer span.End()
+
+	post, err := NewPostUseCase(p.postRepository).GetById(ctx, postId, userId)
+	if err != nil {
+		return err
+	}
+	if post.Status != entity.PostPublished {
+		return ErrPostNotFound
+	}
+
+	poll, err := p.pollRepository.FetchByPost(ctx, postId)
+	if err != nil {
+		return notFound(err, ErrPollNotFound)
+	}
+	if !time.Now().Before(poll.ExpiresAt) {
+		return ErrPollClosed
+	}
+	optionIds = unique(optionIds)
+	if len(optionIds) == 0 {
+		return errorType.NewErrorPostValidation(errorType.NewFieldError("options", errorType.CodeRequired, "choose at least one option"))
+	}
+	if !poll.Multiple && len(optionIds) > 1 {
+		return errorType.NewErrorPostValidation(errorType.NewFieldError("options", errorType.CodeTooMany, "this poll accepts a single option"))
+	}
+	for _, optionId := range optionIds {
+		if !slices.ContainsFunc(poll.Options, func(option entity.PollOption) bool { return option.Id == optionId }) {
+			return errorType.NewErrorPostValidation(errorType.NewFieldError("options", errorType.CodeInvalid, "unknown poll option"))
+		}
+	}
+
+	return p.pollRepository.Vote(ctx, poll.Id, userId, optionIds)
+}
+
+// LoadPolls fills the polls of the posts as seen by the viewer.
+func (p *PollUseCase) LoadPolls(ctx context.Context, viewerId string, posts []entity.Post) error {
+	ctx, span := tracing.Start(ctx, "PollUseCase.LoadPolls")
+	defer span.End()
+
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIds := make([]uint64, len(posts))
+	index := make(map[uint64]int, len(posts))
+	for i, post := range posts {
+		postIds[i] = post.Id
+		index[post.Id] = i
+	}
+
+	polls, err := p.pollRepository.FetchByPosts(ctx, postIds, viewerId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, poll := range polls {
+		i := index[poll.PostId]
+		poll.Present(posts[i].AuthorId == viewerId, now)
+		posts[i].Poll = &poll
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/usecase/mock"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPolls(t *testing.T) {
+	author := "8f2e3d4c-5b6a-4978-8a9b-0c1d2e3f4a66"
+	voter := "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c77"
+	posts := usecase.MockPosts
+	usecase.MockPosts = append(slices.Clone(posts),
+		entity.Post{Id: 50, Title: "Single", Content: "Single", AuthorId: author, Status: entity.PostPublished},
+		entity.Post{Id: 51, Title: "Closed", Content: "Closed", AuthorId: author, Status: entity.PostPublished},
+	)
+	usecase.MockPolls = []entity.Poll{
+		{
+			Id:          1,
+			PostId:      50,
+			Options:     []entity.PollOption{{Id: 1, Text: "Yes"}, {Id: 2, Text: "No"}},
+			HideResults: true,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+		{
+			Id:        2,
+			PostId:    51,
+			Options:   []entity.PollOption{{Id: 3, Text: "Yes"}, {Id: 4, Text: "No"}},
+			ExpiresAt: time.Now().Add(-time.Hour),
+		},
+	}
+	defer func() {
+		usecase.MockPosts = posts
+		usecase.MockPolls = []entity.Poll{}
+		usecase.MockPollVotes = map[uint64]map[string][]uint64{}
+	}()
+
+	t.Run("Should hide results until the viewer votes", func(t *testing.T) {
+		pollUseCase := NewPollUseCase(usecase.NewMockPollRepository(), usecase.NewMockPostRepository())
+		viewed := []entity.Post{{Id: 50, AuthorId: author}}
+		if err := pollUseCase.LoadPolls(t.Context(), voter, viewed); err != nil {
+			t.Errorf("LoadPolls should not return an error. Error: %v", err)
+		}
+		if viewed[0].Poll == nil || viewed[0].Poll.Voters != nil || viewed[0].Poll.Options[0].Votes != nil {
+			t.Errorf("LoadPolls should hide tallies before voting. Got: %v", viewed[0].Poll)
+		}
+
+		if err := pollUseCase.Vote(t.Context(), voter, 50, []uint64{2}); err != nil {
+			t.Errorf("Vote should not return an error. Error: %v", err)
+		}
+		viewed = []entity.Post{{Id: 50, AuthorId: author}}
+		_ = pollUseCase.LoadPolls(t.Context(), voter, viewed)
+		poll := viewed[0].Poll
+		if !poll.Voted || *poll.Voters != 1 || *poll.Options[1].Votes != 1 || !poll.Options[1].Chosen {
+			t.Errorf("LoadPolls should show tallies after voting. Got: %v", poll)
+		}
+	})
+
+	t.Run("Should change a vote until the poll closes", func(t *testing.T) {
+		pollUseCase := NewPollUseCase(usecase.NewMockPollRepository(), usecase.NewMockPostRepository())
+		if err := pollUseCase.Vote(t.Context(), voter, 50, []uint64{1}); err != nil {
+			t.Errorf("Vote should not return an error. Error: %v", err)
+		}
+		viewed := []entity.Post{{Id: 50, AuthorId: author}}
+		_ = pollUseCase.LoadPolls(t.Context(), voter, viewed)
+		poll := viewed[0].Poll
+		if *poll.Voters != 1 || *poll.Options[0].Votes != 1 || *poll.Options[1].Votes != 0 {
+			t.Errorf("Vote should replace the previous vote. Got: %v", poll)
+		}
+
+		if err := pollUseCase.Vote(t.Context(), voter, 51, []uint64{3}); !errors.Is(err, ErrPollClosed) {
+			t.Errorf("Vote should return ErrPollClosed for an expired poll. Got: %v", err)
+		}
+	})
+
+	t.Run("Should validate chosen options", func(t *testing.T) {
+		pollUseCase := NewPollUseCase(usecase.NewMockPollRepository(), usecase.NewMockPostRepository())
+		scenarios := [][]uint64{nil, {1, 2}, {3}}
+		for _, optionIds := range scenarios {
+			var epv *errorType.ErrorPostValidation
+			if err := pollUseCase.Vote(t.Context(), voter, 50, optionIds); !errors.As(err, &epv) {
+				t.Errorf("Vote should return ErrorPostValidation. Options: %v. Got: %v", optionIds, err)
+			}
+		}
+	})
+
+	t.Run("Should return ErrPollNotFound for a post without poll", func(t *testing.T) {
+		pollUseCase := NewPollUseCase(usecase.NewMockPollRepository(), usecase.NewMockPostRepository())
+		if err := pollUseCase.Vote(t.Context(), voter, posts[0].Id, []uint64{1}); !errors.Is(err, ErrPollNotFound) {
+			t.Errorf("Vote should return ErrPollNotFound. Got: %v", err)
+		}
+	})
+}
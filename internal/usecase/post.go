@@ -1,39 +1,92 @@
 package usecase
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/dto"
 	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/metrics"
 	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"time"
 )
 
-var ErrAccessDenied = errors.New("access denied")
+var (
+	ErrAccessDenied   = errorType.NewCodedError("access_denied", "access denied")
+	ErrPostNotFound   = newNotFound("post_not_found", "post not found")
+	ErrEditWindowOver = errorType.NewCodedError("edit_window_over", "the edit window of this post is over")
+)
+
+// publishBatchSize is how many scheduled posts are published per statement.
+const publishBatchSize = 100
+
+const DefaultMaxPinnedPosts = 3
 
 type PostUseCase struct {
 	postRepository repository.Post
+	editWindow     time.Duration
+	maxPinned      int
+	scorer         FeedScorer
 }
 
 func NewPostUseCase(postRepository repository.Post) *PostUseCase {
 	return &PostUseCase{
 		postRepository: postRepository,
+		maxPinned:      DefaultMaxPinnedPosts,
+		scorer:         NewEngagementScorer(),
 	}
 }
 
-func (p *PostUseCase) CreatePost(post *entity.Post) error {
+// WithEditWindow makes published posts immutable once window has passed since publication. Zero means no limit.
+func (p *PostUseCase) WithEditWindow(window time.Duration) *PostUseCase {
+	p.editWindow = window
+	return p
+}
+
+// WithMaxPinned sets how many posts each user may pin on their profile.
+func (p *PostUseCase) WithMaxPinned(max int) *PostUseCase {
+	p.maxPinned = max
+	return p
+}
+
+// WithScorer replaces the scoring function of the ranked feed.
+func (p *PostUseCase) WithScorer(scorer FeedScorer) *PostUseCase {
+	p.scorer = scorer
+	return p
+}
+
+func (p *PostUseCase) CreatePost(ctx context.Context, post *entity.Post) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.CreatePost")
+	defer span.End()
+
+	if post.Status == "" {
+		post.Status = entity.PostPublished
+	}
+	if post.Visibility == "" {
+		post.Visibility = entity.VisibilityPublic
+	}
 	err := post.Prepare()
 	if err != nil {
 		return err
 	}
 
-	post.Id, err = p.postRepository.Create(*post)
+	post.Id, err = p.postRepository.Create(ctx, *post)
 	if err != nil {
 		return err
 	}
+	metrics.PostsCreated.Inc()
 
 	return nil
 }
 
-func (p *PostUseCase) GetByUser(userId string) ([]entity.Post, error) {
-	posts, err := p.postRepository.FetchByUser(userId)
+// GetByUser returns a page of the home feed of the user: their posts and the posts of who they follow.
+func (p *PostUseCase) GetByUser(ctx context.Context, userId string, page, limit int) ([]entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetByUser")
+	defer span.End()
+
+	limit, offset := paginate(page, limit)
+	posts, err := p.postRepository.FetchByUser(ctx, userId, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -41,50 +94,138 @@ func (p *PostUseCase) GetByUser(userId string) ([]entity.Post, error) {
 	return posts, nil
 }
 
-func (p *PostUseCase) GetById(postId uint64) (entity.Post, error) {
-	post, err := p.postRepository.FetchById(postId)
+// GetRanked returns a page of the ranked feed of the user: the recent posts of their home feed ordered by score.
+func (p *PostUseCase) GetRanked(ctx context.Context, userId string, page, limit int) ([]entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetRanked")
+	defer span.End()
+
+	limit, offset := paginate(page, limit)
+	now := time.Now()
+	candidates, err := p.postRepository.FetchFeedCandidates(ctx, userId, now.Add(-rankedFeedWindow), rankedFeedPoolSize)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := rankFeed(candidates, p.scorer, now)
+	if offset >= len(posts) {
+		return []entity.Post{}, nil
+	}
+
+	return posts[offset:min(offset+limit, len(posts))], nil
+}
+
+// GetById returns a post as seen by viewerId, or an empty post if they can't see it. Drafts and scheduled posts are
+// only visible to their author.
+func (p *PostUseCase) GetById(ctx context.Context, postId uint64, viewerId string) (entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetById")
+	defer span.End()
+
+	post, err := p.postRepository.FetchById(ctx, postId, viewerId)
 	if err != nil {
-		return entity.Post{}, nil
+		return entity.Post{}, notFound(err, ErrPostNotFound)
+	}
+	if post.Status != entity.PostPublished && post.AuthorId != viewerId {
+		return entity.Post{}, ErrPostNotFound
 	}
 
 	return post, nil
 }
-func (p *PostUseCase) Update(authorId string, postId uint64, post entity.Post) error {
+
+// Update edits a post of authorId. Fields left out of the update, like visibility or the content warning, keep their
+// current value.
+func (p *PostUseCase) Update(ctx context.Context, authorId string, postId uint64, update dto.PostUpdate) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.Update")
+	defer span.End()
+
+	post := update.Post
+	if update.Sensitive != nil {
+		post.Sensitive = *update.Sensitive
+	}
+	if update.ContentWarning != nil {
+		post.ContentWarning = *update.ContentWarning
+	}
+	// Polls can't be changed once the post is created.
+	post.Poll = nil
 	if err := post.Prepare(); err != nil {
 		return err
 	}
-	postDb, err := p.postRepository.FetchById(postId)
+	postDb, err := p.postRepository.FetchById(ctx, postId, authorId)
 	if err != nil {
-		return err
+		return notFound(err, ErrPostNotFound)
 	}
 	if postDb.AuthorId != authorId {
 		return ErrAccessDenied
 	}
+	if post.Visibility == "" {
+		post.Visibility = postDb.Visibility
+	}
+	// Unflagging the post drops its warning, as with MarkSensitive.
+	if update.ContentWarning == nil && (update.Sensitive == nil || *update.Sensitive) {
+		post.ContentWarning = postDb.ContentWarning
+	}
+	if update.Sensitive == nil {
+		post.Sensitive = postDb.Sensitive || post.ContentWarning != ""
+	}
+	if post.Status == "" {
+		post.Status = postDb.Status
+		post.PublishAt = postDb.PublishAt
+	}
+	if postDb.Status == entity.PostPublished && post.Status != entity.PostPublished {
+		return errorType.NewErrorPostValidation(
+			errorType.NewFieldError("status", errorType.CodeNotAllowed, "a published post cannot be turned back into a draft"),
+		)
+	}
+	if p.editWindow > 0 && postDb.PublishedAt != nil && time.Since(*postDb.PublishedAt) > p.editWindow {
+		return ErrEditWindowOver
+	}
 
-	if err = p.postRepository.Update(postId, post); err != nil {
+	if err = p.postRepository.Update(ctx, postId, post); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (p *PostUseCase) Delete(postId uint64, authorId string) error {
-	postDb, err := p.postRepository.FetchById(postId)
+func (p *PostUseCase) Delete(ctx context.Context, postId uint64, authorId string) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.Delete")
+	defer span.End()
+
+	postDb, err := p.postRepository.FetchById(ctx, postId, authorId)
 	if err != nil {
-		return err
+		return notFound(err, ErrPostNotFound)
 	}
 	if postDb.AuthorId != authorId {
 		return ErrAccessDenied
 	}
-	if err = p.postRepository.Delete(postId); err != nil {
+	if err = p.postRepository.Delete(ctx, postId); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (p *PostUseCase) GetUserPosts(userId string) ([]entity.Post, error) {
-	posts, err := p.postRepository.FetchUserPosts(userId)
+// Remove deletes a post regardless of its author. It's meant for operators, authors go through Delete.
+func (p *PostUseCase) Remove(ctx context.Context, postId uint64) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.Remove")
+	defer span.End()
+
+	return notFound(p.postRepository.Delete(ctx, postId), ErrPostNotFound)
+}
+
+// RebuildTimelines recomputes the materialized home timeline of userId, or of every user when it's empty.
+func (p *PostUseCase) RebuildTimelines(ctx context.Context, userId string) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.RebuildTimelines")
+	defer span.End()
+
+	return p.postRepository.RebuildTimelines(ctx, userId)
+}
+
+// GetUserPosts returns the published posts of userId that viewerId can see.
+func (p *PostUseCase) GetUserPosts(ctx context.Context, userId, viewerId string) ([]entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetUserPosts")
+	defer span.End()
+
+	posts, err := p.postRepository.FetchUserPosts(ctx, userId, viewerId)
 	if err != nil {
 		return nil, err
 	}
@@ -92,18 +233,165 @@ func (p *PostUseCase) GetUserPosts(userId string) ([]entity.Post, error) {
 	return posts, nil
 }
 
-func (p *PostUseCase) LikePost(postId uint64) error {
-	if err := p.postRepository.LikePost(postId); err != nil {
+func (p *PostUseCase) GetDrafts(ctx context.Context, authorId string) ([]entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetDrafts")
+	defer span.End()
+
+	posts, err := p.postRepository.FetchDrafts(ctx, authorId)
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// PublishDue publishes every scheduled post whose publish time has come and returns their ids.
+func (p *PostUseCase) PublishDue(ctx context.Context) ([]uint64, error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.PublishDue")
+	defer span.End()
+
+	var published []uint64
+	for {
+		postIds, err := p.postRepository.PublishDue(ctx, publishBatchSize)
+		if err != nil {
+			return published, err
+		}
+
+		published = append(published, postIds...)
+		if len(postIds) < publishBatchSize {
+			return published, nil
+		}
+	}
+}
+
+// GetRevisions returns the previous versions of a post, newest first, as seen by viewerId.
+func (p *PostUseCase) GetRevisions(ctx context.Context, postId uint64, viewerId string) ([]entity.Revision, error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetRevisions")
+	defer span.End()
+
+	if _, err := p.GetById(ctx, postId, viewerId); err != nil {
+		return nil, err
+	}
+
+	revisions, err := p.postRepository.FetchRevisions(ctx, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Pin highlights a published post of the author on their profile.
+func (p *PostUseCase) Pin(ctx context.Context, authorId string, postId uint64) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.Pin")
+	defer span.End()
+
+	postDb, err := p.postRepository.FetchById(ctx, postId, authorId)
+	if err != nil {
+		return notFound(err, ErrPostNotFound)
+	}
+	if postDb.AuthorId != authorId {
+		return ErrAccessDenied
+	}
+	if postDb.Status != entity.PostPublished {
+		return errorType.NewErrorPostValidation(errorType.NewFieldError("", errorType.CodeNotAllowed, "only published posts can be pinned"))
+	}
+
+	pinned, err := p.postRepository.Pin(ctx, postId, authorId, p.maxPinned)
+	if err != nil {
 		return err
 	}
+	if !pinned {
+		return errorType.NewErrorPostValidation(
+			errorType.NewFieldError("", errorType.CodeTooMany, fmt.Sprintf("at most %d posts can be pinned", p.maxPinned)),
+		)
+	}
 
 	return nil
 }
 
-func (p *PostUseCase) UnLikePost(postId uint64) error {
-	if err := p.postRepository.UnlikePost(postId); err != nil {
+func (p *PostUseCase) Unpin(ctx context.Context, authorId string, postId uint64) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.Unpin")
+	defer span.End()
+
+	postDb, err := p.postRepository.FetchById(ctx, postId, authorId)
+	if err != nil {
+		return notFound(err, ErrPostNotFound)
+	}
+	if postDb.AuthorId != authorId {
+		return ErrAccessDenied
+	}
+
+	return p.postRepository.Unpin(ctx, postId)
+}
+
+// SetSensitive flags or unflags a post as sensitive, with an optional content warning. Authors can flag their own
+// posts and moderators any post they can see; a flag set by a moderator can only be removed by a moderator.
+func (p *PostUseCase) SetSensitive(ctx context.Context, actor entity.User, postId uint64, sensitive dto.Sensitive) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.SetSensitive")
+	defer span.End()
+
+	var post entity.Post
+	if err := post.MarkSensitive(sensitive.Sensitive, sensitive.ContentWarning); err != nil {
 		return err
 	}
 
+	postDb, err := p.postRepository.FetchById(ctx, postId, actor.Id)
+	if err != nil {
+		return notFound(err, ErrPostNotFound)
+	}
+	if postDb.AuthorId != actor.Id && !actor.Moderator {
+		return ErrAccessDenied
+	}
+	if postDb.SensitiveByModerator && !post.Sensitive && !actor.Moderator {
+		return errorType.NewErrorPostValidation(
+			errorType.NewFieldError("sensitive", errorType.CodeNotAllowed, "this post was marked as sensitive by a moderator"),
+		)
+	}
+
+	return p.postRepository.SetSensitive(ctx, postId, post.Sensitive, post.ContentWarning, actor.Moderator)
+}
+
+// LikePost likes a published post the viewer can see.
+func (p *PostUseCase) LikePost(ctx context.Context, postId uint64, viewerId string) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.LikePost")
+	defer span.End()
+
+	if err := p.checkPublished(ctx, postId, viewerId); err != nil {
+		return err
+	}
+	if err := p.postRepository.LikePost(ctx, postId); err != nil {
+		return err
+	}
+	metrics.Likes.Inc()
+
+	return nil
+}
+
+// UnLikePost removes a like from a published post the viewer can see.
+func (p *PostUseCase) UnLikePost(ctx context.Context, postId uint64, viewerId string) error {
+	ctx, span := tracing.Start(ctx, "PostUseCase.UnLikePost")
+	defer span.End()
+
+	if err := p.checkPublished(ctx, postId, viewerId); err != nil {
+		return err
+	}
+	if err := p.postRepository.UnlikePost(ctx, postId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkPublished returns ErrPostNotFound unless the post is published and the viewer can see it.
+func (p *PostUseCase) checkPublished(ctx context.Context, postId uint64, viewerId string) error {
+	post, err := p.GetById(ctx, postId, viewerId)
+	if err != nil {
+		return err
+	}
+	if post.Status != entity.PostPublished {
+		return ErrPostNotFound
+	}
+
 	return nil
 }
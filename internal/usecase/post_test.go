@@ -1,13 +1,18 @@
 package usecase
 
 import (
-	"database/sql"
 	"errors"
+	"github.com/edigar/socialnets-api/internal/config"
+	"github.com/edigar/socialnets-api/internal/dto"
 	"github.com/edigar/socialnets-api/internal/entity"
 	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/repository"
 	"github.com/edigar/socialnets-api/internal/usecase/mock"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCreatePost(t *testing.T) {
@@ -18,7 +23,7 @@ func TestCreatePost(t *testing.T) {
 		}
 
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.CreatePost(&post)
+		err := postUseCase.CreatePost(t.Context(), &post)
 		if err != nil {
 			t.Errorf("CreatePost should not return an error for a valid post data. Post: %v. Error: %v", post, err)
 		} else if post.Id != usecase.NEW_POST_ID {
@@ -41,7 +46,7 @@ func TestCreatePost(t *testing.T) {
 
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
 		for _, scenario := range scenarios {
-			err := postUseCase.CreatePost(&scenario)
+			err := postUseCase.CreatePost(t.Context(), &scenario)
 			var epv *errorType.ErrorPostValidation
 			if !errors.As(err, &epv) {
 				t.Errorf("CreatePost should return an ErrorPostValidation error for a non-valid post data. Post: %v Returned: %v. Error expected: %T",
@@ -57,23 +62,95 @@ func TestCreatePost(t *testing.T) {
 }
 
 func TestGetByUser(t *testing.T) {
-	t.Run("Should get all posts of user", func(t *testing.T) {
+	t.Run("Should get the posts of the timeline of user, newest first", func(t *testing.T) {
 		userId := usecase.MockPosts[1].AuthorId
+		usecase.MockTimelines[userId] = []uint64{3, 2, 1}
+		defer delete(usecase.MockTimelines, userId)
+
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		posts, err := postUseCase.GetByUser(userId)
+		posts, err := postUseCase.GetByUser(t.Context(), userId, 1, DefaultPageSize)
 		if err != nil {
 			t.Errorf("GetByUser should not return an error for a valid user id. User: %v. Error: %v", userId, err)
 		}
 
-		if !reflect.DeepEqual(posts, usecase.MockPosts) {
-			t.Errorf("GetByUser should return posts for user. Expected: %v. Got: %v", posts, usecase.MockPosts)
+		expected := []entity.Post{usecase.MockPosts[2], usecase.MockPosts[1], usecase.MockPosts[0]}
+		if !reflect.DeepEqual(posts, expected) {
+			t.Errorf("GetByUser should return posts for user. Expected: %v. Got: %v", expected, posts)
+		}
+	})
+
+	t.Run("Should backfill posts of a followed account and remove them on unfollow", func(t *testing.T) {
+		author := usecase.MockPosts[1].AuthorId
+		follower := usecase.MockUsers[0].Id
+		defer func() {
+			delete(usecase.MockTimelines, follower)
+			delete(usecase.MockPostFollowers, author)
+		}()
+
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := userUseCase.Follow(t.Context(), author, follower); err != nil {
+			t.Fatalf("Follow should not return an error. Error: %v", err)
+		}
+		if posts, _ := postUseCase.GetByUser(t.Context(), follower, 1, DefaultPageSize); !slices.Equal(rankedIds(posts), []uint64{3, 2}) {
+			t.Errorf("GetByUser should return the backfilled posts of a followed account. Got: %v", posts)
+		}
+
+		if err := userUseCase.Unfollow(t.Context(), author, follower); err != nil {
+			t.Fatalf("Unfollow should not return an error. Error: %v", err)
+		}
+		if posts, _ := postUseCase.GetByUser(t.Context(), follower, 1, DefaultPageSize); len(posts) != 0 {
+			t.Errorf("GetByUser should not return posts of an unfollowed account. Got: %v", posts)
+		}
+	})
+
+	t.Run("Should merge posts of accounts over the fan-out limit and backfill them once below it", func(t *testing.T) {
+		author := usecase.MockPosts[1].AuthorId
+		first := usecase.MockUsers[0].Id
+		second := "0d4f5a1c-5b5f-4a8e-9a43-3a1c1d0a4f11"
+		posts := usecase.MockPosts
+		defer func() {
+			usecase.MockPosts = posts
+			delete(usecase.MockTimelines, first)
+			delete(usecase.MockTimelines, second)
+			delete(usecase.MockPostFollowers, author)
+			delete(usecase.MockMergedAuthors, author)
+		}()
+
+		cfg := config.Default()
+		cfg.FanOutMaxFollowers = 2
+		ctx := config.WithConfig(t.Context(), cfg)
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		_ = userUseCase.Follow(ctx, author, first)
+		_ = userUseCase.Follow(ctx, author, second)
+		if !usecase.MockMergedAuthors[author] {
+			t.Errorf("Follow should merge on read the posts of an account reaching the fan-out limit")
+		}
+
+		usecase.MockPosts = append(slices.Clone(posts),
+			entity.Post{Id: 30, Title: "Merged", Content: "Merged", AuthorId: author, Status: entity.PostPublished},
+		)
+		if feed, _ := postUseCase.GetByUser(ctx, second, 1, DefaultPageSize); !slices.Equal(rankedIds(feed), []uint64{30, 3, 2}) {
+			t.Errorf("GetByUser should merge posts of accounts over the fan-out limit. Got: %v", feed)
+		}
+
+		_ = userUseCase.Unfollow(ctx, author, first)
+		if usecase.MockMergedAuthors[author] {
+			t.Errorf("Unfollow should fan out again the posts of an account dropping below the fan-out limit")
+		}
+		if !slices.Equal(usecase.MockTimelines[second], []uint64{30, 3, 2}) {
+			t.Errorf("Unfollow should backfill posts of an account dropping below the fan-out limit. Got: %v", usecase.MockTimelines[second])
+		}
+		if feed, _ := postUseCase.GetByUser(ctx, second, 1, DefaultPageSize); !slices.Equal(rankedIds(feed), []uint64{30, 3, 2}) {
+			t.Errorf("GetByUser should keep posts of an account dropping below the fan-out limit. Got: %v", feed)
 		}
 	})
 
 	t.Run("Should get a bad connection database error", func(t *testing.T) {
 		userId := usecase.POST_ERROR
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		posts, err := postUseCase.GetByUser(userId)
+		posts, err := postUseCase.GetByUser(t.Context(), userId, 1, DefaultPageSize)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("GetByUser should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -88,25 +165,25 @@ func TestGetById(t *testing.T) {
 	t.Run("Should get a post by id", func(t *testing.T) {
 		postId := usecase.MockPosts[0].Id
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		post, err := postUseCase.GetById(postId)
+		post, err := postUseCase.GetById(t.Context(), postId, usecase.MockPosts[0].AuthorId)
 		if err != nil {
 			t.Errorf("GetById should not return an error for a valid id. Post id: %v. Error: %v", postId, err)
 		}
 
-		if post != usecase.MockPosts[0] {
+		if !reflect.DeepEqual(post, usecase.MockPosts[0]) {
 			t.Errorf("GetByUser should return post by id. Expected: %v. Got: %v", usecase.MockPosts[0].Id, post)
 		}
 	})
 
-	t.Run("Should get empty Post if id is invalid", func(t *testing.T) {
+	t.Run("Should return ErrPostNotFound if id is invalid", func(t *testing.T) {
 		postId := uint64(99)
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		post, err := postUseCase.GetById(postId)
-		if err != nil {
-			t.Errorf("GetById should not return an error for a non-valid id. Post id: %v. Error: %v", postId, err)
+		post, err := postUseCase.GetById(t.Context(), postId, usecase.MockPosts[0].AuthorId)
+		if !errors.Is(err, ErrPostNotFound) || !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetById should return ErrPostNotFound for a non-valid id. Post id: %v. Error: %v", postId, err)
 		}
 
-		if post != (entity.Post{}) {
+		if !reflect.DeepEqual(post, entity.Post{}) {
 			t.Errorf("GetByUser should return empty post for a non-valid id. Expected: %v. Got: %v", entity.Post{}, post)
 		}
 	})
@@ -116,7 +193,7 @@ func TestUpdatePost(t *testing.T) {
 	t.Run("Should update post with valid id", func(t *testing.T) {
 		post := entity.Post{Title: "Title test", Content: "Content test"}
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.Update(usecase.MockPosts[0].AuthorId, usecase.MockPosts[0].Id, post)
+		err := postUseCase.Update(t.Context(), usecase.MockPosts[0].AuthorId, usecase.MockPosts[0].Id, dto.PostUpdate{Post: post})
 		if err != nil {
 			t.Errorf("Update should not return an error with valid data. Data sended: %v. Post updated: %v Error: %v",
 				post,
@@ -135,14 +212,14 @@ func TestUpdatePost(t *testing.T) {
 		post := entity.Post{Title: "Title test", Content: "Content test"}
 		originalPosts := usecase.MockPosts
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.Update(usecase.MockUsers[0].Id, 0, post)
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("Update should return sql.ErrNoRows error with non-valid post id. Data sended: %v. User updated: %v Error: %v",
+		err := postUseCase.Update(t.Context(), usecase.MockUsers[0].Id, 0, dto.PostUpdate{Post: post})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Update should return ErrNotFound error with non-valid post id. Data sended: %v. User updated: %v Error: %v",
 				post,
 				usecase.MockPosts[0],
 				err,
 			)
-		} else if originalPosts[0] != usecase.MockPosts[0] || originalPosts[1] != usecase.MockPosts[1] {
+		} else if !reflect.DeepEqual(originalPosts[0], usecase.MockPosts[0]) || !reflect.DeepEqual(originalPosts[1], usecase.MockPosts[1]) {
 			t.Errorf("Update should not update none of posts with non-valid id. Data sended: %v. Posts: %v", post, usecase.MockPosts)
 		}
 	})
@@ -151,14 +228,14 @@ func TestUpdatePost(t *testing.T) {
 		post := entity.Post{Title: "Title test", Content: "Content test"}
 		originalPosts := usecase.MockPosts
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.Update("wrong-author-id", usecase.MockPosts[0].Id, post)
+		err := postUseCase.Update(t.Context(), "wrong-author-id", usecase.MockPosts[0].Id, dto.PostUpdate{Post: post})
 		if !errors.Is(err, ErrAccessDenied) {
 			t.Errorf("Update should return ErrAccessDenied error with non-valid author id. Data sended: %v. Post updated: %v Error: %v",
 				post,
 				usecase.MockPosts[0],
 				err,
 			)
-		} else if originalPosts[0] != usecase.MockPosts[0] || originalPosts[1] != usecase.MockPosts[1] {
+		} else if !reflect.DeepEqual(originalPosts[0], usecase.MockPosts[0]) || !reflect.DeepEqual(originalPosts[1], usecase.MockPosts[1]) {
 			t.Errorf("Update should not update none of posts with non-valid author id. Data sended: %v. Posts: %v",
 				post,
 				usecase.MockPosts,
@@ -179,7 +256,7 @@ func TestUpdatePost(t *testing.T) {
 		originalPosts := usecase.MockPosts
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
 		for _, scenario := range scenarios {
-			err := postUseCase.Update(usecase.MockUsers[0].Id, usecase.MockPosts[0].Id, scenario)
+			err := postUseCase.Update(t.Context(), usecase.MockUsers[0].Id, usecase.MockPosts[0].Id, dto.PostUpdate{Post: scenario})
 			var euv *errorType.ErrorPostValidation
 			if !errors.As(err, &euv) {
 				t.Errorf("Update should return an ErrorPostValidation error for a non-valid post data. Post: %v Error returned: %v. Error expected: %T",
@@ -187,7 +264,7 @@ func TestUpdatePost(t *testing.T) {
 					err,
 					euv.Error(),
 				)
-			} else if originalPosts[0] != usecase.MockPosts[0] || originalPosts[1] != usecase.MockPosts[1] {
+			} else if !reflect.DeepEqual(originalPosts[0], usecase.MockPosts[0]) || !reflect.DeepEqual(originalPosts[1], usecase.MockPosts[1]) {
 				t.Errorf("Update should not update none of posts with non-valid data. Data sended: %v. Original posts: %v", scenario, originalPosts)
 			}
 		}
@@ -198,7 +275,7 @@ func TestGetUserPosts(t *testing.T) {
 	t.Run("Should get user posts by id", func(t *testing.T) {
 		userId := usecase.MockPosts[1].AuthorId
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		posts, err := postUseCase.GetUserPosts(userId)
+		posts, err := postUseCase.GetUserPosts(t.Context(), userId, userId)
 		if err != nil {
 			t.Errorf("GetByUser should not return an error for a valid user id. User: %v. Error: %v", userId, err)
 		}
@@ -208,7 +285,7 @@ func TestGetUserPosts(t *testing.T) {
 		}
 
 		for _, post := range posts {
-			if post != usecase.MockPosts[1] && post != usecase.MockPosts[2] {
+			if !reflect.DeepEqual(post, usecase.MockPosts[1]) && !reflect.DeepEqual(post, usecase.MockPosts[2]) {
 				t.Errorf("GetByUser should return only posts of user. Post: %v. Posts: %v", post, posts)
 			}
 		}
@@ -217,7 +294,7 @@ func TestGetUserPosts(t *testing.T) {
 	t.Run("Should get a bad connection database error", func(t *testing.T) {
 		userId := usecase.POST_ERROR
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		posts, err := postUseCase.GetUserPosts(userId)
+		posts, err := postUseCase.GetUserPosts(t.Context(), userId, userId)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("GetByUser should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -232,7 +309,7 @@ func TestLikePost(t *testing.T) {
 	t.Run("Should like a post", func(t *testing.T) {
 		postId := usecase.MockPosts[0].Id
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.LikePost(postId)
+		err := postUseCase.LikePost(t.Context(), postId, usecase.MockUsers[1].Id)
 		if err != nil {
 			t.Errorf("LikePost should not return error for a valid post. Error: %v", err)
 		}
@@ -247,9 +324,33 @@ func TestLikePost(t *testing.T) {
 	t.Run("Should not like a post with non-valid id", func(t *testing.T) {
 		postId := uint64(99)
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.LikePost(postId)
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("LikePost should return sql.ErrNoRows error with non-valid id. Error: %v", err)
+		err := postUseCase.LikePost(t.Context(), postId, usecase.MockUsers[1].Id)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("LikePost should return ErrNotFound error with non-valid id. Error: %v", err)
+		}
+	})
+
+	t.Run("Should not like a post the user can't see", func(t *testing.T) {
+		posts := usecase.MockPosts
+		usecase.MockPosts = append(slices.Clone(posts), entity.Post{
+			Id:         23,
+			Title:      "Followers",
+			Content:    "Followers",
+			AuthorId:   usecase.MockUsers[0].Id,
+			Status:     entity.PostPublished,
+			Visibility: entity.VisibilityFollowers,
+		})
+		defer func() { usecase.MockPosts = posts }()
+
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.LikePost(t.Context(), 23, usecase.MockUsers[1].Id); !errors.Is(err, ErrPostNotFound) {
+			t.Errorf("LikePost should return ErrPostNotFound for a post the user can't see. Error: %v", err)
+		}
+		if err := postUseCase.UnLikePost(t.Context(), 23, usecase.MockUsers[1].Id); !errors.Is(err, ErrPostNotFound) {
+			t.Errorf("UnLikePost should return ErrPostNotFound for a post the user can't see. Error: %v", err)
+		}
+		if usecase.MockPosts[3].Likes != 0 {
+			t.Errorf("LikePost should not like a post the user can't see. Got: %v", usecase.MockPosts[3].Likes)
 		}
 	})
 }
@@ -259,7 +360,7 @@ func TestUnLikePost(t *testing.T) {
 		usecase.MockPosts[0].Likes = 2
 		postId := usecase.MockPosts[0].Id
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.UnLikePost(postId)
+		err := postUseCase.UnLikePost(t.Context(), postId, usecase.MockUsers[1].Id)
 		if err != nil {
 			t.Errorf("UnLikePost should not return error for a valid post. Error: %v", err)
 		}
@@ -274,9 +375,9 @@ func TestUnLikePost(t *testing.T) {
 	t.Run("Should not unlike a post with non-valid id", func(t *testing.T) {
 		postId := uint64(99)
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.UnLikePost(postId)
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("UnLikePost should return sql.ErrNoRows error with non-valid id. Error: %v", err)
+		err := postUseCase.UnLikePost(t.Context(), postId, usecase.MockUsers[1].Id)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("UnLikePost should return ErrNotFound error with non-valid id. Error: %v", err)
 		}
 	})
 }
@@ -287,7 +388,7 @@ func TestDeletePost(t *testing.T) {
 		postId := usecase.MockPosts[0].Id
 		expectedPosts := []entity.Post{usecase.MockPosts[1], usecase.MockPosts[2]}
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.Delete(postId, usecase.MockPosts[0].AuthorId)
+		err := postUseCase.Delete(t.Context(), postId, usecase.MockPosts[0].AuthorId)
 		if err != nil {
 			t.Errorf("Delete should not return an error for a valid post id and author id. Post: %v. Error: %v", usecase.MockPosts[0], err)
 		}
@@ -302,22 +403,22 @@ func TestDeletePost(t *testing.T) {
 		var postId uint64
 		postId = 999
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.Delete(postId, usecase.MockPosts[0].AuthorId)
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("Delete should return an error for invalid post id. Expected: %v. Got: %v", sql.ErrNoRows, err)
+		err := postUseCase.Delete(t.Context(), postId, usecase.MockPosts[0].AuthorId)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Delete should return an error for invalid post id. Expected: %v. Got: %v", repository.ErrNotFound, err)
 		}
 	})
 
 	t.Run("Should not delete post with non-valid author id", func(t *testing.T) {
 		originalPosts := usecase.MockPosts
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.Delete(usecase.MockPosts[0].Id, "wrong-author-id")
+		err := postUseCase.Delete(t.Context(), usecase.MockPosts[0].Id, "wrong-author-id")
 		if !errors.Is(err, ErrAccessDenied) {
 			t.Errorf("Delete should return ErrAccessDenied error with non-valid author id. Post to delete: %v Error: %v",
 				usecase.MockPosts[0],
 				err,
 			)
-		} else if originalPosts[0] != usecase.MockPosts[0] || originalPosts[1] != usecase.MockPosts[1] {
+		} else if !reflect.DeepEqual(originalPosts[0], usecase.MockPosts[0]) || !reflect.DeepEqual(originalPosts[1], usecase.MockPosts[1]) {
 			t.Errorf("Delete should not delete with non-valid author id. Posts: %v", usecase.MockPosts)
 		}
 	})
@@ -325,11 +426,361 @@ func TestDeletePost(t *testing.T) {
 	t.Run("Should not delete post with non-valid id", func(t *testing.T) {
 		originalPosts := usecase.MockPosts
 		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
-		err := postUseCase.Delete(0, usecase.MockPosts[0].AuthorId)
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("Delete should return sql.ErrNoRows error with non-valid id. Error: %v", err)
-		} else if originalPosts[0] != usecase.MockPosts[0] || originalPosts[1] != usecase.MockPosts[1] {
+		err := postUseCase.Delete(t.Context(), 0, usecase.MockPosts[0].AuthorId)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Delete should return ErrNotFound error with non-valid id. Error: %v", err)
+		} else if !reflect.DeepEqual(originalPosts[0], usecase.MockPosts[0]) || !reflect.DeepEqual(originalPosts[1], usecase.MockPosts[1]) {
 			t.Errorf("Delete should not delete with non-valid id. Posts: %v", usecase.MockPosts)
 		}
 	})
 }
+
+func TestRemovePost(t *testing.T) {
+	t.Run("Should remove a post of any author", func(t *testing.T) {
+		posts := usecase.MockPosts
+		usecase.MockPosts = append(slices.Clone(posts), entity.Post{Id: 30, AuthorId: "another-user", Status: entity.PostPublished})
+		defer func() { usecase.MockPosts = posts }()
+
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.Remove(t.Context(), 30); err != nil {
+			t.Errorf("Remove should not return an error for an existing post. Error: %v", err)
+		}
+		if len(usecase.MockPosts) != len(posts) {
+			t.Errorf("Remove should delete the post. Posts: %v", usecase.MockPosts)
+		}
+	})
+
+	t.Run("Should return ErrPostNotFound for a non-existent post", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.Remove(t.Context(), 999); !errors.Is(err, ErrPostNotFound) {
+			t.Errorf("Remove should return ErrPostNotFound. Got: %v", err)
+		}
+	})
+}
+
+func TestScheduledPosts(t *testing.T) {
+	t.Run("Should create a published post by default", func(t *testing.T) {
+		post := entity.Post{Title: "Title", Content: "Content"}
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.CreatePost(t.Context(), &post); err != nil {
+			t.Fatalf("CreatePost should not return an error. Error: %v", err)
+		}
+		if post.Status != entity.PostPublished {
+			t.Errorf("CreatePost should publish post without status. Got: %v", post.Status)
+		}
+	})
+
+	t.Run("Should not schedule a post in the past", func(t *testing.T) {
+		publishAt := time.Now().Add(-time.Minute)
+		post := entity.Post{Title: "Title", Content: "Content", Status: entity.PostScheduled, PublishAt: &publishAt}
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		err := postUseCase.CreatePost(t.Context(), &post)
+		var epv *errorType.ErrorPostValidation
+		if !errors.As(err, &epv) {
+			t.Errorf("CreatePost should return ErrorPostValidation for a past publishAt. Got: %v", err)
+		}
+	})
+
+	t.Run("Should hide drafts from other users", func(t *testing.T) {
+		draft := usecase.MockDrafts[0]
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+
+		post, _ := postUseCase.GetById(t.Context(), draft.Id, draft.AuthorId)
+		if post.Id != draft.Id {
+			t.Errorf("GetById should return a draft to its author. Got: %v", post)
+		}
+
+		post, err := postUseCase.GetById(t.Context(), draft.Id, "another-user")
+		if !errors.Is(err, ErrPostNotFound) || !reflect.DeepEqual(post, entity.Post{}) {
+			t.Errorf("GetById should not return a draft to other users. Got: %v", post)
+		}
+	})
+
+	t.Run("Should list drafts of author", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		posts, err := postUseCase.GetDrafts(t.Context(), usecase.MockDrafts[0].AuthorId)
+		if err != nil || len(posts) != 1 || posts[0].Id != usecase.MockDrafts[0].Id {
+			t.Errorf("GetDrafts should return drafts of author. Got: %v. Error: %v", posts, err)
+		}
+	})
+
+	t.Run("Should not turn a published post back into a draft", func(t *testing.T) {
+		post := entity.Post{Title: "Title", Content: "Content", Status: entity.PostDraft}
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		err := postUseCase.Update(t.Context(), usecase.MockPosts[0].AuthorId, usecase.MockPosts[0].Id, dto.PostUpdate{Post: post})
+		var epv *errorType.ErrorPostValidation
+		if !errors.As(err, &epv) {
+			t.Errorf("Update should return ErrorPostValidation when unpublishing a post. Got: %v", err)
+		}
+		if usecase.MockPosts[0].Status != entity.PostPublished {
+			t.Errorf("Update should keep the post published. Got: %v", usecase.MockPosts[0].Status)
+		}
+	})
+
+	t.Run("Should keep status when it isn't sent on update", func(t *testing.T) {
+		post := entity.Post{Title: "Title", Content: "Content"}
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.Update(t.Context(), usecase.MockPosts[0].AuthorId, usecase.MockPosts[0].Id, dto.PostUpdate{Post: post}); err != nil {
+			t.Errorf("Update should not return an error. Error: %v", err)
+		}
+		if usecase.MockPosts[0].Status != entity.PostPublished {
+			t.Errorf("Update should keep current status. Got: %v", usecase.MockPosts[0].Status)
+		}
+	})
+
+	t.Run("Should publish every due post in batches", func(t *testing.T) {
+		usecase.MockScheduledDue = 2*publishBatchSize + 1
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		published, err := postUseCase.PublishDue(t.Context())
+		if err != nil {
+			t.Errorf("PublishDue should not return an error. Error: %v", err)
+		}
+		if len(published) != 2*publishBatchSize+1 || usecase.MockScheduledDue != 0 {
+			t.Errorf("PublishDue should publish all due posts. Published: %v. Left: %v", len(published), usecase.MockScheduledDue)
+		}
+	})
+}
+
+func TestPostRevisions(t *testing.T) {
+	t.Run("Should keep the previous version when a published post is edited", func(t *testing.T) {
+		original := usecase.MockPosts[0]
+		post := entity.Post{Title: "Edited title", Content: "Edited content"}
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.Update(t.Context(), original.AuthorId, original.Id, dto.PostUpdate{Post: post}); err != nil {
+			t.Errorf("Update should not return an error. Error: %v", err)
+		}
+
+		revisions, err := postUseCase.GetRevisions(t.Context(), original.Id, "")
+		if err != nil || len(revisions) == 0 {
+			t.Fatalf("GetRevisions should return revisions of edited post. Got: %v. Error: %v", revisions, err)
+		}
+		if revisions[0].Title != original.Title || revisions[0].Content != original.Content {
+			t.Errorf("GetRevisions should return the previous version first. Got: %v", revisions[0])
+		}
+	})
+
+	t.Run("Should not return revisions of someone else's draft", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if _, err := postUseCase.GetRevisions(t.Context(), usecase.MockDrafts[0].Id, "someone-else"); !errors.Is(err, ErrPostNotFound) {
+			t.Errorf("GetRevisions should return ErrPostNotFound for a hidden post. Got: %v", err)
+		}
+	})
+
+	t.Run("Should not edit a post after the edit window", func(t *testing.T) {
+		publishedAt := time.Now().Add(-time.Hour)
+		usecase.MockPosts[0].PublishedAt = &publishedAt
+		defer func() { usecase.MockPosts[0].PublishedAt = nil }()
+
+		post := entity.Post{Title: "Late title", Content: "Late content"}
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository()).WithEditWindow(15 * time.Minute)
+		err := postUseCase.Update(t.Context(), usecase.MockPosts[0].AuthorId, usecase.MockPosts[0].Id, dto.PostUpdate{Post: post})
+		if !errors.Is(err, ErrEditWindowOver) {
+			t.Errorf("Update should return ErrEditWindowOver. Got: %v", err)
+		}
+		if usecase.MockPosts[0].Title == "Late title" {
+			t.Errorf("Update should not change the post after the edit window")
+		}
+	})
+}
+
+func TestPostVisibility(t *testing.T) {
+	author := "4b0f6f8e-2c1d-4e5f-8a9b-0c1d2e3f4a00"
+	follower := "0d4f5a1c-5b5f-4a8e-9a43-3a1c1d0a4f11"
+	mentioned := "6a1e0b2f-27f4-4f5e-8e3b-6c2f0f5b7d22"
+	stranger := "c3b4a5d6-1e2f-4a3b-9c8d-7e6f5a4b3c33"
+	posts := usecase.MockPosts
+	usecase.MockPosts = append(slices.Clone(posts),
+		entity.Post{Id: 20, Title: "Public", Content: "Public", AuthorId: author, Status: entity.PostPublished, Visibility: entity.VisibilityPublic},
+		entity.Post{Id: 21, Title: "Followers", Content: "Followers", AuthorId: author, Status: entity.PostPublished, Visibility: entity.VisibilityFollowers},
+		entity.Post{Id: 22, Title: "Direct", Content: "@mentioned", AuthorId: author, Status: entity.PostPublished, Visibility: entity.VisibilityDirect},
+	)
+	usecase.MockPostFollowers[author] = []string{follower}
+	usecase.MockMentionedIds[22] = []string{mentioned}
+	defer func() {
+		usecase.MockPosts = posts
+		delete(usecase.MockPostFollowers, author)
+		delete(usecase.MockMentionedIds, 22)
+	}()
+
+	t.Run("Should show followers posts only to approved followers", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if post, _ := postUseCase.GetById(t.Context(), 21, follower); post.Id != 21 {
+			t.Errorf("GetById should return followers post to a follower. Got: %v", post)
+		}
+		if post, _ := postUseCase.GetById(t.Context(), 21, stranger); post.Id != 0 {
+			t.Errorf("GetById should not return followers post to a non follower. Got: %v", post)
+		}
+		if posts, _ := postUseCase.GetUserPosts(t.Context(), author, stranger); len(posts) != 1 || posts[0].Id != 20 {
+			t.Errorf("GetUserPosts should return only public posts to a non follower. Got: %v", posts)
+		}
+		if posts, _ := postUseCase.GetUserPosts(t.Context(), author, follower); len(posts) != 2 {
+			t.Errorf("GetUserPosts should return public and followers posts to a follower. Got: %v", posts)
+		}
+	})
+
+	t.Run("Should show direct posts only to mentioned users", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if post, _ := postUseCase.GetById(t.Context(), 22, mentioned); post.Id != 22 {
+			t.Errorf("GetById should return direct post to a mentioned user. Got: %v", post)
+		}
+		if post, _ := postUseCase.GetById(t.Context(), 22, follower); post.Id != 0 {
+			t.Errorf("GetById should not return direct post to a follower not mentioned. Got: %v", post)
+		}
+		if post, _ := postUseCase.GetById(t.Context(), 22, author); post.Id != 22 {
+			t.Errorf("GetById should return direct post to its author. Got: %v", post)
+		}
+	})
+
+	t.Run("Should keep visibility when it isn't sent on update", func(t *testing.T) {
+		post := entity.Post{Title: "Title", Content: "Content"}
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.Update(t.Context(), author, 21, dto.PostUpdate{Post: post}); err != nil {
+			t.Errorf("Update should not return an error. Error: %v", err)
+		}
+		if updated, _ := postUseCase.GetById(t.Context(), 21, author); updated.Visibility != entity.VisibilityFollowers {
+			t.Errorf("Update should keep current visibility. Got: %v", updated.Visibility)
+		}
+	})
+}
+
+func TestPinnedPosts(t *testing.T) {
+	author := "7e1f2a3b-4c5d-4e6f-8a9b-0c1d2e3f4a55"
+	posts := usecase.MockPosts
+	usecase.MockPosts = append(slices.Clone(posts),
+		entity.Post{Id: 40, Title: "First", Content: "First", AuthorId: author, Status: entity.PostPublished},
+		entity.Post{Id: 41, Title: "Second", Content: "Second", AuthorId: author, Status: entity.PostPublished},
+		entity.Post{Id: 42, Title: "Third", Content: "Third", AuthorId: author, Status: entity.PostPublished},
+	)
+	defer func() { usecase.MockPosts = posts }()
+
+	t.Run("Should return pinned posts first", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository()).WithMaxPinned(2)
+		if err := postUseCase.Pin(t.Context(), author, 42); err != nil {
+			t.Errorf("Pin should not return an error. Error: %v", err)
+		}
+
+		userPosts, _ := postUseCase.GetUserPosts(t.Context(), author, author)
+		if len(userPosts) != 3 || userPosts[0].Id != 42 || !userPosts[0].Pinned || userPosts[1].Pinned {
+			t.Errorf("GetUserPosts should return pinned posts first. Got: %v", userPosts)
+		}
+	})
+
+	t.Run("Should not pin more posts than allowed", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository()).WithMaxPinned(2)
+		if err := postUseCase.Pin(t.Context(), author, 41); err != nil {
+			t.Errorf("Pin should not return an error. Error: %v", err)
+		}
+		var epv *errorType.ErrorPostValidation
+		if err := postUseCase.Pin(t.Context(), author, 40); !errors.As(err, &epv) {
+			t.Errorf("Pin should return ErrorPostValidation over the limit. Got: %v", err)
+		}
+		if err := postUseCase.Pin(t.Context(), author, 41); err != nil {
+			t.Errorf("Pin should accept pinning an already pinned post. Error: %v", err)
+		}
+	})
+
+	t.Run("Should not pin or unpin someone else's post", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.Pin(t.Context(), "another-user", 40); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("Pin should return ErrAccessDenied. Got: %v", err)
+		}
+		if err := postUseCase.Unpin(t.Context(), "another-user", 42); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("Unpin should return ErrAccessDenied. Got: %v", err)
+		}
+	})
+
+	t.Run("Should unpin a post", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.Unpin(t.Context(), author, 42); err != nil {
+			t.Errorf("Unpin should not return an error. Error: %v", err)
+		}
+		if post, _ := postUseCase.GetById(t.Context(), 42, author); post.Pinned {
+			t.Errorf("Unpin should unpin the post. Got: %v", post)
+		}
+	})
+
+	t.Run("Should not pin a draft", func(t *testing.T) {
+		draft := usecase.MockDrafts[0]
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		var epv *errorType.ErrorPostValidation
+		if err := postUseCase.Pin(t.Context(), draft.AuthorId, draft.Id); !errors.As(err, &epv) {
+			t.Errorf("Pin should return ErrorPostValidation for a draft. Got: %v", err)
+		}
+	})
+}
+
+func TestSensitivePosts(t *testing.T) {
+	author := entity.User{Id: "7e1f2a3b-4c5d-4e6f-8a9b-0c1d2e3f4a66"}
+	moderator := entity.User{Id: "7e1f2a3b-4c5d-4e6f-8a9b-0c1d2e3f4a77", Moderator: true}
+	posts := usecase.MockPosts
+	usecase.MockPosts = append(slices.Clone(posts),
+		entity.Post{Id: 60, Title: "Spoilers", Content: "Spoilers", AuthorId: author.Id, Status: entity.PostPublished},
+	)
+	defer func() { usecase.MockPosts = posts }()
+
+	t.Run("Should let the author flag their post", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		err := postUseCase.SetSensitive(t.Context(), author, 60, dto.Sensitive{Sensitive: true, ContentWarning: " Ending "})
+		if err != nil {
+			t.Errorf("SetSensitive should not return an error. Error: %v", err)
+		}
+		if post, _ := postUseCase.GetById(t.Context(), 60, author.Id); !post.Sensitive || post.ContentWarning != "Ending" {
+			t.Errorf("SetSensitive should flag the post with the trimmed warning. Got: %v", post)
+		}
+
+		var epv *errorType.ErrorPostValidation
+		err = postUseCase.SetSensitive(t.Context(), author, 60, dto.Sensitive{Sensitive: true, ContentWarning: strings.Repeat("a", 101)})
+		if !errors.As(err, &epv) {
+			t.Errorf("SetSensitive should return ErrorPostValidation for a long warning. Got: %v", err)
+		}
+	})
+
+	t.Run("Should not let other users flag the post", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		err := postUseCase.SetSensitive(t.Context(), entity.User{Id: "another-user"}, 60, dto.Sensitive{Sensitive: true})
+		if !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("SetSensitive should return ErrAccessDenied. Got: %v", err)
+		}
+	})
+
+	t.Run("Should keep the flag of a moderator", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.SetSensitive(t.Context(), moderator, 60, dto.Sensitive{Sensitive: true}); err != nil {
+			t.Errorf("SetSensitive should let moderators flag any post. Error: %v", err)
+		}
+
+		var epv *errorType.ErrorPostValidation
+		if err := postUseCase.SetSensitive(t.Context(), author, 60, dto.Sensitive{}); !errors.As(err, &epv) {
+			t.Errorf("SetSensitive should not let the author remove the flag of a moderator. Got: %v", err)
+		}
+		if err := postUseCase.SetSensitive(t.Context(), moderator, 60, dto.Sensitive{}); err != nil {
+			t.Errorf("SetSensitive should let moderators remove the flag. Error: %v", err)
+		}
+		if post, _ := postUseCase.GetById(t.Context(), 60, author.Id); post.Sensitive || post.ContentWarning != "" {
+			t.Errorf("SetSensitive should unflag the post and clear the warning. Got: %v", post)
+		}
+	})
+
+	t.Run("Should keep the flag when it isn't sent on update", func(t *testing.T) {
+		postUseCase := NewPostUseCase(usecase.NewMockPostRepository())
+		if err := postUseCase.SetSensitive(t.Context(), author, 60, dto.Sensitive{Sensitive: true, ContentWarning: "Ending"}); err != nil {
+			t.Fatalf("SetSensitive should not return an error. Error: %v", err)
+		}
+
+		post := entity.Post{Title: "Spoilers", Content: "Edited spoilers"}
+		if err := postUseCase.Update(t.Context(), author.Id, 60, dto.PostUpdate{Post: post}); err != nil {
+			t.Errorf("Update should not return an error. Error: %v", err)
+		}
+		if updated, _ := postUseCase.GetById(t.Context(), 60, author.Id); !updated.Sensitive || updated.ContentWarning != "Ending" {
+			t.Errorf("Update should keep the flag and the warning. Got: %v", updated)
+		}
+
+		unflagged := false
+		if err := postUseCase.Update(t.Context(), author.Id, 60, dto.PostUpdate{Post: post, Sensitive: &unflagged}); err != nil {
+			t.Errorf("Update should not return an error. Error: %v", err)
+		}
+		if updated, _ := postUseCase.GetById(t.Context(), 60, author.Id); updated.Sensitive || updated.ContentWarning != "" {
+			t.Errorf("Update should unflag the post and clear the warning. Got: %v", updated)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/logging"
+	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/tracing"
+	"sort"
+	"time"
+)
+
+var ErrStoryNotFound = newNotFound("story_not_found", "story not found")
+
+const DefaultStoryLifetime = 24 * time.Hour
+
+// purgeBatchSize is how many expired stories are deleted at a time.
+const purgeBatchSize = 100
+
+type StoryUseCase struct {
+	storyRepository repository.Story
+	lifetime        time.Duration
+}
+
+func NewStoryUseCase(storyRepository repository.Story) *StoryUseCase {
+	return &StoryUseCase{
+		storyRepository: storyRepository,
+		lifetime:        DefaultStoryLifetime,
+	}
+}
+
+// WithLifetime sets how long new stories are shown before they expire.
+func (s *StoryUseCase) WithLifetime(lifetime time.Duration) *StoryUseCase {
+	s.lifetime = lifetime
+	return s
+}
+
+func (s *StoryUseCase) Create(ctx context.Context, story *entity.Story) error {
+	ctx, span := tracing.Start(ctx, "StoryUseCase.Create")
+	defer span.End()
+
+	if err := story.Prepare(); err != nil {
+		return err
+	}
+	story.CreatedAt = time.Now()
+	story.ExpiresAt = story.CreatedAt.Add(s.lifetime)
+
+	storyId, err := s.storyRepository.Create(ctx, *story)
+	if err != nil {
+		return err
+	}
+	story.Id = storyId
+
+	return nil
+}
+
+// GetRail returns the active stories the viewer can see grouped by author: their own first, then the authors with
+// stories they haven't seen yet.
+func (s *StoryUseCase) GetRail(ctx context.Context, viewerId string) ([]entity.StoryGroup, error) {
+	ctx, span := tracing.Start(ctx, "StoryUseCase.GetRail")
+	defer span.End()
+
+	stories, err := s.storyRepository.FetchRail(ctx, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []entity.StoryGroup{}
+	for _, story := range stories {
+		if len(groups) == 0 || groups[len(groups)-1].AuthorId != story.AuthorId {
+			groups = append(groups, entity.StoryGroup{AuthorId: story.AuthorId, AuthorNick: story.AuthorNick})
+		}
+		group := &groups[len(groups)-1]
+		group.Stories = append(group.Stories, story)
+		if !story.Seen && story.AuthorId != viewerId {
+			group.Unseen = true
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].AuthorId == viewerId) != (groups[j].AuthorId == viewerId) {
+			return groups[i].AuthorId == viewerId
+		}
+		return groups[i].Unseen && !groups[j].Unseen
+	})
+
+	return groups, nil
+}
+
+// View records that the viewer watched the story. Authors watching their own stories aren't counted.
+func (s *StoryUseCase) View(ctx context.Context, storyId uint64, viewerId string) error {
+	ctx, span := tracing.Start(ctx, "StoryUseCase.View")
+	defer span.End()
+
+	story, err := s.storyRepository.FetchById(ctx, storyId, viewerId)
+	if err != nil {
+		return notFound(err, ErrStoryNotFound)
+	}
+	if story.AuthorId == viewerId {
+		return nil
+	}
+
+	return s.storyRepository.View(ctx, storyId, viewerId)
+}
+
+// GetViews returns who watched a story. Only its author can see them.
+func (s *StoryUseCase) GetViews(ctx context.Context, storyId uint64, authorId string) ([]entity.StoryView, error) {
+	ctx, span := tracing.Start(ctx, "StoryUseCase.GetViews")
+	defer span.End()
+
+	story, err := s.storyRepository.FetchById(ctx, storyId, authorId)
+	if err != nil {
+		return nil, notFound(err, ErrStoryNotFound)
+	}
+	if story.AuthorId != authorId {
+		return nil, ErrAccessDenied
+	}
+
+	views, err := s.storyRepository.FetchViews(ctx, storyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return views, nil
+}
+
+// PurgeExpired hard-deletes expired stories along with their attached media and returns how many were deleted.
+// Stories whose media couldn't be removed are kept for the next run, and the ones after them are still purged.
+func (s *StoryUseCase) PurgeExpired(ctx context.Context, media *MediaUseCase) (int, error) {
+	ctx, span := tracing.Start(ctx, "StoryUseCase.PurgeExpired")
+	defer span.End()
+
+	purged := 0
+	var errs []error
+	var afterId uint64
+	for {
+		storyIds, err := s.storyRepository.FetchExpired(ctx, afterId, purgeBatchSize)
+		if err != nil || len(storyIds) == 0 {
+			return purged, errors.Join(append(errs, err)...)
+		}
+		afterId = storyIds[len(storyIds)-1]
+
+		stories := make([]entity.Story, len(storyIds))
+		for i, storyId := range storyIds {
+			stories[i].Id = storyId
+		}
+		if err = media.LoadStoryAttachments(ctx, stories); err != nil {
+			return purged, errors.Join(append(errs, err)...)
+		}
+		var purgedIds []uint64
+		for _, story := range stories {
+			if err = media.Purge(ctx, story.Attachments); err != nil {
+				errs = append(errs, fmt.Errorf("purging media of story %d: %w", story.Id, err))
+				continue
+			}
+			purgedIds = append(purgedIds, story.Id)
+		}
+		logging.FromContext(ctx).Debug("purging expired stories",
+			"stories", len(purgedIds),
+			"failed", len(storyIds)-len(purgedIds),
+		)
+
+		if len(purgedIds) > 0 {
+			if err = s.storyRepository.Delete(ctx, purgedIds); err != nil {
+				return purged, errors.Join(append(errs, err)...)
+			}
+			purged += len(purgedIds)
+		}
+		if len(storyIds) < purgeBatchSize {
+			return purged, errors.Join(errs...)
+		}
+	}
+}
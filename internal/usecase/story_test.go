@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/usecase/mock"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStories(t *testing.T) {
+	author := "93226a19-86d6-4ad7-a215-d5999c2870c4"
+	friend := "d9b56fd4-31b7-4bd5-958f-99028ca5e79a"
+	viewer := "5c6e2ab0-0c6b-4c3a-9df4-5a9a3b2b8f8e"
+	active := time.Now().Add(time.Hour)
+	usecase.MockStories = []entity.Story{
+		{Id: 1, AuthorId: author, Content: "Story 1", ExpiresAt: active},
+		{Id: 2, AuthorId: author, Content: "Story 2", ExpiresAt: active},
+		{Id: 3, AuthorId: friend, Content: "Story 3", ExpiresAt: active},
+		{Id: 4, AuthorId: friend, Content: "Expired", ExpiresAt: time.Now().Add(-time.Hour)},
+	}
+	usecase.MockStoryFollowers = map[string][]string{author: {viewer}, friend: {viewer}}
+	usecase.MockStoryViews = map[uint64][]string{1: {viewer}, 2: {viewer}}
+	defer func() {
+		usecase.MockStories = nil
+		usecase.MockStoryFollowers = map[string][]string{}
+		usecase.MockStoryViews = map[uint64][]string{}
+	}()
+
+	t.Run("Should create a story expiring after the lifetime", func(t *testing.T) {
+		story := entity.Story{AuthorId: author, Content: " Hello "}
+		storyUseCase := NewStoryUseCase(usecase.NewMockStoryRepository()).WithLifetime(time.Hour)
+		if err := storyUseCase.Create(t.Context(), &story); err != nil {
+			t.Fatalf("Create should not return an error. Error: %v", err)
+		}
+		if story.Id != usecase.NEW_STORY_ID || story.Content != "Hello" {
+			t.Errorf("Create should set id and trim content. Got: %v", story)
+		}
+		if lifetime := story.ExpiresAt.Sub(story.CreatedAt); lifetime != time.Hour {
+			t.Errorf("Create should expire the story after the lifetime. Got: %v", lifetime)
+		}
+
+		var esv *errorType.ErrorStoryValidation
+		if err := storyUseCase.Create(t.Context(), &entity.Story{AuthorId: author}); !errors.As(err, &esv) {
+			t.Errorf("Create should return ErrorStoryValidation without content or attachment. Got: %v", err)
+		}
+		if err := storyUseCase.Create(t.Context(), &entity.Story{AuthorId: author, AttachmentIds: []uint64{1, 2}}); !errors.As(err, &esv) {
+			t.Errorf("Create should return ErrorStoryValidation with too many attachments. Got: %v", err)
+		}
+	})
+
+	t.Run("Should group the rail by author with unseen stories first", func(t *testing.T) {
+		storyUseCase := NewStoryUseCase(usecase.NewMockStoryRepository())
+		groups, err := storyUseCase.GetRail(t.Context(), viewer)
+		if err != nil {
+			t.Fatalf("GetRail should not return an error. Error: %v", err)
+		}
+		if len(groups) != 2 {
+			t.Fatalf("GetRail should return a group per author with active stories. Got: %v", groups)
+		}
+		if groups[0].AuthorId != friend || !groups[0].Unseen || len(groups[0].Stories) != 1 {
+			t.Errorf("GetRail should put the unseen group first without expired stories. Got: %v", groups[0])
+		}
+		if groups[1].AuthorId != author || groups[1].Unseen || len(groups[1].Stories) != 2 {
+			t.Errorf("GetRail should put the seen group last. Got: %v", groups[1])
+		}
+
+		groups, _ = storyUseCase.GetRail(t.Context(), author)
+		if len(groups) != 1 || groups[0].AuthorId != author {
+			t.Errorf("GetRail should show the own stories of the author. Got: %v", groups)
+		}
+	})
+
+	t.Run("Should record views of other users only", func(t *testing.T) {
+		storyUseCase := NewStoryUseCase(usecase.NewMockStoryRepository())
+		if err := storyUseCase.View(t.Context(), 3, viewer); err != nil {
+			t.Errorf("View should not return an error. Error: %v", err)
+		}
+		if err := storyUseCase.View(t.Context(), 3, friend); err != nil || slices.Contains(usecase.MockStoryViews[3], friend) {
+			t.Errorf("View should not count the author. Got: %v, %v", usecase.MockStoryViews[3], err)
+		}
+		if err := storyUseCase.View(t.Context(), 3, "stranger"); !errors.Is(err, ErrStoryNotFound) {
+			t.Errorf("View should return ErrStoryNotFound to non followers. Got: %v", err)
+		}
+		if err := storyUseCase.View(t.Context(), 4, viewer); !errors.Is(err, ErrStoryNotFound) {
+			t.Errorf("View should return ErrStoryNotFound for expired stories. Got: %v", err)
+		}
+	})
+
+	t.Run("Should show views to the author only", func(t *testing.T) {
+		storyUseCase := NewStoryUseCase(usecase.NewMockStoryRepository())
+		views, err := storyUseCase.GetViews(t.Context(), 1, author)
+		if err != nil || len(views) != 1 || views[0].ViewerId != viewer {
+			t.Errorf("GetViews should return the viewers to the author. Got: %v, %v", views, err)
+		}
+		if _, err = storyUseCase.GetViews(t.Context(), 1, viewer); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("GetViews should return ErrAccessDenied to other users. Got: %v", err)
+		}
+		if _, err = storyUseCase.GetViews(t.Context(), 1, usecase.STORY_ERROR); err == nil {
+			t.Errorf("GetViews should return the repository error. Got: %v", err)
+		}
+	})
+
+	t.Run("Should purge expired stories and their media", func(t *testing.T) {
+		originalAttachments := append([]entity.Attachment(nil), usecase.MockAttachments...)
+		defer func() { usecase.MockAttachments = originalAttachments }()
+		expiredId := uint64(4)
+		usecase.MockAttachments = append(usecase.MockAttachments, entity.Attachment{
+			Id:           5,
+			StoryId:      &expiredId,
+			OwnerId:      friend,
+			Key:          "5.jpg",
+			ThumbnailKey: "5_thumb.jpg",
+		})
+		store := usecase.NewMockBlobStore()
+		store.Blobs["5.jpg"] = []byte("a")
+		store.Blobs["5_thumb.jpg"] = []byte("b")
+		mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), store, 1<<20)
+
+		storyUseCase := NewStoryUseCase(usecase.NewMockStoryRepository())
+		purged, err := storyUseCase.PurgeExpired(context.Background(), mediaUseCase)
+		if err != nil || purged != 1 {
+			t.Errorf("PurgeExpired should delete the expired story. Got: %v, %v", purged, err)
+		}
+		if len(usecase.MockStories) != 3 {
+			t.Errorf("PurgeExpired should keep active stories. Got: %v", usecase.MockStories)
+		}
+		if len(store.Blobs) != 0 || len(usecase.MockAttachments) != len(originalAttachments) {
+			t.Errorf("PurgeExpired should delete the media of expired stories. Got: %v, %v",
+				store.Blobs,
+				usecase.MockAttachments,
+			)
+		}
+	})
+
+	t.Run("Should keep stories whose media can't be purged and purge the ones after them", func(t *testing.T) {
+		originalAttachments := append([]entity.Attachment(nil), usecase.MockAttachments...)
+		defer func() { usecase.MockAttachments = originalAttachments }()
+		failingId := uint64(5)
+		usecase.MockStories = append(usecase.MockStories,
+			entity.Story{Id: 5, AuthorId: friend, Content: "Failing", ExpiresAt: time.Now().Add(-time.Hour)},
+			entity.Story{Id: 6, AuthorId: friend, Content: "Expired", ExpiresAt: time.Now().Add(-time.Hour)},
+		)
+		usecase.MockAttachments = append(usecase.MockAttachments, entity.Attachment{
+			Id:           6,
+			StoryId:      &failingId,
+			OwnerId:      friend,
+			Key:          "6.jpg",
+			ThumbnailKey: "6_thumb.jpg",
+		})
+		store := usecase.NewMockBlobStore()
+		store.Blobs["6.jpg"] = []byte("a")
+		store.DeleteErrors = map[string]error{"6.jpg": errors.New("storage unavailable")}
+		mediaUseCase := NewMediaUseCase(usecase.NewMockMediaRepository(), store, 1<<20)
+		storyUseCase := NewStoryUseCase(usecase.NewMockStoryRepository())
+
+		purged, err := storyUseCase.PurgeExpired(context.Background(), mediaUseCase)
+		if err == nil || purged != 1 {
+			t.Errorf("PurgeExpired should purge the other stories and report the failure. Got: %v, %v", purged, err)
+		}
+		ids := make([]uint64, 0, len(usecase.MockStories))
+		for _, story := range usecase.MockStories {
+			ids = append(ids, story.Id)
+		}
+		if !slices.Equal(ids, []uint64{1, 2, 3, 5}) {
+			t.Errorf("PurgeExpired should keep only the story whose media failed. Got: %v", ids)
+		}
+
+		store.DeleteErrors = nil
+		if purged, err = storyUseCase.PurgeExpired(context.Background(), mediaUseCase); err != nil || purged != 1 {
+			t.Errorf("PurgeExpired should purge the kept story on the next run. Got: %v, %v", purged, err)
+		}
+	})
+}
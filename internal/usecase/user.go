@@ -1,16 +1,39 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/edigar/socialnets-api/internal/dto"
 	"github.com/edigar/socialnets-api/internal/entity"
+	"github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/metrics"
 	"github.com/edigar/socialnets-api/internal/repository"
+	"github.com/edigar/socialnets-api/internal/tracing"
 	"github.com/edigar/socialnets-api/pkg/crypt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 )
 
 var (
-	ErrOperationDenied = errors.New("operation denied")
-	ErrWrongPassword   = errors.New("wrong password")
+	ErrOperationDenied       = errorType.NewCodedError("operation_denied", "operation denied")
+	ErrWrongPassword         = errorType.NewCodedError("wrong_password", "wrong password")
+	ErrFollowRequestNotFound = newNotFound("follow_request_not_found", "follow request not found")
+	ErrAccountSuspended      = errorType.NewCodedError("account_suspended", "account suspended")
+)
+
+var uuidPattern = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+const (
+	MaxRelationships = 100
+	MaxSuggestions   = 50
+	// suggestionPoolFactor is how many candidates are fetched per returned suggestion before ranking.
+	suggestionPoolFactor = 5
+	// activityHalfLife is the time after which the activity boost of an account is halved.
+	activityHalfLife = 14 * 24 * time.Hour
 )
 
 type UserUseCase struct {
@@ -23,26 +46,43 @@ func NewUserUseCase(userRepository repository.User) *UserUseCase {
 	}
 }
 
-func (u *UserUseCase) Login(email string, password string) (string, error) {
-	user, err := u.userRepository.FetchByEmail(email)
+func (u *UserUseCase) Login(ctx context.Context, email string, password string) (string, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Login")
+	defer span.End()
+
+	user, err := u.userRepository.FetchByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		metrics.Login(metrics.LoginFailure)
+		return "", err
+	}
 	if err != nil {
+		metrics.Login(metrics.LoginError)
 		return "", err
 	}
 
 	if err = crypt.Verify(user.Password, password); err != nil {
+		metrics.Login(metrics.LoginFailure)
 		return "", err
 	}
+	if user.Suspended {
+		metrics.Login(metrics.LoginFailure)
+		return "", ErrAccountSuspended
+	}
 
+	metrics.Login(metrics.LoginSuccess)
 	return user.Id, nil
 }
 
-func (u *UserUseCase) Register(user *entity.User) error {
+func (u *UserUseCase) Register(ctx context.Context, user *entity.User) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Register")
+	defer span.End()
+
 	err := user.Prepare("register")
 	if err != nil {
 		return err
 	}
 
-	user.Id, err = u.userRepository.Create(*user)
+	user.Id, err = u.userRepository.Create(ctx, *user)
 	if err != nil {
 		return err
 	}
@@ -50,8 +90,11 @@ func (u *UserUseCase) Register(user *entity.User) error {
 	return nil
 }
 
-func (u *UserUseCase) GetById(id string) (entity.User, error) {
-	user, err := u.userRepository.FetchById(id)
+func (u *UserUseCase) GetById(ctx context.Context, id string) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetById")
+	defer span.End()
+
+	user, err := u.userRepository.FetchById(ctx, id)
 	if err != nil {
 		return entity.User{}, err
 	}
@@ -59,8 +102,24 @@ func (u *UserUseCase) GetById(id string) (entity.User, error) {
 	return user, nil
 }
 
-func (u *UserUseCase) GetByNameOrNick(nameOrNick string) ([]entity.User, error) {
-	user, err := u.userRepository.FetchByNameOrNick(nameOrNick)
+// GetProfile returns the profile of userId as seen by viewerId, or repository.ErrNotFound if the user doesn't exist.
+func (u *UserUseCase) GetProfile(ctx context.Context, userId string, viewerId string) (entity.Profile, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetProfile")
+	defer span.End()
+
+	profile, err := u.userRepository.FetchProfile(ctx, userId)
+	if err != nil {
+		return entity.Profile{}, err
+	}
+
+	return profile.VisibleTo(viewerId), nil
+}
+
+func (u *UserUseCase) GetByNameOrNick(ctx context.Context, nameOrNick string) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetByNameOrNick")
+	defer span.End()
+
+	user, err := u.userRepository.FetchByNameOrNick(ctx, nameOrNick)
 	if err != nil {
 		return nil, err
 	}
@@ -68,53 +127,80 @@ func (u *UserUseCase) GetByNameOrNick(nameOrNick string) ([]entity.User, error)
 	return user, nil
 }
 
-func (u *UserUseCase) Update(userId string, user entity.User) error {
+func (u *UserUseCase) Update(ctx context.Context, userId string, user entity.User) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Update")
+	defer span.End()
+
 	err := user.Prepare("edit")
 	if err != nil {
 		return err
 	}
 
-	if err = u.userRepository.Update(userId, user); err != nil {
+	if err = u.userRepository.Update(ctx, userId, user); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (u *UserUseCase) Delete(userId string) error {
-	if err := u.userRepository.Delete(userId); err != nil {
+func (u *UserUseCase) Delete(ctx context.Context, userId string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Delete")
+	defer span.End()
+
+	if err := u.userRepository.Delete(ctx, userId); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (u *UserUseCase) Follow(userId string, follower string) error {
+func (u *UserUseCase) Follow(ctx context.Context, userId string, follower string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Follow")
+	defer span.End()
+
 	if follower == userId {
 		return ErrOperationDenied
 	}
 
-	if err := u.userRepository.Follow(userId, follower); err != nil {
+	blocked, err := u.userRepository.IsBlocked(ctx, userId, follower)
+	if err != nil {
 		return err
 	}
+	if blocked {
+		return ErrOperationDenied
+	}
+
+	followed, err := u.userRepository.Follow(ctx, userId, follower)
+	if err != nil {
+		return err
+	}
+	if followed {
+		metrics.Follows.Inc()
+	}
 
 	return nil
 }
 
-func (u *UserUseCase) Unfollow(userId string, follower string) error {
+func (u *UserUseCase) Unfollow(ctx context.Context, userId string, follower string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Unfollow")
+	defer span.End()
+
 	if follower == userId {
 		return ErrOperationDenied
 	}
 
-	if err := u.userRepository.Unfollow(userId, follower); err != nil {
+	if err := u.userRepository.Unfollow(ctx, userId, follower); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (u *UserUseCase) GetFollowers(userId string) ([]entity.User, error) {
-	users, err := u.userRepository.FetchFollowers(userId)
+func (u *UserUseCase) GetFollowers(ctx context.Context, userId string) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetFollowers")
+	defer span.End()
+
+	users, err := u.userRepository.FetchFollowers(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -122,8 +208,11 @@ func (u *UserUseCase) GetFollowers(userId string) ([]entity.User, error) {
 	return users, nil
 }
 
-func (u *UserUseCase) GetFollowing(userId string) ([]entity.User, error) {
-	users, err := u.userRepository.FetchFollowing(userId)
+func (u *UserUseCase) GetFollowing(ctx context.Context, userId string) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetFollowing")
+	defer span.End()
+
+	users, err := u.userRepository.FetchFollowing(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -131,8 +220,11 @@ func (u *UserUseCase) GetFollowing(userId string) ([]entity.User, error) {
 	return users, nil
 }
 
-func (u *UserUseCase) UpdatePassword(userId string, password dto.Password) error {
-	passwordDb, err := u.userRepository.FetchPasswordById(userId)
+func (u *UserUseCase) UpdatePassword(ctx context.Context, userId string, password dto.Password) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.UpdatePassword")
+	defer span.End()
+
+	passwordDb, err := u.userRepository.FetchPasswordById(ctx, userId)
 	if err != nil {
 		return err
 	}
@@ -146,9 +238,256 @@ func (u *UserUseCase) UpdatePassword(userId string, password dto.Password) error
 		return err
 	}
 
-	if err = u.userRepository.UpdatePassword(userId, string(passwordHash)); err != nil {
+	if err = u.userRepository.UpdatePassword(ctx, userId, string(passwordHash)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetPassword replaces the password of a user without asking for the current one.
+func (u *UserUseCase) ResetPassword(ctx context.Context, userId string, password string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ResetPassword")
+	defer span.End()
+
+	if _, err := u.userRepository.FetchPasswordById(ctx, userId); err != nil {
+		return err
+	}
+
+	passwordHash, err := crypt.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.UpdatePassword(ctx, userId, string(passwordHash))
+}
+
+// SetSuspended suspends or reinstates an account. Suspended accounts can't log in nor use tokens issued before.
+func (u *UserUseCase) SetSuspended(ctx context.Context, userId string, suspended bool) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.SetSuspended")
+	defer span.End()
+
+	return u.userRepository.SetSuspended(ctx, userId, suspended)
+}
+
+// IsSuspended reports whether the account is suspended.
+func (u *UserUseCase) IsSuspended(ctx context.Context, userId string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.IsSuspended")
+	defer span.End()
+
+	return u.userRepository.IsSuspended(ctx, userId)
+}
+
+// SetModerator grants or revokes the moderator role.
+func (u *UserUseCase) SetModerator(ctx context.Context, userId string, moderator bool) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.SetModerator")
+	defer span.End()
+
+	return u.userRepository.SetModerator(ctx, userId, moderator)
+}
+
+// UpdateAvatar sets the avatar of a user and returns the key of the replaced one.
+func (u *UserUseCase) UpdateAvatar(ctx context.Context, userId string, avatarKey string) (string, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.UpdateAvatar")
+	defer span.End()
+
+	previousKey, err := u.userRepository.UpdateAvatar(ctx, userId, avatarKey)
+	if err != nil {
+		return "", err
+	}
+
+	return previousKey, nil
+}
+
+func (u *UserUseCase) Block(ctx context.Context, userId string, blocked string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Block")
+	defer span.End()
+
+	if userId == blocked {
+		return ErrOperationDenied
+	}
+
+	if err := u.userRepository.Block(ctx, userId, blocked); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) Unblock(ctx context.Context, userId string, blocked string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Unblock")
+	defer span.End()
+
+	if userId == blocked {
+		return ErrOperationDenied
+	}
+
+	if err := u.userRepository.Unblock(ctx, userId, blocked); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSuggestions returns accounts followed by people userId follows, ranked by mutual connections and recent activity.
+func (u *UserUseCase) GetSuggestions(ctx context.Context, userId string, limit int) ([]entity.Suggestion, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetSuggestions")
+	defer span.End()
+
+	if limit <= 0 || limit > MaxSuggestions {
+		limit = MaxSuggestions
+	}
+
+	suggestions, err := u.userRepository.FetchSuggestionCandidates(ctx, userId, limit*suggestionPoolFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions = rankSuggestions(suggestions, time.Now())
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	for i := range suggestions {
+		suggestions[i].Explain()
+	}
+
+	return suggestions, nil
+}
+
+// rankSuggestions orders suggestions by mutual connections weighted by how recently each account posted:
+// an account that never posted counts half of its mutuals, one that posted just now counts all of them plus half.
+func rankSuggestions(suggestions []entity.Suggestion, now time.Time) []entity.Suggestion {
+	scores := make(map[string]float64, len(suggestions))
+	for _, suggestion := range suggestions {
+		activity := 0.0
+		if suggestion.LastActivity != nil {
+			age := now.Sub(*suggestion.LastActivity)
+			activity = math.Pow(0.5, age.Hours()/activityHalfLife.Hours())
+		}
+		scores[suggestion.Id] = float64(suggestion.Mutuals) * (0.5 + activity)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return scores[suggestions[i].Id] > scores[suggestions[j].Id]
+	})
+
+	return suggestions
+}
+
+func (u *UserUseCase) Mute(ctx context.Context, userId string, muted string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Mute")
+	defer span.End()
+
+	if userId == muted {
+		return ErrOperationDenied
+	}
+
+	if err := u.userRepository.Mute(ctx, userId, muted); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) Unmute(ctx context.Context, userId string, muted string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Unmute")
+	defer span.End()
+
+	if userId == muted {
+		return ErrOperationDenied
+	}
+
+	if err := u.userRepository.Unmute(ctx, userId, muted); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) GetFollowRequests(ctx context.Context, userId string) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetFollowRequests")
+	defer span.End()
+
+	users, err := u.userRepository.FetchFollowRequests(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (u *UserUseCase) AcceptFollowRequest(ctx context.Context, userId string, follower string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.AcceptFollowRequest")
+	defer span.End()
+
+	found, err := u.userRepository.AcceptFollowRequest(ctx, userId, follower)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrFollowRequestNotFound
+	}
+	metrics.Follows.Inc()
+
+	return nil
+}
+
+func (u *UserUseCase) RejectFollowRequest(ctx context.Context, userId string, follower string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.RejectFollowRequest")
+	defer span.End()
+
+	found, err := u.userRepository.RejectFollowRequest(ctx, userId, follower)
+	if err != nil {
 		return err
 	}
+	if !found {
+		return ErrFollowRequestNotFound
+	}
 
 	return nil
 }
+
+// GetRelationships returns how userId relates to each account of otherIds, in the requested order.
+func (u *UserUseCase) GetRelationships(ctx context.Context, userId string, otherIds []string) ([]entity.Relationship, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetRelationships")
+	defer span.End()
+
+	if len(otherIds) == 0 {
+		return []entity.Relationship{}, nil
+	}
+	if len(otherIds) > MaxRelationships {
+		return nil, errorType.NewErrorUserValidation(
+			errorType.NewFieldError("ids", errorType.CodeTooMany, fmt.Sprintf("at most %d ids are allowed", MaxRelationships)),
+		)
+	}
+	var fields []errorType.FieldError
+	for _, id := range otherIds {
+		if !uuidPattern.MatchString(id) {
+			fields = append(fields, errorType.NewFieldError("ids", errorType.CodeInvalid, fmt.Sprintf("invalid user id %q", id)))
+		}
+	}
+	if len(fields) > 0 {
+		return nil, errorType.NewErrorUserValidation(fields...)
+	}
+
+	relationships, err := u.userRepository.FetchRelationships(ctx, userId, otherIds)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]entity.Relationship, len(relationships))
+	for _, relationship := range relationships {
+		byId[strings.ToLower(relationship.Id)] = relationship
+	}
+
+	ordered := make([]entity.Relationship, 0, len(otherIds))
+	for _, id := range otherIds {
+		relationship, ok := byId[strings.ToLower(id)]
+		if !ok {
+			relationship = entity.Relationship{Id: id}
+		}
+		ordered = append(ordered, relationship)
+	}
+
+	return ordered, nil
+}
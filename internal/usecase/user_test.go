@@ -1,15 +1,18 @@
 package usecase
 
 import (
-	"database/sql"
 	"errors"
 	"github.com/edigar/socialnets-api/internal/dto"
 	"github.com/edigar/socialnets-api/internal/entity"
 	errorType "github.com/edigar/socialnets-api/internal/error_type"
+	"github.com/edigar/socialnets-api/internal/metrics"
+	"github.com/edigar/socialnets-api/internal/repository"
 	"github.com/edigar/socialnets-api/internal/usecase/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"golang.org/x/crypto/bcrypt"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLogin(t *testing.T) {
@@ -17,7 +20,7 @@ func TestLogin(t *testing.T) {
 		userPassword := "123"
 
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		userId, err := userUseCase.Login(usecase.MockUsers[0].Email, userPassword)
+		userId, err := userUseCase.Login(t.Context(), usecase.MockUsers[0].Email, userPassword)
 
 		if err != nil {
 			t.Errorf("Login should not return an error for a valid email and password: %v. User: %v",
@@ -36,12 +39,12 @@ func TestLogin(t *testing.T) {
 		userPassword := "123"
 
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		userId, err := userUseCase.Login("x", userPassword)
+		userId, err := userUseCase.Login(t.Context(), "x", userPassword)
 
-		if !errors.Is(err, sql.ErrNoRows) {
+		if !errors.Is(err, repository.ErrNotFound) {
 			t.Errorf("Login should return an error for a wrong email. Returned: %v. Error expected: %v",
 				err,
-				sql.ErrNoRows,
+				repository.ErrNotFound,
 			)
 		} else if userId != "" {
 			t.Errorf("Login should return empty user id for wrong e-mail. Got: %v.", userId)
@@ -52,7 +55,7 @@ func TestLogin(t *testing.T) {
 		userPassword := "1"
 
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		userId, err := userUseCase.Login(usecase.MockUsers[0].Email, userPassword)
+		userId, err := userUseCase.Login(t.Context(), usecase.MockUsers[0].Email, userPassword)
 
 		if !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			t.Errorf("Login should return an error for a wrong email. Returned: %v. Error expected: %v",
@@ -63,6 +66,19 @@ func TestLogin(t *testing.T) {
 			t.Errorf("Login should return empty user id for wrong password. Got: %v.", userId)
 		}
 	})
+
+	t.Run("Should not login a suspended user", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		if err := userUseCase.SetSuspended(t.Context(), usecase.MockUsers[0].Id, true); err != nil {
+			t.Fatalf("SetSuspended should not return an error. Error: %v", err)
+		}
+		defer func() { usecase.MockUsers[0].Suspended = false }()
+
+		userId, err := userUseCase.Login(t.Context(), usecase.MockUsers[0].Email, "123")
+		if !errors.Is(err, ErrAccountSuspended) || userId != "" {
+			t.Errorf("Login should return ErrAccountSuspended for a suspended user. Got: %q. Error: %v", userId, err)
+		}
+	})
 }
 
 func TestRegister(t *testing.T) {
@@ -75,7 +91,7 @@ func TestRegister(t *testing.T) {
 		}
 
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Register(&user)
+		err := userUseCase.Register(t.Context(), &user)
 		if err != nil {
 			t.Errorf("Register should not return an error for a valid user data. User: %v. Error: %v", user, err)
 		} else if user.Id != usecase.NEW_USER_ID {
@@ -124,7 +140,7 @@ func TestRegister(t *testing.T) {
 
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
 		for _, scenario := range scenarios {
-			err := userUseCase.Register(&scenario)
+			err := userUseCase.Register(t.Context(), &scenario)
 			var euv *errorType.ErrorUserValidation
 			if !errors.As(err, &euv) {
 				t.Errorf("Register should return an ErrorUserValidation error for a non-valid user data. User: %v Returned: %v. Error expected: %T",
@@ -142,7 +158,7 @@ func TestRegister(t *testing.T) {
 func TestGetUserById(t *testing.T) {
 	t.Run("Should return valid user by his id", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		user, err := userUseCase.GetById(usecase.MockUsers[0].Id)
+		user, err := userUseCase.GetById(t.Context(), usecase.MockUsers[0].Id)
 		if err != nil {
 			t.Errorf("GetById should not return an error for a valid Id. User Id: %v. User returned: %v Error: %v",
 				usecase.MockUsers[0].Id,
@@ -159,12 +175,12 @@ func TestGetUserById(t *testing.T) {
 
 	t.Run("should return an error for a non-existent id", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		user, err := userUseCase.GetById("wrong-id")
+		user, err := userUseCase.GetById(t.Context(), "wrong-id")
 
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("GetById should return ErrNoRows error for a wrong id. Got: %v. Error expected: %v",
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetById should return ErrNotFound error for a wrong id. Got: %v. Error expected: %v",
 				err,
-				sql.ErrNoRows,
+				repository.ErrNotFound,
 			)
 		} else if user != (entity.User{}) {
 			t.Errorf("GetById should return empty user for wrong id. Got: %v.", user)
@@ -173,12 +189,12 @@ func TestGetUserById(t *testing.T) {
 
 	t.Run("should return an error for an empty id", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		user, err := userUseCase.GetById("")
+		user, err := userUseCase.GetById(t.Context(), "")
 
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("GetById should return ErrNoRows error for an empty id. Got: %v. Error expected: %v",
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetById should return ErrNotFound error for an empty id. Got: %v. Error expected: %v",
 				err,
-				sql.ErrNoRows,
+				repository.ErrNotFound,
 			)
 		} else if user != (entity.User{}) {
 			t.Errorf("GetById should return empty user for empty id. Got: %v.", user)
@@ -186,10 +202,95 @@ func TestGetUserById(t *testing.T) {
 	})
 }
 
+func TestGetProfile(t *testing.T) {
+	t.Run("Should return profile with e-mail to its owner", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		profile, err := userUseCase.GetProfile(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[0].Id)
+		if err != nil {
+			t.Errorf("GetProfile should not return an error for a valid id. Error: %v", err)
+		}
+
+		if profile.Email != usecase.MockUsers[0].Email {
+			t.Errorf("GetProfile should return e-mail to the profile owner. Got: %v", profile.Email)
+		}
+		if profile.Followers != 2 || profile.Following != 1 || profile.Posts != 3 {
+			t.Errorf("GetProfile should return profile counters. Got: %v", profile)
+		}
+	})
+
+	t.Run("Should hide e-mail from other users", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		profile, err := userUseCase.GetProfile(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id)
+		if err != nil {
+			t.Errorf("GetProfile should not return an error for a valid id. Error: %v", err)
+		}
+
+		if profile.Email != "" {
+			t.Errorf("GetProfile should hide e-mail from other users. Got: %v", profile.Email)
+		}
+		if profile.Nick != usecase.MockUsers[0].Nick {
+			t.Errorf("GetProfile should return public data. Got: %v", profile)
+		}
+	})
+
+	t.Run("Should return ErrNotFound for a non-existent user", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		profile, err := userUseCase.GetProfile(t.Context(), "x", usecase.MockUsers[0].Id)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetProfile should return ErrNotFound for a non-existent user. Error: %v", err)
+		}
+		if profile.Id != "" {
+			t.Errorf("GetProfile should return empty profile for a non-existent user. Got: %v", profile)
+		}
+	})
+}
+
+func TestUpdateAvatar(t *testing.T) {
+	t.Run("Should update avatar and return previous key", func(t *testing.T) {
+		usecase.MockUsers[0].AvatarKey = "old_avatar.jpg"
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		previousKey, err := userUseCase.UpdateAvatar(t.Context(), usecase.MockUsers[0].Id, "new_avatar.jpg")
+		if err != nil {
+			t.Errorf("UpdateAvatar should not return an error for a valid user. Error: %v", err)
+		}
+
+		if previousKey != "old_avatar.jpg" {
+			t.Errorf("UpdateAvatar should return previous avatar key. Got: %v", previousKey)
+		}
+		if usecase.MockUsers[0].AvatarKey != "new_avatar.jpg" {
+			t.Errorf("UpdateAvatar should set new avatar key. Got: %v", usecase.MockUsers[0].AvatarKey)
+		}
+
+		usecase.MockUsers[0].AvatarKey = ""
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Run("Should replace the password without the current one", func(t *testing.T) {
+		password := usecase.MockUsers[1].Password
+		defer func() { usecase.MockUsers[1].Password = password }()
+
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		if err := userUseCase.ResetPassword(t.Context(), usecase.MockUsers[1].Id, "new-password"); err != nil {
+			t.Errorf("ResetPassword should not return an error for a valid user. Error: %v", err)
+		}
+		if _, err := userUseCase.Login(t.Context(), usecase.MockUsers[1].Email, "new-password"); err != nil {
+			t.Errorf("Login should accept the new password. Error: %v", err)
+		}
+	})
+
+	t.Run("Should return an error for a non-existent user", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		if err := userUseCase.ResetPassword(t.Context(), "x", "new-password"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("ResetPassword should return repository.ErrNotFound for a non-existent user. Got: %v", err)
+		}
+	})
+}
+
 func TestGetUserByNameOrNick(t *testing.T) {
 	t.Run("Should return one user by his name", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		users, err := userUseCase.GetByNameOrNick(usecase.MockUsers[0].Name)
+		users, err := userUseCase.GetByNameOrNick(t.Context(), usecase.MockUsers[0].Name)
 		if err != nil {
 			t.Errorf("GetByNameOrNick should not return an error for a valid name. User name: %v. Users returned: %v Error: %v",
 				usecase.MockUsers[0].Name,
@@ -203,7 +304,7 @@ func TestGetUserByNameOrNick(t *testing.T) {
 
 	t.Run("Should return one user by his nickname", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		users, err := userUseCase.GetByNameOrNick(usecase.MockUsers[0].Nick)
+		users, err := userUseCase.GetByNameOrNick(t.Context(), usecase.MockUsers[0].Nick)
 		if err != nil {
 			t.Errorf("GetByNameOrNick should not return an error for a valid nickname. User nickname: %v. Users returned: %v Error: %v",
 				usecase.MockUsers[0].Nick,
@@ -212,13 +313,15 @@ func TestGetUserByNameOrNick(t *testing.T) {
 			)
 		} else if len(users) != 1 {
 			t.Errorf("GetByNameOrNick should return just one user with valid nickname. Got %v.", users)
+		} else if users[0].Email != "" {
+			t.Errorf("GetByNameOrNick should not return the e-mail of users. Got %v.", users[0].Email)
 		}
 	})
 
 	t.Run("Should return some users by his name or nick", func(t *testing.T) {
 		str := "ano"
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		users, err := userUseCase.GetByNameOrNick(str)
+		users, err := userUseCase.GetByNameOrNick(t.Context(), str)
 		if err != nil {
 			t.Errorf("GetByNameOrNick should not return an error for a valid string. string: %v. Users returned: %v Error: %v",
 				str,
@@ -233,7 +336,7 @@ func TestGetUserByNameOrNick(t *testing.T) {
 	t.Run("Should return empty list if no find user", func(t *testing.T) {
 		str := "x"
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		users, err := userUseCase.GetByNameOrNick(str)
+		users, err := userUseCase.GetByNameOrNick(t.Context(), str)
 		if err != nil {
 			t.Errorf("GetByNameOrNick should not return an error for a valid string. string: %v. Users returned: %v Error: %v",
 				str,
@@ -247,7 +350,7 @@ func TestGetUserByNameOrNick(t *testing.T) {
 
 	t.Run("Should get an DB error", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		users, err := userUseCase.GetByNameOrNick(usecase.USER_ERROR)
+		users, err := userUseCase.GetByNameOrNick(t.Context(), usecase.USER_ERROR)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("GetByNameOrNick should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -262,7 +365,7 @@ func TestUpdateUser(t *testing.T) {
 	t.Run("Should update user with valid id", func(t *testing.T) {
 		user := entity.User{Name: "Test", Nick: "test", Email: "test@test"}
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Update(usecase.MockUsers[0].Id, user)
+		err := userUseCase.Update(t.Context(), usecase.MockUsers[0].Id, user)
 		if err != nil {
 			t.Errorf("Update should not return an error with valid data. Data sended: %v. User updated: %v Error: %v",
 				user,
@@ -278,7 +381,7 @@ func TestUpdateUser(t *testing.T) {
 		user := entity.User{Name: "Test", Nick: "test", Email: "test@test"}
 		originalUsers := usecase.MockUsers
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Update("x", user)
+		err := userUseCase.Update(t.Context(), "x", user)
 		if err != nil {
 			t.Errorf("Update should not return an error with valid data. Data sended: %v. User updated: %v Error: %v",
 				user,
@@ -319,7 +422,7 @@ func TestUpdateUser(t *testing.T) {
 		originalUsers := usecase.MockUsers
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
 		for _, scenario := range scenarios {
-			err := userUseCase.Update(usecase.MockUsers[0].Id, scenario)
+			err := userUseCase.Update(t.Context(), usecase.MockUsers[0].Id, scenario)
 			var euv *errorType.ErrorUserValidation
 			if !errors.As(err, &euv) {
 				t.Errorf("Update should return an ErrorUserValidation error for a non-valid user data. User: %v Error returned: %v. Error expected: %T",
@@ -336,7 +439,7 @@ func TestUpdateUser(t *testing.T) {
 	t.Run("Should get an DB error", func(t *testing.T) {
 		user := entity.User{Name: "Test", Nick: "test", Email: "test@test"}
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Update(usecase.USER_ERROR, user)
+		err := userUseCase.Update(t.Context(), usecase.USER_ERROR, user)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("Update should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -346,25 +449,261 @@ func TestUpdateUser(t *testing.T) {
 func TestFollow(t *testing.T) {
 	t.Run("Should return ErrOperationDenied if user id is equal to follower id", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Follow(usecase.MockUsers[0].Id, usecase.MockUsers[0].Id)
+		err := userUseCase.Follow(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[0].Id)
 		if !errors.Is(err, ErrOperationDenied) {
 			t.Errorf("Follow should return ErrOperationDenied if user id is equal to follower id. Got %v.", err)
 		}
 	})
 
+	t.Run("Should return ErrOperationDenied if user is blocked", func(t *testing.T) {
+		usecase.MockBlocks[usecase.MockUsers[0].Id] = usecase.MockUsers[1].Id
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		err := userUseCase.Follow(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id)
+		if !errors.Is(err, ErrOperationDenied) {
+			t.Errorf("Follow should return ErrOperationDenied if one user blocked the other. Got %v.", err)
+		}
+
+		delete(usecase.MockBlocks, usecase.MockUsers[0].Id)
+	})
+
+	t.Run("Should count only new follows", func(t *testing.T) {
+		author := usecase.MockUsers[1].Id
+		defer func() {
+			delete(usecase.MockPostFollowers, author)
+			delete(usecase.MockTimelines, usecase.MockUsers[0].Id)
+		}()
+
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		before := testutil.ToFloat64(metrics.Follows)
+		_ = userUseCase.Follow(t.Context(), author, usecase.MockUsers[0].Id)
+		_ = userUseCase.Follow(t.Context(), author, usecase.MockUsers[0].Id)
+		if follows := testutil.ToFloat64(metrics.Follows) - before; follows != 1 {
+			t.Errorf("Follow should count a follow once. Got: %v", follows)
+		}
+	})
+
+	t.Run("Should return ErrNotFound for an user that doesn't exist", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		err := userUseCase.Follow(t.Context(), usecase.UNKNOWN_USER_ID, usecase.MockUsers[0].Id)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Follow should return ErrNotFound for an unknown user. Got %v.", err)
+		}
+	})
+
 	t.Run("Should return bad connection error DB", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Follow(usecase.MockUsers[0].Id, usecase.MockUsers[1].Id)
+		err := userUseCase.Follow(t.Context(), usecase.USER_ERROR, usecase.MockUsers[1].Id)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("Folow should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
 	})
 }
 
+func TestBlock(t *testing.T) {
+	t.Run("Should return ErrOperationDenied if user blocks himself", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		err := userUseCase.Block(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[0].Id)
+		if !errors.Is(err, ErrOperationDenied) {
+			t.Errorf("Block should return ErrOperationDenied if user id is equal to blocked id. Got %v.", err)
+		}
+	})
+
+	t.Run("Should block and unblock an user", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		if err := userUseCase.Block(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id); err != nil {
+			t.Errorf("Block should not return an error. Error: %v", err)
+		}
+		if usecase.MockBlocks[usecase.MockUsers[0].Id] != usecase.MockUsers[1].Id {
+			t.Errorf("Block should register the block. Blocks: %v", usecase.MockBlocks)
+		}
+
+		if err := userUseCase.Unblock(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id); err != nil {
+			t.Errorf("Unblock should not return an error. Error: %v", err)
+		}
+		if _, ok := usecase.MockBlocks[usecase.MockUsers[0].Id]; ok {
+			t.Errorf("Unblock should remove the block. Blocks: %v", usecase.MockBlocks)
+		}
+	})
+
+	t.Run("Should return ErrNotFound for an user that doesn't exist", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		err := userUseCase.Block(t.Context(), usecase.MockUsers[0].Id, usecase.UNKNOWN_USER_ID)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Block should return ErrNotFound for an unknown user. Got %v.", err)
+		}
+		if _, ok := usecase.MockBlocks[usecase.MockUsers[0].Id]; ok {
+			t.Errorf("Block should not register the block. Blocks: %v", usecase.MockBlocks)
+		}
+	})
+}
+
+func TestMute(t *testing.T) {
+	t.Run("Should return ErrOperationDenied if user mutes himself", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		err := userUseCase.Mute(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[0].Id)
+		if !errors.Is(err, ErrOperationDenied) {
+			t.Errorf("Mute should return ErrOperationDenied if user id is equal to muted id. Got %v.", err)
+		}
+	})
+
+	t.Run("Should mute and unmute an user", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		if err := userUseCase.Mute(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id); err != nil {
+			t.Errorf("Mute should not return an error. Error: %v", err)
+		}
+		if usecase.MockMutes[usecase.MockUsers[0].Id] != usecase.MockUsers[1].Id {
+			t.Errorf("Mute should register the mute. Mutes: %v", usecase.MockMutes)
+		}
+
+		if err := userUseCase.Unmute(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id); err != nil {
+			t.Errorf("Unmute should not return an error. Error: %v", err)
+		}
+		if _, ok := usecase.MockMutes[usecase.MockUsers[0].Id]; ok {
+			t.Errorf("Unmute should remove the mute. Mutes: %v", usecase.MockMutes)
+		}
+	})
+
+	t.Run("Should return ErrNotFound for an user that doesn't exist", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		err := userUseCase.Mute(t.Context(), usecase.MockUsers[0].Id, usecase.UNKNOWN_USER_ID)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Mute should return ErrNotFound for an unknown user. Got %v.", err)
+		}
+	})
+}
+
+func TestFollowRequests(t *testing.T) {
+	t.Run("Should list and accept a pending follow request", func(t *testing.T) {
+		usecase.MockFollowRequests[usecase.MockUsers[0].Id] = usecase.MockUsers[1].Id
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+
+		requests, err := userUseCase.GetFollowRequests(t.Context(), usecase.MockUsers[0].Id)
+		if err != nil || len(requests) == 0 || requests[0].Id != usecase.MockUsers[1].Id {
+			t.Errorf("GetFollowRequests should return pending followers. Got: %v. Error: %v", requests, err)
+		}
+
+		if err = userUseCase.AcceptFollowRequest(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id); err != nil {
+			t.Errorf("AcceptFollowRequest should not return an error for a pending request. Error: %v", err)
+		}
+		if _, ok := usecase.MockFollowRequests[usecase.MockUsers[0].Id]; ok {
+			t.Errorf("AcceptFollowRequest should resolve the pending request")
+		}
+	})
+
+	t.Run("Should return ErrFollowRequestNotFound without a pending request", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		if err := userUseCase.AcceptFollowRequest(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id); !errors.Is(err, ErrFollowRequestNotFound) {
+			t.Errorf("AcceptFollowRequest should return ErrFollowRequestNotFound. Got: %v", err)
+		}
+		if err := userUseCase.RejectFollowRequest(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[1].Id); !errors.Is(err, ErrFollowRequestNotFound) {
+			t.Errorf("RejectFollowRequest should return ErrFollowRequestNotFound. Got: %v", err)
+		}
+	})
+}
+
+func TestGetRelationships(t *testing.T) {
+	t.Run("Should return relationships in the requested order", func(t *testing.T) {
+		unknownId := "00000000-0000-0000-0000-000000000000"
+		usecase.MockMutes[usecase.MockUsers[0].Id] = usecase.MockUsers[1].Id
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		relationships, err := userUseCase.GetRelationships(
+			t.Context(),
+			usecase.MockUsers[0].Id,
+			[]string{unknownId, usecase.MockUsers[1].Id},
+		)
+		if err != nil {
+			t.Fatalf("GetRelationships should not return an error for valid ids. Error: %v", err)
+		}
+
+		if len(relationships) != 2 {
+			t.Fatalf("GetRelationships should return one relationship per id. Got: %v", relationships)
+		}
+		if relationships[0] != (entity.Relationship{Id: unknownId}) {
+			t.Errorf("GetRelationships should return an empty relationship for an unknown id. Got: %v", relationships[0])
+		}
+		if relationships[1].Id != usecase.MockUsers[1].Id || !relationships[1].Following || !relationships[1].Muting {
+			t.Errorf("GetRelationships should return repository flags. Got: %v", relationships[1])
+		}
+
+		delete(usecase.MockMutes, usecase.MockUsers[0].Id)
+	})
+
+	t.Run("Should reject invalid ids", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		_, err := userUseCase.GetRelationships(t.Context(), usecase.MockUsers[0].Id, []string{"1; DROP TABLE users"})
+		var uve *errorType.ErrorUserValidation
+		if !errors.As(err, &uve) {
+			t.Errorf("GetRelationships should return ErrorUserValidation for invalid ids. Got: %v", err)
+		}
+	})
+}
+
+func TestGetSuggestions(t *testing.T) {
+	t.Run("Should rank suggestions by mutuals and explain them", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		suggestions, err := userUseCase.GetSuggestions(t.Context(), usecase.MockUsers[0].Id, 10)
+		if err != nil {
+			t.Fatalf("GetSuggestions should not return an error. Error: %v", err)
+		}
+
+		expected := []struct{ id, reason string }{
+			{"a", "followed by beltrano and 2 others"},
+			{"c", "followed by beltrano and 1 other"},
+			{"b", "followed by john"},
+		}
+		if len(suggestions) != len(expected) {
+			t.Fatalf("GetSuggestions should return all candidates. Got: %v", suggestions)
+		}
+		for i, suggestion := range suggestions {
+			if suggestion.Id != expected[i].id || suggestion.Reason != expected[i].reason {
+				t.Errorf("GetSuggestions position %d. Expected: %v. Got: %v (%v)", i, expected[i], suggestion.Id, suggestion.Reason)
+			}
+		}
+	})
+
+	t.Run("Should limit the number of suggestions", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		suggestions, _ := userUseCase.GetSuggestions(t.Context(), usecase.MockUsers[0].Id, 1)
+		if len(suggestions) != 1 || suggestions[0].Id != "a" {
+			t.Errorf("GetSuggestions should return only the best suggestion. Got: %v", suggestions)
+		}
+	})
+
+	t.Run("Should get a bad connection database error", func(t *testing.T) {
+		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
+		suggestions, err := userUseCase.GetSuggestions(t.Context(), usecase.USER_ERROR, 10)
+		if err == nil || suggestions != nil {
+			t.Errorf("GetSuggestions should return the repository error. Got: %v, %v", suggestions, err)
+		}
+	})
+}
+
+func TestRankSuggestions(t *testing.T) {
+	t.Run("Should favor recently active accounts", func(t *testing.T) {
+		now := time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC)
+		recent := now.Add(-time.Hour)
+		old := now.Add(-90 * 24 * time.Hour)
+		suggestions := []entity.Suggestion{
+			{Id: "inactive", Mutuals: 3},
+			{Id: "old", Mutuals: 3, LastActivity: &old},
+			{Id: "recent", Mutuals: 2, LastActivity: &recent},
+		}
+
+		ranked := rankSuggestions(suggestions, now)
+		if ranked[0].Id != "recent" || ranked[1].Id != "old" || ranked[2].Id != "inactive" {
+			t.Errorf("rankSuggestions should order by mutuals weighted by activity. Got: %v, %v, %v",
+				ranked[0].Id,
+				ranked[1].Id,
+				ranked[2].Id,
+			)
+		}
+	})
+}
+
 func TestUnfollow(t *testing.T) {
 	t.Run("Should return ErrOperationDenied if user id is equal to follower id", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Unfollow(usecase.MockUsers[0].Id, usecase.MockUsers[0].Id)
+		err := userUseCase.Unfollow(t.Context(), usecase.MockUsers[0].Id, usecase.MockUsers[0].Id)
 		if !errors.Is(err, ErrOperationDenied) {
 			t.Errorf("Unfollow should return ErrOperationDenied if user id is equal to follower id. Got %v.", err)
 		}
@@ -372,7 +711,7 @@ func TestUnfollow(t *testing.T) {
 
 	t.Run("Should return bad connection error DB", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Unfollow(usecase.MockUsers[0].Id, usecase.MockUsers[1].Id)
+		err := userUseCase.Unfollow(t.Context(), usecase.USER_ERROR, usecase.MockUsers[1].Id)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("Folow should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -384,7 +723,7 @@ func TestUpdatePassword(t *testing.T) {
 		passwordDto := dto.Password{New: "abc", Current: "123"}
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
 		oldPassword := usecase.MockUsers[0].Password
-		err := userUseCase.UpdatePassword(usecase.MockUsers[0].Id, passwordDto)
+		err := userUseCase.UpdatePassword(t.Context(), usecase.MockUsers[0].Id, passwordDto)
 		if err != nil {
 			t.Errorf("UpdatePassword should not return an error for a valid user and password. Error: %v", err)
 		} else if usecase.MockUsers[0].Password == oldPassword {
@@ -399,7 +738,7 @@ func TestUpdatePassword(t *testing.T) {
 		passwordDto := dto.Password{New: "abc", Current: "wrong-password"}
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
 		oldPassword := usecase.MockUsers[0].Password
-		err := userUseCase.UpdatePassword(usecase.MockUsers[0].Id, passwordDto)
+		err := userUseCase.UpdatePassword(t.Context(), usecase.MockUsers[0].Id, passwordDto)
 		if !errors.Is(err, ErrWrongPassword) {
 			t.Errorf("UpdatePassword should return ErrWrongPassword error for wrong password. Got: %v", err)
 		} else if usecase.MockUsers[0].Password != oldPassword {
@@ -413,11 +752,11 @@ func TestUpdatePassword(t *testing.T) {
 	t.Run("Should get error if userId doesn't exist", func(t *testing.T) {
 		passwordDto := dto.Password{New: "abc", Current: "123"}
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.UpdatePassword("wrong-id", passwordDto)
-		if !errors.Is(err, sql.ErrNoRows) {
-			t.Errorf("UpdatePassword should return ErrNoRows error for an Nonexistent id. Got: %v. Error expected: %v",
+		err := userUseCase.UpdatePassword(t.Context(), "wrong-id", passwordDto)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("UpdatePassword should return ErrNotFound error for an Nonexistent id. Got: %v. Error expected: %v",
 				err,
-				sql.ErrNoRows,
+				repository.ErrNotFound,
 			)
 		}
 	})
@@ -426,12 +765,12 @@ func TestUpdatePassword(t *testing.T) {
 func TestGetFollowers(t *testing.T) {
 	t.Run("Should get user followers", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		followers, err := userUseCase.GetFollowers(usecase.MockUsers[0].Id)
+		followers, err := userUseCase.GetFollowers(t.Context(), usecase.MockUsers[0].Id)
 		if err != nil {
 			t.Errorf("GetFollowers should not return an error for a valid user id. Error: %v", err)
 		}
 
-		expectedFollowers := []entity.User{usecase.MockUsers[1], usecase.MockUsers[2]}
+		expectedFollowers := []entity.User{usecase.Listed(usecase.MockUsers[1]), usecase.Listed(usecase.MockUsers[2])}
 		if !reflect.DeepEqual(followers, expectedFollowers) {
 			t.Errorf("GetFollowers should return user followers. Expected: %v. Got: %v", expectedFollowers, followers)
 		}
@@ -439,7 +778,7 @@ func TestGetFollowers(t *testing.T) {
 
 	t.Run("Should get an DB error", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		followers, err := userUseCase.GetFollowers(usecase.USER_ERROR)
+		followers, err := userUseCase.GetFollowers(t.Context(), usecase.USER_ERROR)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("GetFollowers should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -453,12 +792,12 @@ func TestGetFollowers(t *testing.T) {
 func TestGetFollowing(t *testing.T) {
 	t.Run("Should get user following", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		following, err := userUseCase.GetFollowing(usecase.MockUsers[0].Id)
+		following, err := userUseCase.GetFollowing(t.Context(), usecase.MockUsers[0].Id)
 		if err != nil {
 			t.Errorf("GetFollowing should not return an error for a valid user id. Error: %v", err)
 		}
 
-		expectedFollowing := []entity.User{usecase.MockUsers[1], usecase.MockUsers[2]}
+		expectedFollowing := []entity.User{usecase.Listed(usecase.MockUsers[1]), usecase.Listed(usecase.MockUsers[2])}
 		if !reflect.DeepEqual(following, expectedFollowing) {
 			t.Errorf("GetFollowing should return user followers. Expected: %v. Got: %v", expectedFollowing, following)
 		}
@@ -466,7 +805,7 @@ func TestGetFollowing(t *testing.T) {
 
 	t.Run("Should get an DB error", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		following, err := userUseCase.GetFollowing(usecase.USER_ERROR)
+		following, err := userUseCase.GetFollowing(t.Context(), usecase.USER_ERROR)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("GetFollowing should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -480,7 +819,7 @@ func TestGetFollowing(t *testing.T) {
 func TestDelete(t *testing.T) {
 	t.Run("Should get nil if delete an user", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Delete(usecase.MockUsers[2].Id)
+		err := userUseCase.Delete(t.Context(), usecase.MockUsers[2].Id)
 		if err != nil {
 			t.Errorf("Delete should not return an error if delete user. Error: %v", err)
 		}
@@ -488,7 +827,7 @@ func TestDelete(t *testing.T) {
 
 	t.Run("Should get an DB error", func(t *testing.T) {
 		userUseCase := NewUserUseCase(usecase.NewMockUserRepository())
-		err := userUseCase.Delete(usecase.USER_ERROR)
+		err := userUseCase.Delete(t.Context(), usecase.USER_ERROR)
 		if err.Error() != "driver: bad connection" {
 			t.Errorf("Delete should get a bad connection error. Expected: %v. Got: %v", "driver: bad connection", err)
 		}
@@ -0,0 +1,106 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+const maxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions too large")
+)
+
+type Result struct {
+	Data        []byte
+	Thumbnail   []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Sniff returns the content type detected from the first bytes of data, ignoring any client-declared type.
+func Sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// Process validates an uploaded image, strips its metadata (EXIF, text chunks) by re-encoding it and generates a
+// thumbnail whose longest side is at most thumbSize pixels.
+// JPEG orientation is applied to the pixels before the EXIF block is dropped so the photo still displays upright.
+func Process(data []byte, thumbSize int) (Result, error) {
+	contentType := Sniff(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Result{}, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return Result{}, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{ContentType: contentType}
+	var out bytes.Buffer
+
+	switch contentType {
+	case "image/jpeg":
+		img = applyOrientation(img, jpegOrientation(data))
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 90})
+	case "image/png":
+		err = png.Encode(&out, img)
+	case "image/gif":
+		// GIF has no EXIF; keep the original bytes so animations survive.
+		_, err = out.Write(data)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	result.Data = out.Bytes()
+	result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	result.Thumbnail, err = thumbnail(img, thumbSize)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result, nil
+}
+
+func thumbnail(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
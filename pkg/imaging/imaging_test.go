@@ -0,0 +1,88 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func newImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	return img
+}
+
+// exifSegment builds an APP1 segment holding a little-endian TIFF header with a single orientation entry.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte{
+		'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00,
+		0x01, 0x00,
+		0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, byte(orientation), 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+
+	return append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("Should strip EXIF and apply orientation on JPEG", func(t *testing.T) {
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, newImage(40, 20), nil)
+		data := append([]byte{0xFF, 0xD8}, exifSegment(6)...)
+		data = append(data, buf.Bytes()[2:]...)
+
+		result, err := Process(data, 10)
+		if err != nil {
+			t.Fatalf("Process should not return an error for a valid JPEG. Error: %v", err)
+		}
+		if bytes.Contains(result.Data, []byte("Exif")) {
+			t.Errorf("Process should remove EXIF data from image")
+		}
+		if result.Width != 20 || result.Height != 40 {
+			t.Errorf("Process should rotate image by orientation 6. Got: %vx%v", result.Width, result.Height)
+		}
+		if result.ContentType != "image/jpeg" {
+			t.Errorf("Process should sniff image/jpeg. Got: %v", result.ContentType)
+		}
+
+		thumb, err := jpeg.DecodeConfig(bytes.NewReader(result.Thumbnail))
+		if err != nil {
+			t.Fatalf("Thumbnail should be a valid JPEG. Error: %v", err)
+		}
+		if thumb.Width != 5 || thumb.Height != 10 {
+			t.Errorf("Thumbnail should fit in 10px keeping aspect ratio. Got: %vx%v", thumb.Width, thumb.Height)
+		}
+	})
+
+	t.Run("Should keep small PNG dimensions on thumbnail", func(t *testing.T) {
+		var buf bytes.Buffer
+		png.Encode(&buf, newImage(8, 6))
+
+		result, err := Process(buf.Bytes(), 320)
+		if err != nil {
+			t.Fatalf("Process should not return an error for a valid PNG. Error: %v", err)
+		}
+		thumb, _ := jpeg.DecodeConfig(bytes.NewReader(result.Thumbnail))
+		if thumb.Width != 8 || thumb.Height != 6 {
+			t.Errorf("Thumbnail should not upscale image. Got: %vx%v", thumb.Width, thumb.Height)
+		}
+	})
+
+	t.Run("Should reject non image content", func(t *testing.T) {
+		_, err := Process([]byte("<html><body>not an image</body></html>"), 320)
+		if !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("Process should return ErrUnsupportedType. Got: %v", err)
+		}
+	})
+}